@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	httplib "github.com/kunal768/cmpe202/http-lib"
 
 	"github.com/kunal768/cmpe202/chat-consumer/internal/config"
 	"github.com/kunal768/cmpe202/chat-consumer/internal/consumer"
@@ -84,6 +86,21 @@ func main() {
 		log.Fatalf("Failed to start message consumer: %v", err)
 	}
 
+	// Liveness/readiness probes for the worker
+	health := httplib.NewHealth(2 * time.Second)
+	health.Register("mongo", messageRepo.Ping)
+	health.Register("redis", presenceChecker.Ping)
+	health.Register("rabbitmq", messageConsumer.Ping)
+
+	mux := http.NewServeMux()
+	health.RegisterHealthRoutes(mux)
+	healthSrv := &http.Server{Addr: cfg.HealthAddr, Handler: mux}
+	go func() {
+		if err := healthSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Health server error: %v", err)
+		}
+	}()
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -93,19 +110,21 @@ func main() {
 	// Wait for shutdown signal
 	<-sigChan
 	log.Println("Shutdown signal received, stopping...")
+	health.SetDraining()
 
-	// Cancel context to stop consumer
-	cancel()
-
-	// Give some time for graceful shutdown
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
-	// Wait for shutdown or timeout
-	select {
-	case <-shutdownCtx.Done():
-		log.Println("Shutdown timeout reached")
-	default:
-		log.Println("Chat consumer stopped gracefully")
+	// Stop receiving deliveries and let the in-flight message finish before
+	// the deferred Close calls tear down RabbitMQ, Redis and MongoDB.
+	if err := messageConsumer.Stop(shutdownCtx); err != nil {
+		log.Printf("Error stopping message consumer: %v", err)
 	}
+	cancel()
+
+	if err := healthSrv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down health server: %v", err)
+	}
+
+	log.Println("Chat consumer stopped gracefully")
 }
@@ -2,14 +2,26 @@ module github.com/kunal768/cmpe202/chat-consumer
 
 go 1.23.0
 
+replace github.com/kunal768/cmpe202/http-lib => ../http-lib
+
 require (
 	github.com/joho/godotenv v1.5.1
+	github.com/kunal768/cmpe202/http-lib v0.0.0
 	github.com/rabbitmq/amqp091-go v1.10.0
 	github.com/redis/go-redis/v9 v9.14.1
 	go.mongodb.org/mongo-driver v1.14.0
 )
 
-require github.com/google/go-cmp v0.6.0 // indirect
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/pgx/v5 v5.7.6 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/sirupsen/logrus v1.9.3 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
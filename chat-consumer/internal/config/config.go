@@ -13,6 +13,7 @@ type Config struct {
 	RedisPassword     string
 	RedisDB           int
 	MongoURI          string
+	HealthAddr        string
 }
 
 func getenv(key string) string {
@@ -40,6 +41,14 @@ func getenvOptional(key string) string {
 	return os.Getenv(key)
 }
 
+// getenvDefault returns the environment variable value, or def when unset
+func getenvDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 func Load() Config {
 	return Config{
 		RabbitMQURL:       getenv("RABBITMQ_URL"),
@@ -48,5 +57,6 @@ func Load() Config {
 		RedisPassword:     getenvOptional("REDIS_PASSWORD"), // Optional: empty password is valid for Redis
 		RedisDB:           getenvInt("REDIS_DB"),
 		MongoURI:          getenv("MONGO_URI"),
+		HealthAddr:        getenvDefault("HEALTH_ADDR", ":8002"),
 	}
 }
@@ -28,6 +28,8 @@ type MessageConsumer struct {
 	closed              bool
 	notificationSent    map[string]time.Time // Track when we last sent notification for a user
 	notificationSentMu  sync.RWMutex         // Mutex for notificationSent map
+	consumerTag         string               // Tag used to cancel the AMQP consumer on shutdown
+	done                chan struct{}        // Closed when the consume loop has exited
 }
 
 // NewMessageConsumer creates a new message consumer
@@ -79,14 +81,16 @@ func NewMessageConsumer(
 		presenceChecker:  presenceChecker,
 		messagePublisher: messagePublisher,
 		notificationSent: make(map[string]time.Time), // Initialize map to prevent nil map panic
+		consumerTag:      fmt.Sprintf("chat-consumer-%d", time.Now().UnixNano()),
+		done:             make(chan struct{}),
 	}, nil
 }
 
 // Start begins consuming messages from the queue
 func (c *MessageConsumer) Start(ctx context.Context) error {
 	msgs, err := c.channel.Consume(
-		c.queueName, // queue
-		"",          // consumer
+		c.queueName,   // queue
+		c.consumerTag, // consumer
 		false,       // auto-ack
 		false,       // exclusive
 		false,       // no-local
@@ -99,8 +103,11 @@ func (c *MessageConsumer) Start(ctx context.Context) error {
 
 	log.Printf("Started consuming messages from queue: %s", c.queueName)
 
-	// Process messages in a goroutine
+	// Process messages in a goroutine. In-flight messages are processed with a
+	// context detached from ctx so that a shutdown never aborts a half-saved message.
+	processCtx := context.WithoutCancel(ctx)
 	go func() {
+		defer close(c.done)
 		for {
 			select {
 			case <-ctx.Done():
@@ -111,7 +118,7 @@ func (c *MessageConsumer) Start(ctx context.Context) error {
 					log.Println("Message channel closed")
 					return
 				}
-				c.processMessage(ctx, msg)
+				c.processMessage(processCtx, msg)
 			}
 		}
 	}()
@@ -376,6 +383,44 @@ func (c *MessageConsumer) sendUndeliveredNotification(ctx context.Context, recip
 	log.Printf("Notification sent to user %s: %d conversations with undelivered messages", recipientID, count)
 }
 
+// Stop cancels the AMQP consumer so the broker stops delivering new messages,
+// then waits for the message currently being processed to be acked or nacked.
+// Unacked deliveries are requeued by RabbitMQ once the channel closes.
+func (c *MessageConsumer) Stop(ctx context.Context) error {
+	c.mu.RLock()
+	closed := c.closed
+	c.mu.RUnlock()
+	if closed {
+		return nil
+	}
+
+	if err := c.channel.Cancel(c.consumerTag, false); err != nil {
+		return fmt.Errorf("failed to cancel consumer: %w", err)
+	}
+
+	select {
+	case <-c.done:
+		log.Println("Message consumer stopped; in-flight messages drained")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight messages: %w", ctx.Err())
+	}
+}
+
+// Ping reports whether the consumer still holds an open connection and channel
+func (c *MessageConsumer) Ping(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return fmt.Errorf("consumer is closed")
+	}
+	if c.conn.IsClosed() || c.channel.IsClosed() {
+		return fmt.Errorf("not connected to RabbitMQ")
+	}
+	return nil
+}
+
 // Close closes the consumer and connections
 func (c *MessageConsumer) Close() error {
 	c.mu.Lock()
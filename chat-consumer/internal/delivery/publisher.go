@@ -55,6 +55,11 @@ func (r *RedisMessagePublisher) PublishToUser(ctx context.Context, userID string
 	return subscribers, nil
 }
 
+// Ping checks that Redis is reachable
+func (r *RedisMessagePublisher) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Close closes the Redis client
 func (r *RedisMessagePublisher) Close() error {
 	if err := r.client.Close(); err != nil {
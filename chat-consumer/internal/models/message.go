@@ -25,7 +25,7 @@ type ChatMessage struct {
 	RecipientID string             `bson:"recipientId" json:"recipientId"` // target user
 	Content     string             `bson:"content" json:"content"`         // message text
 	Timestamp   time.Time          `bson:"timestamp" json:"timestamp"`     // server timestamp
-	Type        string             `bson:"type" json:"type"`               // "text" (extensible for images/files)
+	Type        string             `bson:"type" json:"type"`               // "text", or "offer" with a JSON offer event as content
 	Status      MessageStatus      `bson:"status" json:"status"`           // delivery status
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`     // when message was first created
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`     // when status was last updated
@@ -61,6 +61,11 @@ func (r *RedisPresenceChecker) IsOnline(ctx context.Context, userID string) (boo
 	return isOnline, nil
 }
 
+// Ping checks that Redis is reachable
+func (r *RedisPresenceChecker) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Close closes the Redis client
 func (r *RedisPresenceChecker) Close() error {
 	if err := r.client.Close(); err != nil {
@@ -179,6 +179,11 @@ func (r *MongoMessageRepository) GetConversationsWithUndeliveredCount(ctx contex
 	return len(senderIDs), nil
 }
 
+// Ping checks that MongoDB is reachable
+func (r *MongoMessageRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx, nil)
+}
+
 // Close closes the MongoDB connection
 func (r *MongoMessageRepository) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
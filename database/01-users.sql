@@ -5,6 +5,18 @@ CREATE EXTENSION IF NOT EXISTS pgcrypto;
 --               DROP TABLES & TYPES (to start fresh)           --
 ------------------------------------------------------------------
 -- Drop tables in order of dependency
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS listing_revisions;
+DROP TABLE IF EXISTS listing_chats;
+DROP TABLE IF EXISTS listing_views;
+DROP TABLE IF EXISTS course_books;
+DROP TABLE IF EXISTS courses;
+DROP TABLE IF EXISTS book_metadata;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS offers;
+DROP TABLE IF EXISTS listing_price_history;
+DROP TABLE IF EXISTS saved_search_matches;
+DROP TABLE IF EXISTS saved_searches;
 DROP TABLE IF EXISTS flagged_listings;
 DROP TABLE IF EXISTS listing_media;
 DROP TABLE IF EXISTS saved_listings;
@@ -14,6 +26,8 @@ DROP TABLE IF EXISTS listings;
 DROP TABLE IF EXISTS users;
 
 -- Drop custom types
+DROP TYPE IF EXISTS ITEM_CONDITION;
+DROP TYPE IF EXISTS OFFER_STATUS;
 DROP TYPE IF EXISTS FLAG_REASON;
 DROP TYPE IF EXISTS FLAG_STATUS;
 DROP TYPE IF EXISTS LISTING_STATUS;
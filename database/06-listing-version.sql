@@ -0,0 +1,5 @@
+-- Optimistic concurrency for listings
+-- version is bumped on every write and exposed to clients as the ETag;
+-- writes sent with If-Match only apply while the version is unchanged.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,46 @@
+-- Audit log of privileged actions
+-- Written by the orchestrator and listing-service whenever an admin deletes a
+-- user, resolves or deletes a flag, or edits or removes someone else's listing.
+-- Rows are never updated or deleted.
+
+CREATE TABLE IF NOT EXISTS audit_events (
+  id BIGSERIAL PRIMARY KEY,
+
+  -- Who performed the action. Not a foreign key so events outlive the actor.
+  actor_user_id UUID,
+  actor_role TEXT NOT NULL,
+
+  action TEXT NOT NULL,            -- e.g. user.delete, flag.update, listing.delete
+  target_type TEXT NOT NULL,       -- user | listing | flag | listing_media
+  target_id TEXT NOT NULL,
+  reason TEXT,                     -- required for destructive actions
+
+  -- Snapshots of the target before and after the change
+  before JSONB,
+  after JSONB,
+
+  correlation_id TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);
+
+-- Enforce append-only at the database level
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_events_append_only ON audit_events;
+CREATE TRIGGER trg_audit_events_append_only
+  BEFORE UPDATE OR DELETE ON audit_events
+  FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+DROP TRIGGER IF EXISTS trg_audit_events_no_truncate ON audit_events;
+CREATE TRIGGER trg_audit_events_no_truncate
+  BEFORE TRUNCATE ON audit_events
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
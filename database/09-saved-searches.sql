@@ -0,0 +1,50 @@
+-- Saved searches
+-- A saved search stores the filters of a listing search under a name. New or
+-- updated listings are matched against every saved search; each match is
+-- recorded once, pushed to the owner in real time and optionally emailed in
+-- a periodic digest.
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+  id BIGSERIAL PRIMARY KEY,
+
+  user_id UUID NOT NULL,
+  CONSTRAINT fk_saved_search_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(user_id)
+    ON DELETE CASCADE,
+
+  name TEXT NOT NULL,
+
+  -- Filters as sent by the client: query is typed web-search syntax,
+  -- keywords come from chat search
+  query TEXT,
+  keywords TEXT[],
+  category LISTING_CATEGORY,
+  status LISTING_STATUS,
+  min_price INTEGER,
+  max_price INTEGER,
+  sort TEXT,
+
+  -- websearch_to_tsquery input derived from query or keywords, used for matching
+  search_text TEXT,
+
+  email_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user ON saved_searches(user_id);
+
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+  saved_search_id BIGINT NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  matched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  emailed_at TIMESTAMPTZ,
+  PRIMARY KEY (saved_search_id, listing_id)
+);
+
+-- Pending digest rows
+CREATE INDEX IF NOT EXISTS idx_saved_search_matches_unemailed
+  ON saved_search_matches(matched_at) WHERE emailed_at IS NULL;
@@ -0,0 +1,16 @@
+-- Price history for listings
+-- One row per price change, written by listing-service in the same
+-- transaction as the update. notified_at is set once saved-listing holders
+-- have been alerted about a drop, so each drop is announced only once.
+
+CREATE TABLE IF NOT EXISTS listing_price_history (
+  id BIGSERIAL PRIMARY KEY,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  old_price INTEGER NOT NULL,
+  new_price INTEGER NOT NULL,
+  changed_by UUID,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  notified_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_price_history_listing ON listing_price_history(listing_id, changed_at DESC);
@@ -0,0 +1,11 @@
+-- Listing expiry
+-- Active (AVAILABLE/PENDING) listings are archived by listing-service once
+-- expires_at passes; owners push it back out by renewing. Existing listings
+-- get a fresh 30 days rather than expiring on deploy.
+-- expiry_reminded_at is set by the orchestrator once the owner was warned.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '30 days';
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS expiry_reminded_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_listings_active_expiry ON listings(expires_at)
+  WHERE status IN ('AVAILABLE', 'PENDING');
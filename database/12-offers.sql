@@ -0,0 +1,50 @@
+-- Offers and counter-offers
+-- A buyer offers an amount on a listing; the other party accepts, rejects or
+-- counters it. A counter closes the offer as COUNTERED and opens a new one
+-- from the countering party, linked through parent_offer_id. Accepting moves
+-- the listing to PENDING and reserves it for the buyer.
+
+DO $$ BEGIN
+  CREATE TYPE OFFER_STATUS AS ENUM ('PENDING', 'ACCEPTED', 'REJECTED', 'COUNTERED', 'WITHDRAWN', 'EXPIRED');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS reserved_for UUID REFERENCES users(user_id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS offers (
+  id BIGSERIAL PRIMARY KEY,
+
+  listing_id INTEGER NOT NULL,
+  CONSTRAINT fk_offer_listing
+    FOREIGN KEY (listing_id)
+    REFERENCES listings(id)
+    ON DELETE CASCADE,
+
+  buyer_id UUID NOT NULL,
+  CONSTRAINT fk_offer_buyer
+    FOREIGN KEY (buyer_id)
+    REFERENCES users(user_id)
+    ON DELETE CASCADE,
+
+  seller_id UUID NOT NULL,
+  CONSTRAINT fk_offer_seller
+    FOREIGN KEY (seller_id)
+    REFERENCES users(user_id)
+    ON DELETE CASCADE,
+
+  -- The party who made this offer: the buyer, or the seller for a counter
+  from_user_id UUID NOT NULL,
+  parent_offer_id BIGINT REFERENCES offers(id) ON DELETE SET NULL,
+
+  amount INTEGER NOT NULL CHECK (amount > 0),
+  message TEXT,
+  status OFFER_STATUS NOT NULL DEFAULT 'PENDING',
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- At most one open negotiation per buyer and listing
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offers_open ON offers(listing_id, buyer_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_offers_buyer ON offers(buyer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_offers_seller ON offers(seller_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_offers_pending_expiry ON offers(expires_at) WHERE status = 'PENDING';
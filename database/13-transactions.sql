@@ -0,0 +1,42 @@
+-- Transactions
+-- A transaction records a sale the seller confirmed to a specific buyer,
+-- with the final price. Title and category are copied from the listing so
+-- sales history, seller stats and analytics survive the listing being
+-- deleted. The buyer confirms receipt by setting received_at.
+
+CREATE TABLE IF NOT EXISTS transactions (
+  id BIGSERIAL PRIMARY KEY,
+
+  listing_id INTEGER,
+  CONSTRAINT fk_transaction_listing
+    FOREIGN KEY (listing_id)
+    REFERENCES listings(id)
+    ON DELETE SET NULL,
+
+  buyer_id UUID,
+  CONSTRAINT fk_transaction_buyer
+    FOREIGN KEY (buyer_id)
+    REFERENCES users(user_id)
+    ON DELETE SET NULL,
+
+  seller_id UUID,
+  CONSTRAINT fk_transaction_seller
+    FOREIGN KEY (seller_id)
+    REFERENCES users(user_id)
+    ON DELETE SET NULL,
+
+  -- The accepted offer the price came from, if any
+  offer_id BIGINT REFERENCES offers(id) ON DELETE SET NULL,
+
+  listing_title TEXT NOT NULL,
+  category LISTING_CATEGORY NOT NULL,
+  price INTEGER NOT NULL CHECK (price >= 0),
+  completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  received_at TIMESTAMPTZ,
+
+  -- A listing is sold once
+  UNIQUE (listing_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_buyer ON transactions(buyer_id, completed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_seller ON transactions(seller_id, completed_at DESC);
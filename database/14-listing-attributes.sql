@@ -0,0 +1,21 @@
+-- Structured listing attributes
+-- Every listing may carry an item condition, a campus pickup location and
+-- free-form tags. Category-specific attributes (ISBN, edition and course code
+-- for TEXTBOOK; brand, model and storage for GADGET) live in a flat JSONB
+-- object of string values, validated by listing-service.
+
+DO $$ BEGIN
+  CREATE TYPE ITEM_CONDITION AS ENUM ('NEW', 'LIKE_NEW', 'GOOD', 'FAIR', 'POOR');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS condition ITEM_CONDITION;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS pickup_location TEXT;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'
+  CHECK (jsonb_typeof(attributes) = 'object');
+
+-- Tag filters and the exact-match attributes (ISBN, course code, storage) use
+-- containment (@>)
+CREATE INDEX IF NOT EXISTS idx_listings_tags ON listings USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_listings_attributes ON listings USING GIN (attributes jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_listings_condition ON listings (condition);
@@ -0,0 +1,19 @@
+-- Book metadata cache
+-- Edition details looked up by ISBN-13 (Open Library by default). work_id
+-- groups the editions of one book so an ISBN search also finds listings of
+-- the other editions.
+
+CREATE TABLE IF NOT EXISTS book_metadata (
+  isbn TEXT PRIMARY KEY CHECK (isbn ~ '^[0-9]{13}$'),
+  work_id TEXT,
+  title TEXT NOT NULL,
+  authors TEXT[] NOT NULL DEFAULT '{}',
+  edition TEXT,
+  publisher TEXT,
+  publish_date TEXT,
+  cover_url TEXT,
+  fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_metadata_work ON book_metadata (work_id);
+CREATE INDEX IF NOT EXISTS idx_listings_isbn ON listings ((attributes->>'isbn'));
@@ -0,0 +1,27 @@
+-- Course catalog
+-- Courses and the textbooks they require or recommend, imported by admins
+-- from CSV. Listings match a course through these ISBNs (and the other
+-- editions of the same work, see book_metadata) or through their own
+-- course_code attribute.
+
+CREATE TABLE IF NOT EXISTS courses (
+  code TEXT PRIMARY KEY CHECK (code ~ '^[A-Z]{2,6}[0-9]{1,4}[A-Z]?$'),
+  title TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS course_books (
+  course_code TEXT NOT NULL,
+  CONSTRAINT fk_course_book_course
+    FOREIGN KEY (course_code)
+    REFERENCES courses(code)
+    ON DELETE CASCADE,
+
+  isbn TEXT NOT NULL CHECK (isbn ~ '^[0-9]{13}$'),
+  required BOOLEAN NOT NULL DEFAULT TRUE,
+
+  PRIMARY KEY (course_code, isbn)
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_books_isbn ON course_books (isbn);
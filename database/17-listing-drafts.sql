@@ -0,0 +1,12 @@
+-- Draft and scheduled listings
+-- A DRAFT listing is only visible to its owner. Setting publish_at schedules
+-- it: listing-service publishes due drafts as AVAILABLE, starting their expiry
+-- period and resetting created_at so they show up as new. publish_at is
+-- cleared once a listing is published.
+
+ALTER TYPE LISTING_STATUS ADD VALUE IF NOT EXISTS 'DRAFT';
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_listings_publish_at ON listings(publish_at)
+  WHERE publish_at IS NOT NULL;
@@ -0,0 +1,41 @@
+-- Listing engagement
+-- Views are de-duplicated per viewer in Redis by the orchestrator and flushed
+-- here in batches, one row per listing per hour. listing_chats records the
+-- first time a user contacts the seller of a listing. Together with
+-- saved_listings they feed the trending feed and the view counts sellers see.
+
+CREATE TABLE IF NOT EXISTS listing_views (
+  listing_id INTEGER NOT NULL,
+  CONSTRAINT fk_listing_views_listing
+    FOREIGN KEY (listing_id)
+    REFERENCES listings(id)
+    ON DELETE CASCADE,
+
+  -- Start of the hour the views were flushed in
+  bucket TIMESTAMPTZ NOT NULL,
+  views INTEGER NOT NULL CHECK (views > 0),
+
+  PRIMARY KEY (listing_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_views_bucket ON listing_views(bucket);
+
+CREATE TABLE IF NOT EXISTS listing_chats (
+  listing_id INTEGER NOT NULL,
+  CONSTRAINT fk_listing_chats_listing
+    FOREIGN KEY (listing_id)
+    REFERENCES listings(id)
+    ON DELETE CASCADE,
+
+  user_id UUID NOT NULL,
+  CONSTRAINT fk_listing_chats_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(user_id)
+    ON DELETE CASCADE,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (listing_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_chats_created ON listing_chats(created_at);
@@ -0,0 +1,12 @@
+-- Duplicate and repost detection
+-- listing-service stores a 64-bit SimHash of each listing's title,
+-- description and media URLs. Listings within a few bits of each other are
+-- near-duplicates. The fingerprint is also split into eight 8-bit bands (the
+-- band number in the high bits): listings within 6 bits share at least one
+-- band, so candidates are found through the GIN index before comparing whole
+-- fingerprints. Existing listings are fingerprinted on their next edit.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS fingerprint BIGINT;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS fingerprint_bands INTEGER[];
+
+CREATE INDEX IF NOT EXISTS idx_listings_fingerprint_bands ON listings USING GIN (fingerprint_bands);
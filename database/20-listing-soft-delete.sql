@@ -0,0 +1,11 @@
+-- Soft deletion
+-- Deleting a listing sets deleted_at instead of removing the row, so flags,
+-- media and saves stay as moderation evidence and the owner or an admin can
+-- restore it. Deleted listings are left out of every read. listing-service
+-- purges them, with their media blobs, once the retention window has passed.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS deleted_by UUID;
+
+CREATE INDEX IF NOT EXISTS idx_listings_deleted_at ON listings(deleted_at)
+  WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Listing edit history
+-- One row per listing update that changed something, written by
+-- listing-service in the same transaction as the update. changes maps each
+-- changed field to its old and new value; version is the listing version the
+-- edit produced. notified_at is set once buyers talking to the seller have
+-- been told about the edit, so each one is announced only once.
+
+CREATE TABLE IF NOT EXISTS listing_revisions (
+  id BIGSERIAL PRIMARY KEY,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  version INTEGER NOT NULL,
+  changes JSONB NOT NULL,
+  changed_by UUID,
+  changed_by_role TEXT,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  notified_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_revisions_listing ON listing_revisions(listing_id, changed_at DESC);
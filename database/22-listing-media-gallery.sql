@@ -0,0 +1,27 @@
+-- Ordered media galleries
+-- position orders a listing's media from 0; is_cover marks the image shown
+-- in list responses, at most one per listing. alt_text and caption are set
+-- by the seller; mime_type, width, height and size_bytes describe the file
+-- as uploaded and are NULL for media added before they were recorded.
+
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS is_cover BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS alt_text TEXT;
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS caption TEXT;
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS mime_type TEXT;
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS width INTEGER CHECK (width > 0);
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS height INTEGER CHECK (height > 0);
+ALTER TABLE listing_media ADD COLUMN IF NOT EXISTS size_bytes BIGINT CHECK (size_bytes > 0);
+
+-- Existing media keep the order they were added in, the first one as cover
+UPDATE listing_media m
+SET position = o.position, is_cover = (o.position = 0)
+FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY listing_id ORDER BY created_at, id) - 1 AS position
+  FROM listing_media
+) o
+WHERE m.id = o.id
+  AND NOT EXISTS (SELECT 1 FROM listing_media c WHERE c.listing_id = m.listing_id AND c.is_cover);
+
+CREATE INDEX IF NOT EXISTS idx_listing_media_position ON listing_media(listing_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_media_cover ON listing_media(listing_id) WHERE is_cover;
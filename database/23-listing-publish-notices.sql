@@ -0,0 +1,9 @@
+-- Announcing scheduled listings
+-- Listings published by listing-service's scheduler never pass through the
+-- orchestrator, so publish_notice_pending marks them until the orchestrator
+-- has run its listing watchers (saved search alerts and the like) for them.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS publish_notice_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_listings_publish_notice ON listings(id)
+  WHERE publish_notice_pending;
@@ -0,0 +1,11 @@
+-- Structured saved search filters
+-- Saved searches re-run the structured listing filters too: item condition,
+-- a pickup location substring, tags (all must match), a course (listings
+-- tagged with it or selling one of its books) and category attributes.
+
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS condition ITEM_CONDITION;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS pickup_location TEXT;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS course TEXT;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'
+  CHECK (jsonb_typeof(attributes) = 'object');
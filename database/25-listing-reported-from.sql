@@ -0,0 +1,6 @@
+-- Status before a report
+-- Flagging takes an AVAILABLE or PENDING listing off the market as REPORTED.
+-- reported_from keeps the status it had so a moderator releasing it puts a
+-- sale that was under way back to PENDING, with its buyer reservation.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS reported_from LISTING_STATUS;
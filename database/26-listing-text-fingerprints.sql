@@ -0,0 +1,10 @@
+-- Text fingerprints for the cross-seller check
+-- Media URLs name the uploading seller, so they never match between sellers
+-- and only make two sellers' listings look further apart. Listings nearly
+-- repeating another seller's are therefore found by a second SimHash of the
+-- title and description alone, banded like fingerprint.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS text_fingerprint BIGINT;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS text_fingerprint_bands INTEGER[];
+
+CREATE INDEX IF NOT EXISTS idx_listings_text_fingerprint_bands ON listings USING GIN (text_fingerprint_bands);
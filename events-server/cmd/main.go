@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -24,10 +25,11 @@ import (
 	wsx "github.com/kunal768/cmpe202/events-server/internal/ws"
 )
 
-// readConnection starts an HTTP listener that upgrades requests to gobwas/ws
-// websockets. Each upgraded connection is handled in its own goroutine.
+// readConnection builds the HTTP server that upgrades requests to gobwas/ws
+// websockets. Each upgraded connection is handled in its own goroutine and
+// tracked in conns so shutdown can wait for them.
 // onConnection and onClose are hooks you can customize.
-func readConnection(hub *wsx.Hub, pres presence.PresenceStore, authc auth.AuthClient, msgService *message.MessageService, cfg config.Config) {
+func readConnection(hub *wsx.Hub, pres presence.PresenceStore, authc auth.AuthClient, msgService *message.MessageService, cfg config.Config, health *httplib.Health, conns *sync.WaitGroup) *http.Server {
 	addr := cfg.Port
 	if addr == "" {
 		log.Fatal("PORT is not set")
@@ -55,7 +57,11 @@ func readConnection(hub *wsx.Hub, pres presence.PresenceStore, authc auth.AuthCl
 		}
 		log.Printf("WebSocket upgrade successful from %s", r.RemoteAddr)
 		// handle each websocket connection concurrently
-		go handleConn(conn, hub, pres, authc, msgService, cfg)
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			handleConn(conn, hub, pres, authc, msgService, cfg)
+		}()
 	})
 
 	// HTTP API endpoint for sending messages to WebSocket clients
@@ -66,36 +72,26 @@ func readConnection(hub *wsx.Hub, pres presence.PresenceStore, authc auth.AuthCl
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			httplib.WriteJSON(w, http.StatusBadRequest, map[string]string{
-				"error":   "Invalid request body",
-				"message": "Failed to decode request body",
-			})
+			httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 			return
 		}
 
 		if req.UserID == "" {
-			httplib.WriteJSON(w, http.StatusBadRequest, map[string]string{
-				"error":   "Validation error",
-				"message": "userId is required",
-			})
+			httplib.WriteProblem(w, httplib.ValidationProblem("userId is required",
+				httplib.FieldError{Field: "userId", Code: "required", Message: "userId is required"}))
 			return
 		}
 
 		if len(req.Message) == 0 {
-			httplib.WriteJSON(w, http.StatusBadRequest, map[string]string{
-				"error":   "Validation error",
-				"message": "message is required",
-			})
+			httplib.WriteProblem(w, httplib.ValidationProblem("message is required",
+				httplib.FieldError{Field: "message", Code: "required", Message: "message is required"}))
 			return
 		}
 
 		// Send message to user via WebSocket
 		if err := hub.SendMessageToUser(req.UserID, req.Message); err != nil {
 			log.Printf("Failed to send message to user %s: %v", req.UserID, err)
-			httplib.WriteJSON(w, http.StatusNotFound, map[string]string{
-				"error":   "User not connected",
-				"message": err.Error(),
-			})
+			httplib.WriteError(w, http.StatusNotFound, "User not connected", err.Error())
 			return
 		}
 
@@ -104,20 +100,18 @@ func readConnection(hub *wsx.Hub, pres presence.PresenceStore, authc auth.AuthCl
 		})
 	})
 
+	// Liveness and readiness probes
+	health.RegisterHealthRoutes(mux)
+
 	// Wrap mux with CORS middleware
-	handler := httplib.CORSMiddleware(mux)
+	handler := httplib.CORSMiddleware(httplib.CorrelationIDMiddleware(mux))
 
-	srv := &http.Server{
+	return &http.Server{
 		Addr:         addr,
 		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
-
-	log.Printf("websocket server listening on %s", addr)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("websocket ListenAndServe: %v", err)
-	}
 }
 
 // handleConn provides a minimal read loop using wsutil. Replace the loop body
@@ -174,28 +168,50 @@ func main() {
 	// Initialize message service
 	msgService := message.NewMessageService(publisher)
 
-	// Setup graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		log.Println("Shutting down...")
-		cancel()
-	}()
+	// Readiness depends on Redis (presence + pub/sub) and RabbitMQ
+	health := httplib.NewHealth(2 * time.Second)
+	health.Register("redis", func(ctx context.Context) error { return pres.Client.Ping(ctx).Err() })
+	health.Register("rabbitmq", publisher.Ping)
+
+	// Setup graceful shutdown on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var conns sync.WaitGroup
+	srv := readConnection(hub, pres, authc, msgService, cfg, health, &conns)
+
+	// Websockets are hijacked connections, so http.Server.Shutdown does not
+	// track them; close them explicitly once draining starts.
+	srv.RegisterOnShutdown(func() {
+		wsCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := hub.Shutdown(wsCtx); err != nil {
+			log.Printf("Error draining websocket clients: %v", err)
+		}
+	})
 
 	// Start the websocket listener
 	log.Printf("Events server listening on %s", cfg.Port)
 	log.Printf("RabbitMQ queue: %s", cfg.RabbitMQQueueName)
 
-	// Start server in goroutine
-	go readConnection(hub, pres, authc, msgService, cfg)
+	serveErr := httplib.ListenAndServeGracefully(ctx, srv, health, httplib.PreStopDelayFromEnv(), 15*time.Second)
+	if serveErr != nil {
+		log.Printf("websocket server error: %v", serveErr)
+	}
 
-	// Wait for shutdown signal
-	<-ctx.Done()
+	// Wait for connection goroutines (including unauthenticated ones, which
+	// time out on their auth deadline) so presence cleanup runs before Redis closes
+	log.Println("Waiting for websocket connections to close...")
+	done := make(chan struct{})
+	go func() {
+		conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		log.Println("Timed out waiting for websocket connections")
+	}
 
 	// Graceful shutdown
 	log.Println("Closing message service...")
@@ -208,5 +224,9 @@ func main() {
 		log.Printf("Error closing Redis subscriber: %v", err)
 	}
 
+	if serveErr != nil {
+		log.Println("Server did not shut down cleanly")
+		os.Exit(1)
+	}
 	log.Println("Server stopped")
 }
@@ -14,7 +14,7 @@ type ChatMessage struct {
 	RecipientID string    `json:"recipientId"` // target user
 	Content     string    `json:"content"`     // message text
 	Timestamp   time.Time `json:"timestamp"`   // server timestamp
-	Type        string    `json:"type"`        // "text" (extensible for images/files)
+	Type        string    `json:"type"`        // "text", or "offer" with a JSON offer event as content
 }
 
 // NewChatMessage creates a new ChatMessage with server-generated fields
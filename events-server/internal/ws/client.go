@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
 	"github.com/kunal768/cmpe202/events-server/internal/auth"
 	"github.com/kunal768/cmpe202/events-server/internal/message"
@@ -46,6 +47,18 @@ func (c *Client) Close(ctx context.Context) {
 	_ = c.conn.Close()
 }
 
+// CloseGoingAway tells the peer the server is shutting down (close code 1001)
+// and closes the connection. The read loop in Serve then exits and runs the
+// normal cleanup (presence offline, hub unregister).
+func (c *Client) CloseGoingAway() {
+	frame := ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusGoingAway, "server shutting down"))
+	_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := ws.WriteFrame(c.conn, frame); err != nil {
+		log.Printf("Failed to send close frame to %s: %v", c.ID, err)
+	}
+	_ = c.conn.Close()
+}
+
 func (c *Client) Serve(ctx context.Context) {
 	defer c.Close(ctx)
 
@@ -79,6 +79,38 @@ func (h *Hub) Get(userID string) (*Client, bool) {
 	return c, ok
 }
 
+// Shutdown sends a going-away close frame to every registered client and waits
+// until they have all unregistered or ctx expires.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for _, c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+
+	log.Printf("[Hub] Closing %d websocket connections", len(clients))
+	for _, c := range clients {
+		c.CloseGoingAway()
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		h.mu.RLock()
+		remaining := len(h.clients)
+		h.mu.RUnlock()
+		if remaining == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d websocket clients still registered: %w", remaining, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
 // SendMessageToUser sends a message to a specific user via WebSocket if they are connected
 func (h *Hub) SendMessageToUser(userID string, message []byte) error {
 	client, exists := h.Get(userID)
@@ -96,10 +128,11 @@ func (h *Hub) handleMessage(msg []byte) error {
 	
 	// First, check if this is a notification message
 	var notificationCheck struct {
-		Type        string `json:"type"`
-		SubType     string `json:"subType"`
-		Count       int    `json:"count"`
-		RecipientID string `json:"recipientId"`
+		Type        string          `json:"type"`
+		SubType     string          `json:"subType"`
+		Count       int             `json:"count"`
+		RecipientID string          `json:"recipientId"`
+		Data        json.RawMessage `json:"data"`
 	}
 	
 	if err := json.Unmarshal(msg, &notificationCheck); err == nil && notificationCheck.Type == "notification" {
@@ -115,6 +148,7 @@ func (h *Hub) handleMessage(msg []byte) error {
 			Type:    notificationCheck.Type,
 			SubType: notificationCheck.SubType,
 			Count:   notificationCheck.Count,
+			Data:    notificationCheck.Data,
 		}
 		if err := client.SendNotification(notifMsg); err != nil {
 			log.Printf("[Hub] Failed to send notification to user %s: %v", notificationCheck.RecipientID, err)
@@ -39,9 +39,10 @@ type AuthAckMessage struct {
 
 // NotificationMessage is sent by server to notify clients of events
 type NotificationMessage struct {
-	Type    string `json:"type"`    // "notification"
-	SubType string `json:"subType"` // "inbox"
-	Count   int    `json:"count"`   // number of undelivered messages
+	Type    string          `json:"type"`           // "notification"
+	SubType string          `json:"subType"`        // "inbox", "saved_search", "price_drop", "listing_expiring"
+	Count   int             `json:"count"`          // number of undelivered messages or new matches
+	Data    json.RawMessage `json:"data,omitempty"` // subType specific payload, passed through as is
 }
 
 func ParseMessage(b []byte) (string, any, error) {
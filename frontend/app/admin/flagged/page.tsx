@@ -11,10 +11,11 @@ import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, D
 import { Textarea } from "@/components/ui/textarea"
 import { Label } from "@/components/ui/label"
 import { Collapsible, CollapsibleContent, CollapsibleTrigger } from "@/components/ui/collapsible"
+import { ListingHistory } from "@/components/listing-history"
 import { useAuth } from "@/hooks/use-auth"
 import { orchestratorApi } from "@/lib/api/orchestrator"
-import type { FlaggedListing, FlagStatus, FlagReason, Listing } from "@/lib/api/types"
-import { AlertCircle, Flag, Clock, User, FileText, ArrowLeft, Edit, Trash2, ChevronDown } from "lucide-react"
+import type { FlaggedListing, FlagStatus, FlagReason, Listing, ListingRevision } from "@/lib/api/types"
+import { AlertCircle, Flag, Clock, User, FileText, ArrowLeft, Edit, Trash2, ChevronDown, History } from "lucide-react"
 import Link from "next/link"
 
 type GroupedFlaggedListing = {
@@ -45,7 +46,12 @@ export default function FlaggedListingsPage() {
   const [updating, setUpdating] = useState(false)
   const [deleteDialogOpen, setDeleteDialogOpen] = useState(false)
   const [deleting, setDeleting] = useState(false)
+  const [deleteReason, setDeleteReason] = useState("")
   const [expandedListings, setExpandedListings] = useState<Set<number>>(new Set())
+  const [historyListing, setHistoryListing] = useState<Listing | null>(null)
+  const [history, setHistory] = useState<ListingRevision[]>([])
+  const [historyLoading, setHistoryLoading] = useState(false)
+  const [historyError, setHistoryError] = useState<string | null>(null)
 
   useEffect(() => {
     if (typeof window !== "undefined") {
@@ -275,18 +281,39 @@ export default function FlaggedListingsPage() {
     }
   }
 
+  // Load the edit history of a listing to compare what reporters saw with
+  // what it says now
+  const handleOpenHistory = async (listing: Listing) => {
+    if (!token || !refreshToken) return
+
+    setHistoryListing(listing)
+    setHistory([])
+    setHistoryError(null)
+    try {
+      setHistoryLoading(true)
+      const revisions = await orchestratorApi.getListingHistory(token, refreshToken, listing.id)
+      setHistory(Array.isArray(revisions) ? revisions : [])
+    } catch (err) {
+      console.error("Error fetching listing history:", err)
+      setHistoryError(err instanceof Error ? err.message : "Failed to fetch listing history")
+    } finally {
+      setHistoryLoading(false)
+    }
+  }
+
   const handleOpenDeleteDialog = (flagged: FlaggedListing) => {
     setSelectedFlag(flagged)
+    setDeleteReason("")
     setDeleteDialogOpen(true)
   }
 
   const handleDeleteFlagListing = async () => {
-    if (!selectedFlag || !token || !refreshToken) return
+    if (!selectedFlag || !token || !refreshToken || !deleteReason.trim()) return
 
     try {
       setDeleting(true)
       setError(null)
-      await orchestratorApi.deleteFlagListing(token, refreshToken, selectedFlag.flag_id)
+      await orchestratorApi.deleteFlagListing(token, refreshToken, selectedFlag.flag_id, deleteReason.trim())
 
       // Refresh the flagged listings
       const status = statusFilter === "ALL" ? undefined : statusFilter
@@ -503,12 +530,16 @@ export default function FlaggedListingsPage() {
                             )}
                           </div>
                         </div>
-                        <div className="pt-2">
+                        <div className="pt-2 space-y-2">
                           <Link href={`/listing/${grouped.listing.id}`}>
                             <Button variant="outline" className="w-full">
                               View Listing
                             </Button>
                           </Link>
+                          <Button variant="outline" className="w-full" onClick={() => handleOpenHistory(grouped.listing)}>
+                            <History className="h-4 w-4 mr-2" />
+                            Edit History
+                          </Button>
                         </div>
                       </div>
                     </div>
@@ -667,6 +698,25 @@ export default function FlaggedListingsPage() {
         </DialogContent>
       </Dialog>
 
+      {/* Listing Edit History Dialog */}
+      <Dialog open={historyListing !== null} onOpenChange={(open) => !open && setHistoryListing(null)}>
+        <DialogContent className="sm:max-w-[600px] max-h-[80vh] overflow-y-auto">
+          <DialogHeader>
+            <DialogTitle>Edit History</DialogTitle>
+            <DialogDescription>
+              Every change made to {historyListing?.title ?? "this listing"}, newest first.
+            </DialogDescription>
+          </DialogHeader>
+          {historyLoading ? (
+            <p className="text-sm text-muted-foreground">Loading history...</p>
+          ) : historyError ? (
+            <p className="text-sm text-destructive">{historyError}</p>
+          ) : (
+            <ListingHistory revisions={history} sellerId={historyListing?.user_id} />
+          )}
+        </DialogContent>
+      </Dialog>
+
       {/* Delete Flag Dialog */}
       <Dialog open={deleteDialogOpen} onOpenChange={setDeleteDialogOpen}>
         <DialogContent className="sm:max-w-[500px]">
@@ -696,6 +746,17 @@ export default function FlaggedListingsPage() {
                     {selectedFlag.reason}
                   </Badge>
                 </div>
+                <div className="space-y-2">
+                  <Label htmlFor="delete-reason">Why are you deleting this flag?</Label>
+                  <Textarea
+                    id="delete-reason"
+                    value={deleteReason}
+                    onChange={(e) => setDeleteReason(e.target.value)}
+                    placeholder="Recorded in the audit log"
+                    rows={3}
+                    disabled={deleting}
+                  />
+                </div>
               </div>
             )}
           </div>
@@ -703,7 +764,7 @@ export default function FlaggedListingsPage() {
             <Button variant="outline" onClick={() => setDeleteDialogOpen(false)} disabled={deleting}>
               Cancel
             </Button>
-            <Button variant="destructive" onClick={handleDeleteFlagListing} disabled={deleting}>
+            <Button variant="destructive" onClick={handleDeleteFlagListing} disabled={deleting || !deleteReason.trim()}>
               {deleting ? "Deleting..." : "Delete"}
             </Button>
           </DialogFooter>
@@ -36,6 +36,7 @@ export default function AdminUsersPage() {
   const [editContactEmail, setEditContactEmail] = useState("")
   const [updating, setUpdating] = useState(false)
   const [deleting, setDeleting] = useState(false)
+  const [deleteReason, setDeleteReason] = useState("")
   const [userListings, setUserListings] = useState<Listing[]>([])
   const [listingsLoading, setListingsLoading] = useState(false)
   const [listingsError, setListingsError] = useState<string | null>(null)
@@ -238,13 +239,13 @@ export default function AdminUsersPage() {
 
   // Delete user
   const handleDeleteUser = async () => {
-    if (!selectedUser || !token || !refreshToken) return
+    if (!selectedUser || !token || !refreshToken || !deleteReason.trim()) return
 
     try {
       setDeleting(true)
       setError(null)
 
-      await orchestratorApi.deleteUser(token, refreshToken, selectedUser.user_id)
+      await orchestratorApi.deleteUser(token, refreshToken, selectedUser.user_id, deleteReason.trim())
 
       // Remove user from list
       setUsers((prev) => prev.filter((u) => u.user_id !== selectedUser.user_id))
@@ -442,6 +443,7 @@ export default function AdminUsersPage() {
                       className="flex-1"
                       onClick={() => {
                         setSelectedUser(user)
+                        setDeleteReason("")
                         setDeleteDialogOpen(true)
                       }}
                       disabled={user.user_id === authUser?.user_id}
@@ -683,13 +685,23 @@ export default function AdminUsersPage() {
                   <p className="text-sm text-muted-foreground">{selectedUser.email}</p>
                 </div>
               </div>
+              <div className="space-y-2 mt-4">
+                <Label htmlFor="delete-reason">Reason (recorded in the audit log)</Label>
+                <Input
+                  id="delete-reason"
+                  value={deleteReason}
+                  onChange={(e) => setDeleteReason(e.target.value)}
+                  placeholder="Why is this user being deleted?"
+                  disabled={deleting}
+                />
+              </div>
             </div>
           )}
           <DialogFooter>
             <Button variant="outline" onClick={() => setDeleteDialogOpen(false)} disabled={deleting}>
               Cancel
             </Button>
-            <Button variant="destructive" onClick={handleDeleteUser} disabled={deleting}>
+            <Button variant="destructive" onClick={handleDeleteUser} disabled={deleting || !deleteReason.trim()}>
               {deleting ? (
                 <>
                   <div className="mr-2 h-4 w-4 animate-spin rounded-full border-2 border-current border-t-transparent" />
@@ -427,6 +427,8 @@ export default function EditListingPage() {
                         <SelectContent>
                           {statuses
                             .filter((status) => status !== "All") // Remove "All" from edit options
+                            // Sales are recorded with a buyer, not by editing the listing
+                            .filter((status) => status !== "Sold" || formData.status === "Sold")
                             .map((status) => (
                               <SelectItem key={status} value={status}>
                                 {status}
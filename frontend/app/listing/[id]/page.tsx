@@ -42,11 +42,13 @@ import {
   Package,
   AlertTriangle,
   User as UserIcon,
+  Eye,
 } from "lucide-react"
 import Link from "next/link"
 import { useAuth } from "@/hooks/use-auth"
 import { orchestratorApi } from "@/lib/api/orchestrator"
-import type { Listing, FlagReason, ListingMedia, User } from "@/lib/api/types"
+import type { Listing, FlagReason, ListingMedia, User, SimilarListing } from "@/lib/api/types"
+import { ListingCard } from "@/components/listing-card"
 import { formatPrice, formatTimeAgo, mapCategoryToDisplay } from "@/lib/utils/listings"
 import { useToast } from "@/hooks/use-toast"
 import { getCachedMedia, setCachedMedia, invalidateMediaCache } from "@/lib/utils/media-cache"
@@ -76,6 +78,7 @@ export default function ListingDetailPage() {
   const [userListings, setUserListings] = useState<Listing[]>([])
   const [listingsLoading, setListingsLoading] = useState(false)
   const [listingsError, setListingsError] = useState<string | null>(null)
+  const [similarListings, setSimilarListings] = useState<SimilarListing[]>([])
   const { toast } = useToast()
 
   const listingId = params?.id ? parseInt(params.id as string, 10) : null
@@ -156,6 +159,21 @@ export default function ListingDetailPage() {
     fetchMedia()
   }, [isHydrated, isAuthenticated, token, refreshToken, listingId])
 
+  // Fetch "you might also like" recommendations
+  useEffect(() => {
+    if (!isHydrated || !isAuthenticated || !token || !refreshToken || !listingId || isNaN(listingId)) {
+      return
+    }
+
+    orchestratorApi
+      .getSimilarListings(token, refreshToken, listingId, 6)
+      .then(setSimilarListings)
+      .catch((err) => {
+        console.error("Error fetching similar listings:", err)
+        setSimilarListings([])
+      })
+  }, [isHydrated, isAuthenticated, token, refreshToken, listingId])
+
   // Check if user has already flagged this listing
   useEffect(() => {
     if (!isHydrated || !isAuthenticated || !token || !refreshToken || !listingId || isNaN(listingId) || !user) {
@@ -529,7 +547,7 @@ export default function ListingDetailPage() {
                 ) : (
                   <img
                     src={images[currentImageIndex] || "/placeholder.svg"}
-                    alt={listing.title}
+                    alt={mediaUrls[currentImageIndex]?.alt_text || listing.title}
                     className="h-full w-full object-cover transition-transform duration-700"
                     onError={(e) => {
                       // Fallback to placeholder if image fails to load
@@ -569,6 +587,9 @@ export default function ListingDetailPage() {
                   </>
                 )}
               </div>
+              {mediaUrls[currentImageIndex]?.caption && (
+                <p className="px-4 pt-3 text-sm text-muted-foreground">{mediaUrls[currentImageIndex].caption}</p>
+              )}
               {images.length > 1 && (
                 <div className="grid grid-cols-4 gap-2 p-4">
                   {images.map((image, index) => (
@@ -581,7 +602,7 @@ export default function ListingDetailPage() {
                     >
                       <img
                         src={image || "/placeholder.svg"}
-                        alt={`Thumbnail ${index + 1}`}
+                        alt={mediaUrls[index]?.alt_text || `Thumbnail ${index + 1}`}
                         className="h-full w-full object-cover"
                       />
                     </button>
@@ -629,6 +650,23 @@ export default function ListingDetailPage() {
                 </ul>
               </CardContent>
             </Card>
+
+            {similarListings.length > 0 && (
+              <div className="mt-6 scroll-reveal">
+                <h2 className="mb-4 text-2xl font-bold text-foreground">You might also like</h2>
+                <div className="grid grid-cols-1 gap-6 sm:grid-cols-2 xl:grid-cols-3">
+                  {similarListings.map((similar, index) => (
+                    <ListingCard
+                      key={similar.id}
+                      listing={similar}
+                      token={token!}
+                      refreshToken={refreshToken}
+                      index={index}
+                    />
+                  ))}
+                </div>
+              </div>
+            )}
           </div>
 
           {/* Right Column - Purchase Info */}
@@ -654,6 +692,14 @@ export default function ListingDetailPage() {
                       <Clock className="h-5 w-5 text-primary" />
                       <span>Posted {formatTimeAgo(listing.created_at)}</span>
                     </div>
+                    {isOwner && listing.views !== undefined && (
+                      <div className="flex items-center gap-3 text-muted-foreground">
+                        <Eye className="h-5 w-5 text-primary" />
+                        <span>
+                          {listing.views} {listing.views === 1 ? "view" : "views"}
+                        </span>
+                      </div>
+                    )}
                     <div className="flex items-center gap-3 text-muted-foreground">
                       <span className="text-xs font-mono">ID: {listing.id}</span>
                     </div>
@@ -674,7 +720,14 @@ export default function ListingDetailPage() {
                       <Button
                         className="w-full h-14 text-base font-semibold magnetic-button"
                         size="lg"
-                        onClick={() => {
+                        onClick={async () => {
+                          if (token) {
+                            try {
+                              await orchestratorApi.contactSeller(token, refreshToken, listing.id)
+                            } catch (error) {
+                              console.error("Failed to record seller contact:", error)
+                            }
+                          }
                           sessionStorage.setItem('openConversationWith', listing.user_id)
                           router.push('/messages')
                         }}
@@ -18,7 +18,7 @@ import { useWebSocket } from "@/contexts/websocket-context"
 import { useUnreadCount } from "@/hooks/use-unread-count"
 import { orchestratorApi } from "@/lib/api/orchestrator"
 import { getCurrentUserId } from "@/lib/utils/jwt"
-import type { User } from "@/lib/api/types"
+import type { User, OfferEvent } from "@/lib/api/types"
 
 interface Conversation {
   otherUserId: string
@@ -42,6 +42,16 @@ interface ChatMessage {
   isRead?: boolean
 }
 
+// Offer messages carry a JSON OfferEvent; show its summary as the text
+function messageText(message: { type: string; content: string }): string {
+  if (message.type !== "offer") return message.content
+  try {
+    return (JSON.parse(message.content) as OfferEvent).text || message.content
+  } catch {
+    return message.content
+  }
+}
+
 export default function MessagesPage() {
   const router = useRouter()
   const { user, token, refreshToken, isAuthenticated, isHydrated } = useAuth()
@@ -238,7 +248,7 @@ export default function MessagesPage() {
               c.otherUserId === otherUserId
                 ? {
                     ...c,
-                    lastMessage: chatMessage.content,
+                    lastMessage: messageText(chatMessage),
                     lastTimestamp: chatMessage.timestamp,
                     unreadCount: isCurrentlySelected ? 0 : c.unreadCount + 1,
                     isLastFromMe: false,
@@ -251,7 +261,7 @@ export default function MessagesPage() {
             {
               otherUserId,
               otherUserName: undefined,
-              lastMessage: chatMessage.content,
+              lastMessage: messageText(chatMessage),
               lastTimestamp: chatMessage.timestamp,
               unreadCount: isCurrentlySelected ? 0 : 1,
               isLastFromMe: false,
@@ -563,7 +573,9 @@ export default function MessagesPage() {
                                 isOwn ? "bg-primary text-primary-foreground" : "bg-muted text-foreground"
                               }`}
                             >
-                              <p className="text-sm">{message.content}</p>
+                              <p className={`text-sm ${message.type === "offer" ? "font-medium" : ""}`}>
+                                {messageText(message)}
+                              </p>
                             </div>
                             <div className="flex items-center gap-1 mt-1 px-2">
                               <p className="text-xs text-muted-foreground">{formatMessageTime(message.timestamp)}</p>
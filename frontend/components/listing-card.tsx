@@ -45,6 +45,12 @@ export function ListingCard({ listing, token, refreshToken, index }: ListingCard
   }, [])
 
   const fetchMedia = async () => {
+    // List responses carry the cover image, so most cards need no request
+    if (listing.cover) {
+      setMediaUrl(listing.cover.media_url)
+      return
+    }
+
     // Check cache first
     const cached = getCachedMedia(listing.id)
     if (cached && cached.length > 0) {
@@ -82,7 +88,7 @@ export function ListingCard({ listing, token, refreshToken, index }: ListingCard
             ) : (
               <img
                 src={mediaUrl || "/placeholder.svg"}
-                alt={listing.title}
+                alt={listing.cover?.alt_text || listing.title}
                 className="h-full w-full object-cover transition-transform duration-500 hover:scale-110"
                 onError={(e) => {
                   // Fallback to placeholder if image fails to load
@@ -0,0 +1,74 @@
+"use client"
+
+import { Badge } from "@/components/ui/badge"
+import type { ListingRevision } from "@/lib/api/types"
+import { formatPrice, formatTimeAgo } from "@/lib/utils/listings"
+
+interface ListingHistoryProps {
+  revisions: ListingRevision[]
+  sellerId?: string
+}
+
+const fieldLabels: Record<string, string> = {
+  title: "Title",
+  description: "Description",
+  price: "Price",
+  category: "Category",
+  status: "Status",
+  condition: "Condition",
+  pickup_location: "Pickup location",
+  tags: "Tags",
+  attributes: "Details",
+  publish_at: "Scheduled for",
+}
+
+function formatValue(field: string, value: unknown): string {
+  if (value === null || value === undefined || value === "") return "—"
+  if (field === "price" && typeof value === "number") return formatPrice(value)
+  if (field === "publish_at" && typeof value === "string") return new Date(value).toLocaleString()
+  if (Array.isArray(value)) return value.length > 0 ? value.join(", ") : "—"
+  if (typeof value === "object") {
+    const entries = Object.entries(value as Record<string, unknown>)
+    return entries.length > 0 ? entries.map(([k, v]) => `${k}: ${v}`).join(", ") : "—"
+  }
+  return String(value)
+}
+
+// Shows each edit of a listing as the old and new value of every field it
+// changed, newest edit first
+export function ListingHistory({ revisions, sellerId }: ListingHistoryProps) {
+  if (revisions.length === 0) {
+    return <p className="text-sm text-muted-foreground">This listing has not been edited.</p>
+  }
+
+  return (
+    <div className="space-y-4">
+      {revisions.map((revision) => (
+        <div key={revision.id} className="rounded-md border p-3">
+          <div className="mb-2 flex flex-wrap items-center gap-2 text-xs text-muted-foreground">
+            <Badge variant="outline">v{revision.version}</Badge>
+            <span title={new Date(revision.changed_at).toLocaleString()}>{formatTimeAgo(revision.changed_at)}</span>
+            {revision.changed_by && revision.changed_by !== sellerId && (
+              <Badge variant="secondary">Edited by {revision.changed_by_role === "0" ? "an admin" : revision.changed_by}</Badge>
+            )}
+          </div>
+          <div className="space-y-2">
+            {Object.entries(revision.changes).map(([field, change]) => (
+              <div key={field} className="text-sm">
+                <span className="font-medium">{fieldLabels[field] ?? field}</span>
+                <div className="mt-1 grid gap-1">
+                  <p className="rounded bg-destructive/10 px-2 py-1 text-xs line-through whitespace-pre-wrap break-words">
+                    {formatValue(field, change.old)}
+                  </p>
+                  <p className="rounded bg-green-500/10 px-2 py-1 text-xs whitespace-pre-wrap break-words">
+                    {formatValue(field, change.new)}
+                  </p>
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+      ))}
+    </div>
+  )
+}
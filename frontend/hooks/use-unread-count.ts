@@ -120,8 +120,8 @@ export function useUnreadCount(
 
   // Listen to WebSocket notifications and refresh
   useEffect(() => {
-    if (notification) {
-      // WebSocket notification received, refresh conversations
+    if (notification?.subType === 'inbox') {
+      // WebSocket inbox notification received, refresh conversations
       refreshUnreadCount()
     }
   }, [notification, refreshUnreadCount])
@@ -24,6 +24,20 @@ import type {
   ChatSearchResponse,
   ChatMessage,
   AnalyticsResponse,
+  Offer,
+  OfferAction,
+  RespondOfferResponse,
+  FetchOffersResponse,
+  Transaction,
+  SellerStats,
+  ItemCondition,
+  BookPrefill,
+  Course,
+  CourseImportResult,
+  ImportReport,
+  TrendingListing,
+  SimilarListing,
+  ListingRevision,
 } from "./types"
 import { isTokenExpired } from "@/lib/utils/jwt"
 
@@ -61,12 +75,12 @@ async function refreshAccessToken(
 
       if (!response.ok) {
         const error: ErrorResponse = await response.json().catch(() => ({
-          error: "Unknown error",
-          message: `HTTP ${response.status}: ${response.statusText}`,
+          title: "Unknown error",
+          detail: `HTTP ${response.status}: ${response.statusText}`,
         }))
         
         // Handle expired refresh token gracefully - return null instead of throwing
-        if (response.status === 401 || error.message?.toLowerCase().includes('expired')) {
+        if (response.status === 401 || (error.detail || error.message)?.toLowerCase().includes('expired')) {
           console.debug('[API] Refresh token expired')
           // Clear expired tokens from localStorage
           if (typeof window !== 'undefined') {
@@ -79,7 +93,7 @@ async function refreshAccessToken(
           return null
         }
         
-        throw new Error(error.message || error.error || "Token refresh failed")
+        throw new Error(error.detail || error.title || error.message || error.error || "Token refresh failed")
       }
 
       const data: RefreshTokenResponse = await response.json()
@@ -168,23 +182,23 @@ async function handleResponse<T>(
           error = JSON.parse(text)
         } catch {
           error = {
-            error: "Parse Error",
-            message: `HTTP ${response.status}: ${response.statusText}. Response: ${text.substring(0, 100)}`,
+            title: "Parse Error",
+            detail: `HTTP ${response.status}: ${response.statusText}. Response: ${text.substring(0, 100)}`,
           }
         }
       } else {
         error = {
-          error: "Unknown error",
-          message: `HTTP ${response.status}: ${response.statusText}`,
+          title: "Unknown error",
+          detail: `HTTP ${response.status}: ${response.statusText}`,
         }
       }
     } catch {
       error = {
-        error: "Unknown error",
-        message: `HTTP ${response.status}: ${response.statusText}`,
+        title: "Unknown error",
+        detail: `HTTP ${response.status}: ${response.statusText}`,
       }
     }
-    throw new Error(error.message || error.error || "Request failed")
+    throw new Error(error.detail || error.title || error.message || error.error || "Request failed")
   }
   
   // Parse successful response
@@ -240,6 +254,38 @@ async function getValidToken(refreshToken: string | null): Promise<string | null
   return token
 }
 
+/**
+ * Sends an authenticated change to a listing's media gallery, retrying once
+ * with a refreshed token
+ */
+async function mediaRequest<T>(
+  token: string,
+  refreshToken: string | null,
+  method: string,
+  path: string,
+  body?: unknown,
+): Promise<T> {
+  const validToken = (await getValidToken(refreshToken)) || token
+  const url = `${ORCHESTRATOR_URL}${path}`
+
+  const makeRequest = (accessToken: string) =>
+    fetch(url, {
+      method,
+      headers: {
+        Authorization: `Bearer ${accessToken}`,
+        "Content-Type": "application/json",
+      },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    })
+
+  const response = await makeRequest(validToken)
+
+  return handleResponse<T>(response, refreshToken, tokenUpdateCallback || undefined, async () => {
+    const newToken = await getValidToken(refreshToken)
+    return makeRequest(newToken || validToken)
+  })
+}
+
 export const orchestratorApi = {
   async signup(data: SignupRequest): Promise<SignupResponse> {
     const response = await fetch(`${ORCHESTRATOR_URL}/api/users/signup`, {
@@ -419,11 +465,17 @@ export const orchestratorApi = {
     })
   },
 
-  async deleteUser(token: string, refreshToken: string | null, userId: string): Promise<{ message: string }> {
+  async deleteUser(
+    token: string,
+    refreshToken: string | null,
+    userId: string,
+    reason: string,
+  ): Promise<{ message: string }> {
     const validToken = (await getValidToken(refreshToken)) || token
+    const url = `${ORCHESTRATOR_URL}/api/users/${userId}?reason=${encodeURIComponent(reason)}`
 
     const makeRequest = () =>
-      fetch(`${ORCHESTRATOR_URL}/api/users/${userId}`, {
+      fetch(url, {
         method: "DELETE",
         headers: {
           Authorization: `Bearer ${validToken}`,
@@ -435,7 +487,7 @@ export const orchestratorApi = {
 
     return handleResponse<{ message: string }>(response, refreshToken, tokenUpdateCallback || undefined, async () => {
       const newToken = await getValidToken(refreshToken)
-      return fetch(`${ORCHESTRATOR_URL}/api/users/${userId}`, {
+      return fetch(url, {
         method: "DELETE",
         headers: {
           Authorization: `Bearer ${newToken || validToken}`,
@@ -550,12 +602,39 @@ export const orchestratorApi = {
     if (filters?.max_price !== undefined) {
       params.set("max_price", filters.max_price.toString())
     }
+    if (filters?.condition) {
+      params.set("condition", filters.condition)
+    }
+    if (filters?.pickup_location) {
+      params.set("pickup_location", filters.pickup_location)
+    }
+    if (filters?.tags?.length) {
+      params.set("tags", filters.tags.join(","))
+    }
+    for (const [key, value] of Object.entries(filters?.attributes ?? {})) {
+      params.set(`attr.${key}`, value)
+    }
+    if (filters?.course) {
+      params.set("course", filters.course)
+    }
     if (filters?.limit !== undefined) {
       params.set("limit", filters.limit.toString())
     }
     if (filters?.offset !== undefined) {
       params.set("offset", filters.offset.toString())
     }
+    if (filters?.cursor) {
+      params.set("cursor", filters.cursor)
+    }
+    if (filters?.include_count !== undefined) {
+      params.set("include_count", String(filters.include_count))
+    }
+    if (filters?.facets) {
+      params.set("facets", filters.facets)
+    }
+    if (filters?.price_buckets) {
+      params.set("price_buckets", filters.price_buckets)
+    }
     if (filters?.sort) {
       params.set("sort", filters.sort)
     }
@@ -832,6 +911,13 @@ export const orchestratorApi = {
       description?: string
       price: number
       category: string
+      condition?: ItemCondition
+      pickup_location?: string
+      tags?: string[]
+      attributes?: Record<string, string>
+      // Create as a DRAFT; a future publish_at (ISO time) also publishes it then
+      draft?: boolean
+      publish_at?: string
     },
   ): Promise<Listing> {
     const validToken = (await getValidToken(refreshToken)) || token
@@ -843,6 +929,10 @@ export const orchestratorApi = {
       description: listing.description || undefined,
       price: listing.price,
       category: listing.category,
+      condition: listing.condition,
+      pickup_location: listing.pickup_location || undefined,
+      tags: listing.tags,
+      attributes: listing.attributes,
     }
 
     const makeRequest = () =>
@@ -880,12 +970,15 @@ export const orchestratorApi = {
     refreshToken: string | null,
     listingId: number,
     hardDelete?: boolean,
+    reason?: string,
   ): Promise<{ status: string }> {
     const validToken = (await getValidToken(refreshToken)) || token
 
-    const url = hardDelete
-      ? `${ORCHESTRATOR_URL}/api/listings/delete/${listingId}?hard=true`
-      : `${ORCHESTRATOR_URL}/api/listings/delete/${listingId}`
+    const params = new URLSearchParams()
+    if (hardDelete) params.set("hard", "true")
+    if (reason) params.set("reason", reason)
+    const query = params.toString()
+    const url = `${ORCHESTRATOR_URL}/api/listings/delete/${listingId}${query ? `?${query}` : ""}`
 
     const makeRequest = () =>
       fetch(url, {
@@ -915,6 +1008,114 @@ export const orchestratorApi = {
     )
   },
 
+  /**
+   * Renew a listing's expiry, relisting it if it was archived
+   */
+  async renewListing(token: string, refreshToken: string | null, listingId: number): Promise<Listing> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/renew/${listingId}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Listing>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  async restoreListing(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    reason?: string,
+  ): Promise<Listing> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const query = reason ? `?${new URLSearchParams({ reason }).toString()}` : ""
+    const url = `${ORCHESTRATOR_URL}/api/listings/restore/${listingId}${query}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Listing>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  async getDeletedListings(token: string, refreshToken: string | null): Promise<Listing[]> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/deleted`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Listing[]>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
   async updateListing(
     token: string,
     refreshToken: string | null,
@@ -925,6 +1126,14 @@ export const orchestratorApi = {
       price?: number
       category?: string
       status?: string
+      condition?: ItemCondition
+      // An empty string clears it
+      pickup_location?: string
+      tags?: string[]
+      // Changing the category without new attributes clears them
+      attributes?: Record<string, string>
+      // Reschedules a DRAFT; set status to AVAILABLE to publish it now
+      publish_at?: string
     },
   ): Promise<Listing> {
     const validToken = (await getValidToken(refreshToken)) || token
@@ -937,6 +1146,10 @@ export const orchestratorApi = {
       price?: number
       category?: string
       status?: string
+      condition?: ItemCondition
+      pickup_location?: string
+      tags?: string[]
+      attributes?: Record<string, string>
     } = {}
 
     if (updates.title !== undefined) body.title = updates.title
@@ -944,6 +1157,10 @@ export const orchestratorApi = {
     if (updates.price !== undefined) body.price = updates.price
     if (updates.category !== undefined) body.category = updates.category
     if (updates.status !== undefined) body.status = updates.status
+    if (updates.condition !== undefined) body.condition = updates.condition
+    if (updates.pickup_location !== undefined) body.pickup_location = updates.pickup_location
+    if (updates.tags !== undefined) body.tags = updates.tags
+    if (updates.attributes !== undefined) body.attributes = updates.attributes
 
     const makeRequest = () =>
       fetch(url, {
@@ -1042,10 +1259,11 @@ export const orchestratorApi = {
     token: string,
     refreshToken: string | null,
     flagId: number,
+    reason: string,
   ): Promise<DeleteFlagListingResponse> {
     const validToken = (await getValidToken(refreshToken)) || token
 
-    const url = `${ORCHESTRATOR_URL}/api/listings/flag/${flagId}`
+    const url = `${ORCHESTRATOR_URL}/api/listings/flag/${flagId}?reason=${encodeURIComponent(reason)}`
 
     const makeRequest = () =>
       fetch(url, {
@@ -1332,6 +1550,59 @@ export const orchestratorApi = {
     )
   },
 
+  /**
+   * Delete a single media item of a listing by ID
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @param listingId - Listing ID
+   * @param mediaId - Media ID
+   * @returns Success response
+   */
+  async deleteListingMediaById(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    mediaId: number,
+  ): Promise<{ message: string; version: number }> {
+    return mediaRequest(token, refreshToken, "DELETE", `/api/listings/media/${listingId}/${mediaId}`)
+  },
+
+  /**
+   * Set the gallery order of a listing's media
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @param listingId - Listing ID
+   * @param mediaIds - Every media ID of the listing, in the new order
+   * @returns Success response
+   */
+  async reorderListingMedia(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    mediaIds: number[],
+  ): Promise<{ message: string; version: number }> {
+    return mediaRequest(token, refreshToken, "PUT", `/api/listings/media/${listingId}/order`, {
+      media_ids: mediaIds,
+    })
+  },
+
+  /**
+   * Make a media item the cover image of its listing
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @param listingId - Listing ID
+   * @param mediaId - Media ID
+   * @returns Success response
+   */
+  async setListingCover(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    mediaId: number,
+  ): Promise<{ message: string; version: number }> {
+    return mediaRequest(token, refreshToken, "POST", `/api/listings/media/${listingId}/${mediaId}/cover`)
+  },
+
   /**
    * Merged: New function from the second file
    * Searches for chat conversations based on a query.
@@ -1468,24 +1739,24 @@ export const orchestratorApi = {
   },
 
   /**
-   * Unsave a listing for the current user
+   * Record that the current user is contacting the seller of a listing
    * @param token - Access token
    * @param refreshToken - Refresh token
-   * @param listingId - Listing ID to unsave
-   * @returns Success response
+   * @param listingId - Listing ID the user is asking about
+   * @returns The seller to open a conversation with
    */
-  async unsaveListing(
+  async contactSeller(
     token: string,
     refreshToken: string | null,
     listingId: number,
-  ): Promise<{ message: string }> {
+  ): Promise<{ seller_id: string }> {
     const validToken = (await getValidToken(refreshToken)) || token
 
-    const url = `${ORCHESTRATOR_URL}/api/listings/save/${listingId}`
+    const url = `${ORCHESTRATOR_URL}/api/listings/contact/${listingId}`
 
     const makeRequest = () =>
       fetch(url, {
-        method: "DELETE",
+        method: "POST",
         headers: {
           Authorization: `Bearer ${validToken}`,
           "Content-Type": "application/json",
@@ -1494,14 +1765,14 @@ export const orchestratorApi = {
 
     const response = await makeRequest()
 
-    return handleResponse<{ message: string }>(
+    return handleResponse<{ seller_id: string }>(
       response,
       refreshToken,
       tokenUpdateCallback || undefined,
       async () => {
         const newToken = await getValidToken(refreshToken)
         return fetch(url, {
-          method: "DELETE",
+          method: "POST",
           headers: {
             Authorization: `Bearer ${newToken || validToken}`,
             "Content-Type": "application/json",
@@ -1512,20 +1783,23 @@ export const orchestratorApi = {
   },
 
   /**
-   * Check if a listing is saved by the current user
+   * Get the listings ranked by recent views, saves and chats started
    * @param token - Access token
    * @param refreshToken - Refresh token
-   * @param listingId - Listing ID to check
-   * @returns Boolean indicating if listing is saved
+   * @param params - Optional category and limit
+   * @returns Trending listings, highest score first
    */
-  async isListingSaved(
+  async getTrendingListings(
     token: string,
     refreshToken: string | null,
-    listingId: number,
-  ): Promise<boolean> {
+    params?: { category?: string; limit?: number },
+  ): Promise<TrendingListing[]> {
     const validToken = (await getValidToken(refreshToken)) || token
 
-    const url = `${ORCHESTRATOR_URL}/api/listings/save/${listingId}/check`
+    const query = new URLSearchParams()
+    if (params?.category) query.set("category", params.category)
+    if (params?.limit) query.set("limit", String(params.limit))
+    const url = `${ORCHESTRATOR_URL}/api/listings/trending${query.toString() ? `?${query}` : ""}`
 
     const makeRequest = () =>
       fetch(url, {
@@ -1538,7 +1812,7 @@ export const orchestratorApi = {
 
     const response = await makeRequest()
 
-    const result = await handleResponse<{ is_saved: boolean }>(
+    return handleResponse<TrendingListing[]>(
       response,
       refreshToken,
       tokenUpdateCallback || undefined,
@@ -1553,23 +1827,25 @@ export const orchestratorApi = {
         })
       },
     )
-
-    return result.is_saved
   },
 
   /**
-   * Get all saved listings for the current user
+   * Get available listings similar to a listing, excluding the user's own
    * @param token - Access token
    * @param refreshToken - Refresh token
-   * @returns Array of saved listings
+   * @param listingId - Listing ID to find similar listings for
+   * @param limit - Optional number of listings to return
+   * @returns Similar listings, most similar first
    */
-  async getSavedListings(
+  async getSimilarListings(
     token: string,
     refreshToken: string | null,
-  ): Promise<Listing[]> {
+    listingId: number,
+    limit?: number,
+  ): Promise<SimilarListing[]> {
     const validToken = (await getValidToken(refreshToken)) || token
 
-    const url = `${ORCHESTRATOR_URL}/api/listings/saved`
+    const url = `${ORCHESTRATOR_URL}/api/listings/similar/${listingId}${limit ? `?limit=${limit}` : ""}`
 
     const makeRequest = () =>
       fetch(url, {
@@ -1582,10 +1858,7 @@ export const orchestratorApi = {
 
     const response = await makeRequest()
 
-    // The API returns an array of saved listings with nested listing objects
-    const savedListings = await handleResponse<
-      Array<{ listing: Listing; listing_id: number; created_at: string; id: number; user_id: string }>
-    >(
+    return handleResponse<SimilarListing[]>(
       response,
       refreshToken,
       tokenUpdateCallback || undefined,
@@ -1600,8 +1873,712 @@ export const orchestratorApi = {
         })
       },
     )
+  },
 
-    // Extract just the listings from the saved listings array
-    return savedListings.map((sl) => sl.listing).filter((listing) => listing != null)
+  async getListingHistory(token: string, refreshToken: string | null, listingId: number): Promise<ListingRevision[]> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/history/${listingId}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<ListingRevision[]>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
   },
-}
\ No newline at end of file
+
+  /**
+   * Unsave a listing for the current user
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @param listingId - Listing ID to unsave
+   * @returns Success response
+   */
+  async unsaveListing(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+  ): Promise<{ message: string }> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/save/${listingId}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "DELETE",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<{ message: string }>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "DELETE",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  /**
+   * Check if a listing is saved by the current user
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @param listingId - Listing ID to check
+   * @returns Boolean indicating if listing is saved
+   */
+  async isListingSaved(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+  ): Promise<boolean> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/save/${listingId}/check`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    const result = await handleResponse<{ is_saved: boolean }>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+
+    return result.is_saved
+  },
+
+  /**
+   * Get all saved listings for the current user
+   * @param token - Access token
+   * @param refreshToken - Refresh token
+   * @returns Array of saved listings
+   */
+  async getSavedListings(
+    token: string,
+    refreshToken: string | null,
+  ): Promise<Listing[]> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/saved`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    // The API returns an array of saved listings with nested listing objects
+    const savedListings = await handleResponse<
+      Array<{ listing: Listing; listing_id: number; created_at: string; id: number; user_id: string }>
+    >(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+
+    // Extract just the listings from the saved listings array
+    return savedListings.map((sl) => sl.listing).filter((listing) => listing != null)
+  },
+  /**
+   * Make an offer on a listing
+   */
+  async createOffer(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    amount: number,
+    message?: string,
+  ): Promise<Offer> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/offers`
+    const body = JSON.stringify({ listing_id: listingId, amount, message })
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+        body,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Offer>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+          body,
+        })
+      },
+    )
+  },
+
+  /**
+   * Accept, reject, counter or withdraw an offer
+   */
+  async respondToOffer(
+    token: string,
+    refreshToken: string | null,
+    offerId: number,
+    action: OfferAction,
+    counter?: { amount: number; message?: string },
+  ): Promise<RespondOfferResponse> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/offers/${offerId}/respond`
+    const body = JSON.stringify({ action, ...counter })
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+        body,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<RespondOfferResponse>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+          body,
+        })
+      },
+    )
+  },
+
+  /**
+   * List the offers the current user made or received
+   */
+  async getOffers(
+    token: string,
+    refreshToken: string | null,
+    params: { role?: "buyer" | "seller"; listingId?: number; limit?: number; cursor?: string } = {},
+  ): Promise<FetchOffersResponse> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const query = new URLSearchParams()
+    if (params.role) query.set("role", params.role)
+    if (params.listingId !== undefined) query.set("listing_id", String(params.listingId))
+    if (params.limit !== undefined) query.set("limit", String(params.limit))
+    if (params.cursor) query.set("cursor", params.cursor)
+    const qs = query.toString()
+    const url = `${ORCHESTRATOR_URL}/api/offers${qs ? `?${qs}` : ""}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<FetchOffersResponse>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  /**
+   * Mark one of your listings sold to a buyer; price defaults to their accepted offer
+   */
+  async recordSale(
+    token: string,
+    refreshToken: string | null,
+    listingId: number,
+    buyerId: string,
+    price?: number,
+  ): Promise<Transaction> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/sell/${listingId}`
+    const body = JSON.stringify({ buyer_id: buyerId, price })
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+        body,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Transaction>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+          body,
+        })
+      },
+    )
+  },
+
+  /**
+   * Confirm you received an item you bought
+   */
+  async confirmReceipt(
+    token: string,
+    refreshToken: string | null,
+    transactionId: number,
+  ): Promise<Transaction> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/transactions/${transactionId}/confirm`
+    const body = JSON.stringify({})
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+        body,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Transaction>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+          body,
+        })
+      },
+    )
+  },
+
+  /**
+   * List the current user's purchases and sales
+   */
+  async getTransactions(
+    token: string,
+    refreshToken: string | null,
+    params: { role?: "buyer" | "seller"; limit?: number; cursor?: string } = {},
+  ): Promise<Transaction[]> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const query = new URLSearchParams()
+    if (params.role) query.set("role", params.role)
+    if (params.limit !== undefined) query.set("limit", String(params.limit))
+    if (params.cursor) query.set("cursor", params.cursor)
+    const qs = query.toString()
+    const url = `${ORCHESTRATOR_URL}/api/listings/transactions${qs ? `?${qs}` : ""}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Transaction[]>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  /**
+   * Get a seller's sales record
+   */
+  async getSellerStats(
+    token: string,
+    refreshToken: string | null,
+    sellerId: string,
+  ): Promise<SellerStats> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/users/${sellerId}/stats`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<SellerStats>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  /**
+   * Fill in a textbook listing from its ISBN
+   */
+  async lookupBook(token: string, refreshToken: string | null, isbn: string): Promise<BookPrefill> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/books/${encodeURIComponent(isbn)}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<BookPrefill>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  async getCourse(token: string, refreshToken: string | null, code: string): Promise<Course> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/courses/${encodeURIComponent(code)}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<Course>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  async getCourseListings(
+    token: string,
+    refreshToken: string | null,
+    code: string,
+    page?: { limit?: number; cursor?: string; sort?: string },
+  ): Promise<FetchAllListingsResponse> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const params = new URLSearchParams()
+    if (page?.limit !== undefined) {
+      params.set("limit", page.limit.toString())
+    }
+    if (page?.cursor) {
+      params.set("cursor", page.cursor)
+    }
+    if (page?.sort) {
+      params.set("sort", page.sort)
+    }
+    const query = params.toString()
+    const url = `${ORCHESTRATOR_URL}/api/courses/${encodeURIComponent(code)}/listings${query ? `?${query}` : ""}`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "application/json",
+        },
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<FetchAllListingsResponse>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "GET",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "application/json",
+          },
+        })
+      },
+    )
+  },
+
+  // Admin only. csv has the header code,title,required_isbns,optional_isbns
+  // with ISBNs separated by semicolons; any invalid row rejects the import
+  async importCourses(token: string, refreshToken: string | null, csv: string): Promise<CourseImportResult> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/courses/import`
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": "text/csv",
+        },
+        body: csv,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<CourseImportResult>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": "text/csv",
+          },
+          body: csv,
+        })
+      },
+    )
+  },
+
+  // body is a JSON array of listings or CSV with the columns
+  // title,description,price,category,condition,pickup_location,tags,attributes
+  // (price in cents; tags and key=value attributes separated by semicolons)
+  async importListings(
+    token: string,
+    refreshToken: string | null,
+    body: string,
+    format: "json" | "csv",
+    dryRun = false,
+  ): Promise<ImportReport> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/import${dryRun ? "?dry_run=true" : ""}`
+    const contentType = format === "csv" ? "text/csv" : "application/json"
+
+    const makeRequest = () =>
+      fetch(url, {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${validToken}`,
+          "Content-Type": contentType,
+        },
+        body,
+      })
+
+    const response = await makeRequest()
+
+    return handleResponse<ImportReport>(
+      response,
+      refreshToken,
+      tokenUpdateCallback || undefined,
+      async () => {
+        const newToken = await getValidToken(refreshToken)
+        return fetch(url, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${newToken || validToken}`,
+            "Content-Type": contentType,
+          },
+          body,
+        })
+      },
+    )
+  },
+
+  // Downloads the caller's listings in the layout importListings accepts
+  async exportListings(token: string, refreshToken: string | null, format: "json" | "csv" = "json"): Promise<Blob> {
+    const validToken = (await getValidToken(refreshToken)) || token
+
+    const url = `${ORCHESTRATOR_URL}/api/listings/export?format=${format}`
+
+    const makeRequest = (bearer: string) =>
+      fetch(url, {
+        method: "GET",
+        headers: {
+          Authorization: `Bearer ${bearer}`,
+        },
+      })
+
+    let response = await makeRequest(validToken)
+    if (response.status === 401 && refreshToken) {
+      const newToken = await refreshAccessToken(refreshToken, tokenUpdateCallback || undefined)
+      if (newToken) {
+        response = await makeRequest(newToken)
+      }
+    }
+    if (!response.ok) {
+      // Throws the server's error
+      return handleResponse<Blob>(response, null)
+    }
+    return response.blob()
+  },
+}
@@ -46,11 +46,27 @@ export interface RefreshTokenResponse {
   user: User
 }
 
-export interface ErrorResponse {
-  error: string
+export interface FieldError {
+  field: string
+  code?: string
   message: string
 }
 
+// RFC 7807 problem details returned by every backend service
+export interface ErrorResponse {
+  type?: string
+  title?: string
+  status?: number
+  detail?: string
+  instance?: string
+  code?: string
+  correlation_id?: string
+  errors?: FieldError[]
+  // Legacy error shape
+  error?: string
+  message?: string
+}
+
 export interface Listing {
   id: number
   title: string
@@ -60,6 +76,187 @@ export interface Listing {
   user_id: string
   status: string
   created_at: string
+  // Buyer whose accepted offer moved the listing to PENDING
+  reserved_for?: string
+  // AVAILABLE and PENDING listings are archived at this time unless renewed
+  expires_at?: string
+  condition?: ItemCondition
+  // Campus spot to meet, e.g. "MLK Library"
+  pickup_location?: string
+  tags?: string[]
+  // Category-specific details, e.g. isbn and course_code for TEXTBOOK
+  attributes?: Record<string, string>
+  // Set on a scheduled DRAFT, which only its seller can see
+  publish_at?: string
+  // How often the listing was viewed, only returned to its seller
+  views?: number
+  // Only set on the seller's deleted listings, which can be restored for a while
+  deleted_at?: string
+  // The cover image, returned with listing lists so cards need no media request
+  cover?: ListingCover
+  // Only returned by the listing detail, newest change first
+  price_history?: PriceChange[]
+}
+
+// A listing of the trending feed, ranked by recent views, saves and chats
+export interface TrendingListing extends Listing {
+  trending_score: number
+}
+
+// A listing recommended from the one being viewed
+export interface SimilarListing extends Listing {
+  similarity_score: number
+}
+
+// The value of a listing field before and after an edit
+export interface FieldChange {
+  old: unknown
+  new: unknown
+}
+
+// One edit of a listing, keyed by the JSON name of each changed field
+export interface ListingRevision {
+  id: number
+  listing_id: number
+  // Listing version the edit produced
+  version: number
+  changes: Record<string, FieldChange>
+  changed_by?: string
+  changed_by_role?: string
+  changed_at: string
+}
+
+export type ItemCondition = "NEW" | "LIKE_NEW" | "GOOD" | "FAIR" | "POOR"
+
+// One edition of a book, looked up by ISBN
+export interface BookMetadata {
+  isbn: string
+  // Groups the editions of the same book
+  work_id?: string
+  title: string
+  authors: string[]
+  edition?: string
+  publisher?: string
+  publish_date?: string
+  cover_url?: string
+  fetched_at: string
+}
+
+// Create-listing fields filled in from an ISBN; attach cover_url with
+// addMediaURL once the listing exists
+export interface BookPrefill {
+  book: BookMetadata
+  listing: {
+    title: string
+    description?: string
+    price: number
+    category: string
+    attributes?: Record<string, string>
+  }
+  cover_url?: string
+}
+
+// A failed row of a bulk import, numbered from 1 without the CSV header
+export interface ImportRowError {
+  row: number
+  field: string
+  code: string
+  message: string
+}
+
+// Outcome of a bulk import; a dry run creates no listings
+export interface ImportReport {
+  dry_run: boolean
+  rows: number
+  valid: number
+  errors: ImportRowError[]
+  listings: Listing[]
+}
+
+// A course catalog entry; book is set once the ISBN has been looked up
+export interface CourseBook {
+  isbn: string
+  required: boolean
+  book?: BookMetadata
+}
+
+export interface Course {
+  code: string
+  title: string
+  // Required books first
+  books: CourseBook[]
+  created_at: string
+  updated_at: string
+}
+
+export interface CourseImportResult {
+  courses: number
+  books: number
+}
+
+export type OfferStatus = "PENDING" | "ACCEPTED" | "REJECTED" | "COUNTERED" | "WITHDRAWN" | "EXPIRED"
+export type OfferAction = "accept" | "reject" | "counter" | "withdraw"
+
+export interface Offer {
+  id: number
+  listing_id: number
+  buyer_id: string
+  seller_id: string
+  // The buyer, or the seller for a counter-offer
+  from_user_id: string
+  parent_offer_id?: number
+  amount: number
+  message?: string
+  status: OfferStatus
+  expires_at: string
+  created_at: string
+  updated_at: string
+}
+
+export interface RespondOfferResponse {
+  offer: Offer
+  declined?: Offer[]
+}
+
+export interface FetchOffersResponse {
+  offers: Offer[]
+  next_cursor?: string
+}
+
+// Content of a chat message with type "offer"
+export interface OfferEvent {
+  event: "created" | "countered" | "accepted" | "rejected" | "withdrawn"
+  offer: Offer
+  text: string
+}
+
+// A sale the seller recorded to a specific buyer; amounts are in cents
+export interface Transaction {
+  id: number
+  listing_id?: number
+  buyer_id?: string
+  seller_id?: string
+  offer_id?: number
+  listing_title: string
+  category: string
+  price: number
+  completed_at: string
+  // Set once the buyer confirms they received the item
+  received_at?: string
+}
+
+export interface SellerStats {
+  seller_id: string
+  sales_count: number
+  confirmed_sales: number
+  active_listings: number
+  last_sale_at?: string
+}
+
+export interface PriceChange {
+  old_price: number
+  new_price: number
+  changed_at: string
 }
 
 export type FlagReason = "SPAM" | "SCAM" | "INAPPROPRIATE" | "MISLEADING" | "OTHER"
@@ -91,14 +288,48 @@ export interface FetchAllListingsRequest {
   status?: string
   min_price?: number
   max_price?: number
+  condition?: ItemCondition
+  pickup_location?: string
+  // Listings must carry every tag
+  tags?: string[]
+  // Sent as attr.<key>=<value>, e.g. { course_code: "CMPE202" }
+  attributes?: Record<string, string>
+  // A catalog course code; matches listings of the course's books
+  course?: string
   limit?: number
   offset?: number
+  cursor?: string
+  include_count?: boolean
   sort?: string
+  // Comma separated: "category", "status", "price"
+  facets?: string
+  // Comma separated lower bounds of the price facet, in cents
+  price_buckets?: string
+}
+
+export interface FacetCount {
+  value: string
+  count: number
+}
+
+export interface PriceBucket {
+  min: number
+  max?: number
+  count: number
+}
+
+export interface Facets {
+  category?: FacetCount[]
+  status?: FacetCount[]
+  price?: PriceBucket[]
 }
 
 export interface FetchAllListingsResponse {
   items: Listing[]
-  count: number
+  next_cursor?: string
+  // Only present on the first page unless include_count is set
+  count?: number
+  facets?: Facets
 }
 
 export interface FlagListingRequest {
@@ -144,9 +375,26 @@ export interface ListingMedia {
   id: number
   listing_id: number
   media_url: string
+  // Order in the gallery, from 0
+  position: number
+  is_cover: boolean
+  alt_text?: string
+  caption?: string
+  mime_type?: string
+  width?: number
+  height?: number
+  size_bytes?: number
   created_at: string
 }
 
+export interface ListingCover {
+  media_id: number
+  media_url: string
+  alt_text?: string
+  width?: number
+  height?: number
+}
+
 export interface OverviewStats {
   total_users: number
   total_listings: number
@@ -174,12 +422,26 @@ export interface FlagsByReason {
   count: number
 }
 
+export interface SalesStats {
+  total_sales: number
+  confirmed_sales: number
+  gross_value: number
+}
+
+export interface SalesByCategory {
+  category: string
+  count: number
+  gross_value: number
+}
+
 export interface AnalyticsResponse {
   overview: OverviewStats
   listings_by_status: ListingsByStatus[]
   listings_by_category: ListingsByCategory[]
   flags_by_status: FlagsByStatus[]
   flags_by_reason: FlagsByReason[]
+  sales: SalesStats
+  sales_by_category: SalesByCategory[]
 }
 
 export interface UpdateUserRequest {
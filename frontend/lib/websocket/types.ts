@@ -37,8 +37,10 @@ export interface AuthAckMessage {
 
 export interface NotificationMessage {
   type: 'notification'
-  subType: 'inbox'
+  subType: 'inbox' | 'saved_search' | 'price_drop' | 'listing_expiring' | 'listing_changed'
   count: number
+  // Payload specific to the subType, e.g. the matched listing for saved_search
+  data?: Record<string, unknown>
 }
 
 export type WebSocketMessage = AuthMessage | PresenceMessage | ChatMessage | IncomingMessage | AuthAckMessage | NotificationMessage
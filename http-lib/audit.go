@@ -0,0 +1,62 @@
+package httplib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Execer is satisfied by *pgxpool.Pool and pgx.Tx, so audit events can be
+// written in the same transaction as the change they describe.
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// AuditEvent is a row of the append-only audit_events table, which both the
+// orchestrator and listing-service write. Before and After are snapshots of
+// the target, stored as JSON; nil leaves them NULL.
+type AuditEvent struct {
+	ActorUserID string
+	ActorRole   string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Reason      string
+	Before      any
+	After       any
+}
+
+// RecordAudit writes ev with db, tagging it with the correlation ID of ctx.
+func RecordAudit(ctx context.Context, db Execer, ev AuditEvent) error {
+	before, err := auditSnapshot(ev.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditSnapshot(ev.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO audit_events (actor_user_id, actor_role, action, target_type, target_id, reason, before, after, correlation_id)
+		VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NULLIF($9, ''))
+	`, ev.ActorUserID, ev.ActorRole, ev.Action, ev.TargetType, ev.TargetID, ev.Reason, before, after,
+		CorrelationIDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func auditSnapshot(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,56 @@
+package httplib
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VersionETag formats a row version as a strong entity tag, e.g. "v3".
+func VersionETag(version int64) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// IfMatchVersion parses the If-Match header of r into the version it names.
+// A nil version means the header was absent or "*", so the write is
+// unconditional. Only a single entity tag created by VersionETag is accepted.
+func IfMatchVersion(r *http.Request) (*int64, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return nil, nil
+	}
+	if strings.Contains(h, ",") {
+		return nil, fmt.Errorf("If-Match must contain a single entity tag")
+	}
+	tag := strings.TrimPrefix(h, "W/")
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`)
+	v, err := strconv.ParseInt(strings.TrimPrefix(tag, "v"), 10, 64)
+	if err != nil || !strings.HasPrefix(tag, "v") {
+		return nil, fmt.Errorf("invalid If-Match entity tag %q", h)
+	}
+	return &v, nil
+}
+
+// NotModified reports whether the If-None-Match header of r matches etag,
+// using the weak comparison required for conditional GETs.
+func NotModified(r *http.Request, etag string) bool {
+	h := r.Header.Get("If-None-Match")
+	if h == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(h, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteNotModified answers a conditional GET whose entity tag still matches.
+func WriteNotModified(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+}
@@ -0,0 +1,117 @@
+package httplib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionETag(t *testing.T) {
+	if got := VersionETag(3); got != `"v3"` {
+		t.Errorf(`Expected "v3", got %s`, got)
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   *int64
+	}{
+		{"absent", "", nil},
+		{"any", "*", nil},
+		{"any with spaces", " * ", nil},
+		{"strong tag", `"v3"`, ptr(int64(3))},
+		{"weak tag", `W/"v12"`, ptr(int64(12))},
+		{"surrounding spaces", ` "v7" `, ptr(int64(7))},
+		{"round trip", VersionETag(41), ptr(int64(41))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got, err := IfMatchVersion(r)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("Expected %v, got %v", deref(tt.want), deref(got))
+			}
+		})
+	}
+}
+
+func TestIfMatchVersionInvalid(t *testing.T) {
+	for _, header := range []string{`"v1", "v2"`, `"3"`, `"vx"`, `"abc"`, `v`, `"v"`} {
+		t.Run(header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			r.Header.Set("If-Match", header)
+			if got, err := IfMatchVersion(r); err == nil {
+				t.Errorf("Expected an error, got %v", deref(got))
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := VersionETag(3)
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"absent", "", false},
+		{"same tag", `"v3"`, true},
+		{"weak tag matches strong", `W/"v3"`, true},
+		{"older version", `"v2"`, false},
+		{"one of several", `"v1", "v3"`, true},
+		{"none of several", `"v1", "v2"`, false},
+		{"any", "*", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-None-Match", tt.header)
+			}
+			if got := NotModified(r, etag); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("WeakETagMatchesStrongHeader", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", `"v3"`)
+		if !NotModified(r, `W/"v3"`) {
+			t.Error("Expected a match")
+		}
+	})
+}
+
+func TestWriteNotModified(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteNotModified(w, `"v3"`)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304, got %d", w.Code)
+	}
+	if got := w.Header().Get("ETag"); got != `"v3"` {
+		t.Errorf(`Expected ETag "v3", got %s`, got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected an empty body, got %q", w.Body)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref(v *int64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,169 @@
+package httplib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HealthCheck reports whether a dependency is usable. It should honour ctx.
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health serves liveness and readiness probes. Liveness only reports that the
+// process is up; readiness runs every registered dependency check and fails
+// while the service is draining for shutdown.
+type Health struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewHealth creates a Health whose readiness checks are bounded by timeout.
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Health{timeout: timeout}
+}
+
+// Register adds a named dependency check to the readiness probe.
+func (h *Health) Register(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// SetDraining makes readiness fail so load balancers stop routing new traffic.
+func (h *Health) SetDraining() {
+	h.draining.Store(true)
+}
+
+// LiveHandler always answers 200 while the process can serve HTTP.
+func (h *Health) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadyHandler answers 200 when every dependency check passes, 503 otherwise.
+func (h *Health) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		WriteJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "draining",
+		})
+		return
+	}
+
+	h.mu.RLock()
+	checks := append([]namedCheck(nil), h.checks...)
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	results := make(map[string]string, len(checks))
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		healthy = true
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			err := c.check(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				healthy = false
+				results[c.name] = err.Error()
+				logrus.WithError(err).WithField("dependency", c.name).Warn("readiness check failed")
+				return
+			}
+			results[c.name] = "ok"
+		}(c)
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	if !healthy {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	WriteJSON(w, code, map[string]interface{}{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// RegisterHealthRoutes mounts GET /health/live and GET /health/ready on mux.
+// GET /health is kept as an alias of liveness for existing probes.
+func (h *Health) RegisterHealthRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /health", h.LiveHandler)
+	mux.HandleFunc("GET /health/live", h.LiveHandler)
+	mux.HandleFunc("GET /health/ready", h.ReadyHandler)
+}
+
+// DefaultPreStopDelay is how long a draining service keeps accepting requests
+// after failing readiness: several probe periods of a typical load balancer.
+const DefaultPreStopDelay = 15 * time.Second
+
+// PreStopDelayFromEnv returns SHUTDOWN_PRESTOP_DELAY, or DefaultPreStopDelay
+// when it is unset or invalid. "0s" stops right away, e.g. in development.
+func PreStopDelayFromEnv() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("SHUTDOWN_PRESTOP_DELAY"))
+	if err != nil || d < 0 {
+		return DefaultPreStopDelay
+	}
+	return d
+}
+
+// ListenAndServeGracefully runs srv until ctx is cancelled, then marks health
+// as draining. It keeps serving for preStopDelay, so load balancers see the
+// failing readiness probe and stop routing new traffic, and then waits up to
+// drainTimeout for in-flight requests to finish. Hooks registered via
+// srv.RegisterOnShutdown run once the listener closes.
+func ListenAndServeGracefully(ctx context.Context, srv *http.Server, health *Health, preStopDelay, drainTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	if health != nil {
+		health.SetDraining()
+		if preStopDelay > 0 {
+			logrus.WithField("delay", preStopDelay.String()).Info("failing readiness before shutdown")
+			select {
+			case err := <-errCh:
+				return err
+			case <-time.After(preStopDelay):
+			}
+		}
+	}
+	logrus.WithField("timeout", drainTimeout.String()).Info("draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-errCh
+}
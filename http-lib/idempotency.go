@@ -0,0 +1,362 @@
+package httplib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderIdempotencyKey is sent by clients to make a mutating request safe to retry.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed marks a response that was replayed from the store.
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+const (
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+// maxIdempotentBody is the default bound on how much of a request body is
+// read for fingerprinting.
+const maxIdempotentBody = 1 << 20
+
+// maxIdempotentMemory is how much of a body is kept in memory; larger bodies,
+// such as uploads, are spooled to a temporary file.
+const maxIdempotentMemory = 1 << 20
+
+// IdempotencyRecord is what gets stored per key: the request fingerprint and,
+// once the handler has finished, the response to replay.
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`
+	Completed   bool                `json:"completed"`
+	Status      int                 `json:"status,omitempty"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
+
+// IdempotencyStore persists idempotency records.
+type IdempotencyStore interface {
+	// Reserve stores rec under key if the key is unused. When the key already
+	// exists the stored record is returned and ok is false.
+	Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (existing *IdempotencyRecord, ok bool, err error)
+	// Complete overwrites the record for key with the final response.
+	Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	// Release deletes the key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// RedisIdempotencyStore keeps idempotency records in Redis.
+type RedisIdempotencyStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisIdempotencyStore(client redis.Cmdable) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, false, err
+	}
+	// SET NX GET reserves the key and returns what was there in one step, so
+	// the record cannot expire in between
+	raw, err := s.client.SetArgs(ctx, key, data, redis.SetArgs{Mode: "NX", Get: true, TTL: ttl}).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var existing IdempotencyRecord
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, false, fmt.Errorf("corrupt idempotency record: %w", err)
+	}
+	return &existing, false, nil
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// Idempotency replays the stored response for repeated requests carrying the
+// same Idempotency-Key, and rejects a key reused with a different payload.
+type Idempotency struct {
+	store IdempotencyStore
+	// LockTTL bounds how long a key stays reserved while its first request runs.
+	LockTTL time.Duration
+	// TTL is how long completed responses are kept for replay.
+	TTL time.Duration
+	// MaxBody is the largest request body accepted with an Idempotency-Key.
+	MaxBody int64
+}
+
+// NewIdempotency creates the middleware factory. A nil *Idempotency is valid
+// and passes every request through.
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	return &Idempotency{store: store, LockTTL: time.Minute, TTL: 24 * time.Hour, MaxBody: maxIdempotentBody}
+}
+
+// WithMaxBody returns a copy of i accepting request bodies of up to n bytes,
+// for routes such as uploads that take more than the default.
+func (i *Idempotency) WithMaxBody(n int64) *Idempotency {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	c.MaxBody = n
+	return &c
+}
+
+// Middleware enforces idempotency for requests that send an Idempotency-Key.
+// Requests without the header are unaffected. It must run after
+// AuthMiddleWare so keys are scoped per user.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	if i == nil || i.store == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			WriteProblem(w, ValidationProblem("Idempotency-Key must be at most 255 characters",
+				FieldError{Field: HeaderIdempotencyKey, Code: "too_long", Message: "must be at most 255 characters"}))
+			return
+		}
+
+		maxBody := i.MaxBody
+		if maxBody <= 0 {
+			maxBody = maxIdempotentBody
+		}
+		body, bodyHash, err := spoolBody(r.Body, maxBody)
+		if errors.Is(err, errBodyTooLarge) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "Request too large", "Request body is too large for an idempotent request")
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to read request body")
+			return
+		}
+		defer body.Close()
+		r.Body = body
+
+		if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+			if bodyHash, err = multipartDigest(body, params["boundary"]); err != nil {
+				WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to read multipart request body")
+				return
+			}
+		}
+
+		userID, _ := r.Context().Value(ContextKey("userId")).(string)
+		storeKey := fmt.Sprintf("idempotency:%s:%s:%s:%s", userID, r.Method, r.URL.Path, key)
+		fingerprint := requestFingerprint(r, bodyHash)
+
+		existing, reserved, err := i.store.Reserve(r.Context(), storeKey, IdempotencyRecord{Fingerprint: fingerprint}, i.LockTTL)
+		if err != nil {
+			logrus.WithError(err).Warn("idempotency store unavailable; processing request without replay protection")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				WriteProblem(w, NewCodedProblem(http.StatusUnprocessableEntity, CodeIdempotencyKeyReused,
+					"Idempotency-Key was already used with a different request payload"))
+			case !existing.Completed:
+				WriteProblem(w, NewCodedProblem(http.StatusConflict, CodeIdempotencyInProgress,
+					"A request with this Idempotency-Key is still being processed"))
+			default:
+				for k, vs := range existing.Header {
+					for _, v := range vs {
+						w.Header().Add(k, v)
+					}
+				}
+				w.Header().Set(HeaderIdempotentReplayed, "true")
+				w.WriteHeader(existing.Status)
+				_, _ = w.Write(existing.Body)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// Server errors are not cached so the client can retry them.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= 500 {
+			if err := i.store.Release(ctx, storeKey); err != nil {
+				logrus.WithError(err).Warn("failed to release idempotency key")
+			}
+			return
+		}
+		header := map[string][]string{}
+		for _, k := range []string{"Content-Type", "Location", "ETag"} {
+			if v := rec.Header().Values(k); len(v) > 0 {
+				header[k] = v
+			}
+		}
+		err = i.store.Complete(ctx, storeKey, IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      rec.status,
+			Header:      header,
+			Body:        rec.body.Bytes(),
+		}, i.TTL)
+		if err != nil {
+			logrus.WithError(err).Warn("failed to store idempotent response")
+		}
+	})
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// spoolBody reads up to limit bytes of body and returns a copy to hand on to
+// the handler together with the body's SHA-256. Bodies larger than
+// maxIdempotentMemory are copied to a temporary file, removed on Close.
+func spoolBody(body io.Reader, limit int64) (io.ReadSeekCloser, []byte, error) {
+	h := sha256.New()
+	src := io.TeeReader(io.LimitReader(body, limit+1), h)
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(src, maxIdempotentMemory+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > limit {
+		return nil, nil, errBodyTooLarge
+	}
+	if n <= maxIdempotentMemory {
+		return memoryBody{bytes.NewReader(buf.Bytes())}, h.Sum(nil), nil
+	}
+
+	f, err := os.CreateTemp("", "idempotent-body-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	spooled := &tempFile{f}
+	total, err := io.Copy(f, io.MultiReader(&buf, src))
+	if err == nil && total > limit {
+		err = errBodyTooLarge
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, nil, err
+	}
+	return spooled, h.Sum(nil), nil
+}
+
+// memoryBody is a spooled body small enough to keep in memory.
+type memoryBody struct {
+	*bytes.Reader
+}
+
+func (memoryBody) Close() error {
+	return nil
+}
+
+// multipartDigest hashes the parts of a multipart body, leaving out the
+// boundary that clients pick at random, so a retried upload fingerprints the
+// same as the original. body is rewound for the handler.
+func multipartDigest(body io.ReadSeeker, boundary string) ([]byte, error) {
+	h := sha256.New()
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range []string{part.FormName(), part.FileName(), part.Header.Get("Content-Type")} {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+		n, err := io.Copy(h, part)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// tempFile is a temporary file deleted when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// requestFingerprint hashes everything that identifies the intent of a
+// request; bodyHash is the SHA-256 of its body, or of its parts when multipart.
+func requestFingerprint(r *http.Request, bodyHash []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(bodyHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder tees the response so it can be stored for replay.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
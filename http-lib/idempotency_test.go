@@ -0,0 +1,306 @@
+package httplib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryIdempotencyStore is an in-process IdempotencyStore for tests.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+	err     error
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	if existing, ok := s.records[key]; ok {
+		return &existing, false, nil
+	}
+	s.records[key] = rec
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = rec
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// idempotentHandler counts its calls and echoes the request body back
+type idempotentHandler struct {
+	calls  int
+	status int
+	bodies [][]byte
+}
+
+func (h *idempotentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	body, _ := io.ReadAll(r.Body)
+	h.bodies = append(h.bodies, body)
+	status := h.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/listings/42")
+	w.Header().Set("X-Not-Replayed", "1")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]int{"call": h.calls})
+}
+
+func idempotentRequest(key, userID, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/listings/create", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(HeaderIdempotencyKey, key)
+	}
+	return r.WithContext(context.WithValue(r.Context(), ContextKey("userId"), userID))
+}
+
+func serveIdempotent(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func problemCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var p Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	return p.Code
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Run("WithoutKeyPassesThrough", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("", "u1", `{"title":"a"}`))
+		serveIdempotent(h, idempotentRequest("", "u1", `{"title":"a"}`))
+		if next.calls != 2 {
+			t.Errorf("Expected 2 calls, got %d", next.calls)
+		}
+	})
+
+	t.Run("ReplaysCompletedResponse", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		first := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		second := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+
+		if next.calls != 1 {
+			t.Fatalf("Expected the handler to run once, got %d", next.calls)
+		}
+		if string(next.bodies[0]) != `{"title":"a"}` {
+			t.Errorf("Expected the handler to read the full body, got %q", next.bodies[0])
+		}
+		if second.Code != first.Code || second.Body.String() != first.Body.String() {
+			t.Errorf("Expected %d %s, got %d %s", first.Code, first.Body, second.Code, second.Body)
+		}
+		if second.Header().Get(HeaderIdempotentReplayed) != "true" {
+			t.Error("Expected the replay to be marked")
+		}
+		if first.Header().Get(HeaderIdempotentReplayed) != "" {
+			t.Error("Expected the first response not to be marked as a replay")
+		}
+		if got := second.Header().Get("Location"); got != "/api/listings/42" {
+			t.Errorf("Expected Location to be replayed, got %q", got)
+		}
+		if got := second.Header().Get("X-Not-Replayed"); got != "" {
+			t.Errorf("Expected only allowlisted headers to be replayed, got X-Not-Replayed %q", got)
+		}
+	})
+
+	t.Run("RejectsKeyReusedWithDifferentBody", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"b"}`))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected 422, got %d", w.Code)
+		}
+		if code := problemCode(t, w); code != CodeIdempotencyKeyReused {
+			t.Errorf("Expected %s, got %s", CodeIdempotencyKeyReused, code)
+		}
+		if next.calls != 1 {
+			t.Errorf("Expected the handler to run once, got %d", next.calls)
+		}
+	})
+
+	t.Run("RejectsKeyReusedWithDifferentQuery", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("k1", "u1", `{}`))
+		r := idempotentRequest("k1", "u1", `{}`)
+		r.URL.RawQuery = "dry_run=true"
+		if w := serveIdempotent(h, r); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("RejectsKeyStillInProgress", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		next := &idempotentHandler{}
+		h := NewIdempotency(store).Middleware(next)
+		r := idempotentRequest("k1", "u1", `{"title":"a"}`)
+		fingerprint := requestFingerprint(r, sha256Of(`{"title":"a"}`))
+		store.records["idempotency:u1:POST:/api/listings/create:k1"] = IdempotencyRecord{Fingerprint: fingerprint}
+
+		w := serveIdempotent(h, r)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("Expected 409, got %d", w.Code)
+		}
+		if code := problemCode(t, w); code != CodeIdempotencyInProgress {
+			t.Errorf("Expected %s, got %s", CodeIdempotencyInProgress, code)
+		}
+		if next.calls != 0 {
+			t.Errorf("Expected the handler not to run, got %d calls", next.calls)
+		}
+	})
+
+	t.Run("KeysAreScopedPerUser", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		w := serveIdempotent(h, idempotentRequest("k1", "u2", `{"title":"a"}`))
+		if next.calls != 2 || w.Header().Get(HeaderIdempotentReplayed) != "" {
+			t.Errorf("Expected another user's request to run, got %d calls", next.calls)
+		}
+	})
+
+	t.Run("ServerErrorsReleaseTheKey", func(t *testing.T) {
+		next := &idempotentHandler{status: http.StatusBadGateway}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		next.status = http.StatusCreated
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		if next.calls != 2 || w.Code != http.StatusCreated {
+			t.Errorf("Expected the retry to run and succeed, got %d calls and %d", next.calls, w.Code)
+		}
+	})
+
+	t.Run("ClientErrorsAreReplayed", func(t *testing.T) {
+		next := &idempotentHandler{status: http.StatusBadRequest}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		serveIdempotent(h, idempotentRequest("k1", "u1", `{}`))
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", `{}`))
+		if next.calls != 1 || w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a replayed 400, got %d calls and %d", next.calls, w.Code)
+		}
+	})
+
+	t.Run("StoreErrorPassesThrough", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		store.err = errors.New("redis down")
+		next := &idempotentHandler{}
+		h := NewIdempotency(store).Middleware(next)
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		if next.calls != 1 || w.Code != http.StatusCreated {
+			t.Errorf("Expected the request to be served, got %d calls and %d", next.calls, w.Code)
+		}
+	})
+
+	t.Run("RejectsLongKey", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		w := serveIdempotent(h, idempotentRequest(strings.Repeat("k", 256), "u1", `{}`))
+		if w.Code != http.StatusBadRequest || next.calls != 0 {
+			t.Errorf("Expected 400 without calling the handler, got %d and %d calls", w.Code, next.calls)
+		}
+	})
+
+	t.Run("RejectsBodyOverLimit", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).WithMaxBody(8).Middleware(next)
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", `{"title":"a"}`))
+		if w.Code != http.StatusRequestEntityTooLarge || next.calls != 0 {
+			t.Errorf("Expected 413 without calling the handler, got %d and %d calls", w.Code, next.calls)
+		}
+	})
+
+	t.Run("SpoolsLargeBodies", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).WithMaxBody(4 << 20).Middleware(next)
+		body := strings.Repeat("x", 3<<20)
+		serveIdempotent(h, idempotentRequest("k1", "u1", body))
+		w := serveIdempotent(h, idempotentRequest("k1", "u1", body))
+
+		if next.calls != 1 || !bytes.Equal(next.bodies[0], []byte(body)) {
+			t.Fatalf("Expected the handler to read the whole body once, got %d calls", next.calls)
+		}
+		if w.Header().Get(HeaderIdempotentReplayed) != "true" {
+			t.Error("Expected the repeated upload to be replayed")
+		}
+	})
+
+	t.Run("ReplaysMultipartRetryWithNewBoundary", func(t *testing.T) {
+		next := &idempotentHandler{}
+		h := NewIdempotency(newMemoryIdempotencyStore()).Middleware(next)
+		upload := func(boundary, content string) *httptest.ResponseRecorder {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			mw.SetBoundary(boundary)
+			fw, _ := mw.CreateFormFile("files", "photo.jpg")
+			fw.Write([]byte(content))
+			mw.Close()
+			r := idempotentRequest("k1", "u1", buf.String())
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			return serveIdempotent(h, r)
+		}
+
+		upload("first-boundary", "jpeg bytes")
+		w := upload("second-boundary", "jpeg bytes")
+		if next.calls != 1 || w.Header().Get(HeaderIdempotentReplayed) != "true" {
+			t.Fatalf("Expected the retry to be replayed, got %d calls", next.calls)
+		}
+		if !bytes.Contains(next.bodies[0], []byte("jpeg bytes")) {
+			t.Errorf("Expected the handler to read the whole body, got %q", next.bodies[0])
+		}
+		if w := upload("third-boundary", "other bytes"); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected a different file to be rejected with 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("NilIdempotencyPassesThrough", func(t *testing.T) {
+		var i *Idempotency
+		next := &idempotentHandler{}
+		serveIdempotent(i.WithMaxBody(1).Middleware(next), idempotentRequest("k1", "u1", `{}`))
+		if next.calls != 1 {
+			t.Errorf("Expected 1 call, got %d", next.calls)
+		}
+	})
+}
+
+func sha256Of(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
@@ -24,10 +24,7 @@ func AuthMiddleWare(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "Authorization header required",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "Authorization header required", "Please provide a valid access token")
 			return
 		}
 		tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
@@ -40,46 +37,31 @@ func AuthMiddleWare(next http.Handler) http.Handler {
 		})
 
 		if err != nil {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "Invalid token",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "Invalid token", "Please provide a valid access token")
 			return
 		}
 
 		if !token.Valid {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "Invalid token",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "Invalid token", "Please provide a valid access token")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "Invalid token claims",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "Invalid token claims", "Please provide a valid access token")
 			return
 		}
 
 		// Check token type
 		tokenType, ok := claims["type"].(string)
 		if !ok || tokenType != "access" {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "Invalid token type",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "Invalid token type", "Please provide a valid access token")
 			return
 		}
 
 		userID, ok := claims["userId"].(string)
 		if !ok {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{
-				"error":   "User ID not found in token",
-				"message": "Please provide a valid access token",
-			})
+			WriteError(w, http.StatusUnauthorized, "User ID not found in token", "Please provide a valid access token")
 			return
 		}
 
@@ -131,7 +113,7 @@ func EnforceXRequestID(defaultID string) func(http.Handler) http.Handler {
 func EnforceXUserID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("X-User-ID") == "" {
-			http.Error(w, "Missing required header: X-User-ID", http.StatusBadRequest)
+			WriteProblem(w, ValidationProblem("Missing required header: X-User-ID", FieldError{Field: "X-User-ID", Code: "required", Message: "header is required"}))
 			return // Short-circuit
 		}
 		next.ServeHTTP(w, r)
@@ -142,7 +124,7 @@ func EnforceXUserID(next http.Handler) http.Handler {
 func EnforceXRoleID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("X-Role-ID") == "" {
-			http.Error(w, "Missing required header: X-Role-ID", http.StatusBadRequest)
+			WriteProblem(w, ValidationProblem("Missing required header: X-Role-ID", FieldError{Field: "X-Role-ID", Code: "required", Message: "header is required"}))
 			return // Short-circuit
 		}
 		next.ServeHTTP(w, r)
@@ -190,7 +172,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Correlation-ID, Idempotency-Key, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Correlation-ID, Idempotent-Replayed, ETag, X-Next-Cursor")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
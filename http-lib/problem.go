@@ -0,0 +1,255 @@
+package httplib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProblemContentType is the media type for RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// HeaderCorrelationID carries the id used to correlate logs and errors across
+// services. It is separate from X-Request-ID, which listing-service uses to
+// authenticate the orchestrator.
+const HeaderCorrelationID = "X-Correlation-ID"
+
+// Machine-readable problem codes shared by every service.
+const (
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeValidation         = "VALIDATION_FAILED"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	CodeConflict           = "CONFLICT"
+	CodePreconditionFailed = "PRECONDITION_FAILED"
+	CodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	CodeUnprocessable      = "UNPROCESSABLE_ENTITY"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeBadGateway         = "BAD_GATEWAY"
+	CodeUnavailable        = "SERVICE_UNAVAILABLE"
+)
+
+// FieldError describes a single invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details object. It doubles as an error so
+// that upstream failures can be passed through unchanged.
+type Problem struct {
+	Type          string       `json:"type"`
+	Title         string       `json:"title"`
+	Status        int          `json:"status"`
+	Detail        string       `json:"detail,omitempty"`
+	Instance      string       `json:"instance,omitempty"`
+	Code          string       `json:"code"`
+	CorrelationID string       `json:"correlation_id,omitempty"`
+	Errors        []FieldError `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Code, p.Detail)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Code, p.Title)
+}
+
+// ProblemMapper is implemented by application errors that know how to
+// describe themselves as a Problem.
+type ProblemMapper interface {
+	Problem() *Problem
+}
+
+// CodeForStatus returns the default problem code for an HTTP status.
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeBadGateway
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// problemType builds the type URI for a code, e.g. urn:problem:not-found.
+func problemType(code string) string {
+	return "urn:problem:" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// NewProblem creates a problem with the standard title for status and the
+// default code for that status.
+func NewProblem(status int, detail string) *Problem {
+	return NewCodedProblem(status, CodeForStatus(status), detail)
+}
+
+// NewCodedProblem creates a problem with an explicit machine-readable code.
+func NewCodedProblem(status int, code, detail string) *Problem {
+	title := http.StatusText(status)
+	if title == "" {
+		title = "Error"
+	}
+	return &Problem{
+		Type:   problemType(code),
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// ValidationProblem creates a 400 problem listing every invalid field.
+func ValidationProblem(detail string, fields ...FieldError) *Problem {
+	p := NewCodedProblem(http.StatusBadRequest, CodeValidation, detail)
+	p.Title = "Validation error"
+	p.Errors = fields
+	return p
+}
+
+// WithTitle overrides the human-readable summary.
+func (p *Problem) WithTitle(title string) *Problem {
+	if title != "" {
+		p.Title = title
+	}
+	return p
+}
+
+// WriteProblem writes p as application/problem+json. The correlation id is
+// taken from the response headers set by CorrelationIDMiddleware.
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	if p.CorrelationID == "" {
+		p.CorrelationID = w.Header().Get(HeaderCorrelationID)
+	}
+	if p.Type == "" {
+		p.Type = problemType(p.Code)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+	logrus.WithFields(logrus.Fields{
+		"status":         p.Status,
+		"code":           p.Code,
+		"correlation_id": p.CorrelationID,
+	}).Info("problem sent")
+}
+
+// WriteError writes a problem with the given status, title and detail.
+func WriteError(w http.ResponseWriter, status int, title, detail string) {
+	WriteProblem(w, NewProblem(status, detail).WithTitle(title))
+}
+
+// WriteServiceError writes err as a problem. Problems (for example ones
+// received from an upstream service) and ProblemMappers are written
+// unchanged; any other error becomes a problem with the given status and title.
+func WriteServiceError(w http.ResponseWriter, status int, title string, err error) {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p
+		WriteProblem(w, &cp)
+		return
+	}
+	var m ProblemMapper
+	if errors.As(err, &m) {
+		WriteProblem(w, m.Problem())
+		return
+	}
+	WriteError(w, status, title, err.Error())
+}
+
+// ProblemFromResponse turns a non-2xx upstream response into a Problem. A
+// problem+json body is decoded as is; anything else is wrapped with the
+// upstream status so callers can still pass it through.
+func ProblemFromResponse(resp *http.Response) *Problem {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mt == ProblemContentType {
+		var p Problem
+		if err := json.Unmarshal(body, &p); err == nil && p.Status != 0 {
+			return &p
+		}
+	}
+
+	detail := strings.TrimSpace(string(body))
+	// Legacy {"error": "..."} bodies
+	var legacy struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &legacy); err == nil {
+		if legacy.Message != "" {
+			detail = legacy.Message
+		} else if legacy.Error != "" {
+			detail = legacy.Error
+		}
+	}
+	p := NewProblem(resp.StatusCode, detail)
+	p.CorrelationID = resp.Header.Get(HeaderCorrelationID)
+	return p
+}
+
+// CorrelationIDMiddleware ensures every request carries an X-Correlation-ID,
+// reusing the caller's id when present. The id is echoed on the response,
+// stored in the request context and kept on the request headers so it can be
+// forwarded to downstream services.
+func CorrelationIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderCorrelationID)
+		if id == "" || len(id) > 128 {
+			id = newCorrelationID()
+			r.Header.Set(HeaderCorrelationID, id)
+		}
+		w.Header().Set(HeaderCorrelationID, id)
+		ctx := context.WithValue(r.Context(), ContextKey("correlationId"), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CorrelationIDFromContext returns the id stored by CorrelationIDMiddleware.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKey("correlationId")).(string)
+	return id
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,318 @@
+package httplib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimit describes a token bucket: up to Burst requests at once, refilled
+// at Burst tokens every Per.
+type RateLimit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// refillPerMs returns how many tokens are added back to the bucket every millisecond.
+func (l RateLimit) refillPerMs() float64 {
+	return float64(l.Burst) / float64(l.Per.Milliseconds())
+}
+
+func (l RateLimit) valid() bool {
+	return l.Burst > 0 && l.Per >= time.Millisecond
+}
+
+// ParseRateLimit parses limits written as "<burst>/<duration>", e.g. "10/1m".
+func ParseRateLimit(s string) (RateLimit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected <burst>/<duration>", s)
+	}
+	burst, err := strconv.Atoi(parts[0])
+	if err != nil || burst <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", s)
+	}
+	per, err := time.ParseDuration(parts[1])
+	if err != nil || per < time.Millisecond {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: bad duration", s)
+	}
+	return RateLimit{Burst: burst, Per: per}, nil
+}
+
+// RateLimitRule configures the buckets that guard a single route. Requests are
+// charged against the user bucket (when the caller is authenticated) and the
+// IP bucket; a nil limit disables that dimension.
+type RateLimitRule struct {
+	Route string
+	User  *RateLimit
+	IP    *RateLimit
+}
+
+// RateLimitRuleFromEnv overrides the defaults of a rule with
+// RATE_LIMIT_<ROUTE>_USER and RATE_LIMIT_<ROUTE>_IP when they are set.
+// A value of "off" disables that dimension.
+func RateLimitRuleFromEnv(def RateLimitRule) RateLimitRule {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(strings.NewReplacer("-", "_", "/", "_", ".", "_").Replace(def.Route))
+	rule := def
+	for suffix, target := range map[string]**RateLimit{"_USER": &rule.User, "_IP": &rule.IP} {
+		v := os.Getenv(prefix + suffix)
+		if v == "" {
+			continue
+		}
+		if strings.EqualFold(v, "off") {
+			*target = nil
+			continue
+		}
+		limit, err := ParseRateLimit(v)
+		if err != nil {
+			logrus.WithError(err).WithField("env", prefix+suffix).Warn("ignoring invalid rate limit override")
+			continue
+		}
+		*target = &limit
+	}
+	return rule
+}
+
+// tokenBucketScript refills the bucket based on Redis server time so that all
+// replicas share the same clock, then tries to take one token.
+// Returns {allowed, tokens_left} with tokens_left as a string to keep precision.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local refill = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * refill)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, math.ceil(capacity / refill) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimiter enforces RateLimitRules using token buckets stored in Redis, so
+// limits hold across every replica of a service.
+type RateLimiter struct {
+	client  redis.Scripter
+	prefix  string
+	trusted []*net.IPNet
+}
+
+// NewRateLimiter creates a limiter backed by the given Redis client. Client
+// addresses are only taken from forwarding headers set by trusted proxies,
+// see ClientIP. A nil *RateLimiter is valid and never limits anything.
+func NewRateLimiter(client redis.Scripter, trusted []*net.IPNet) *RateLimiter {
+	return &RateLimiter{client: client, prefix: "ratelimit", trusted: trusted}
+}
+
+// ParseTrustedProxies parses a comma-separated list of proxy addresses and
+// CIDR ranges, e.g. "10.0.0.0/8,127.0.0.1".
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+type bucketResult struct {
+	limit     RateLimit
+	allowed   bool
+	remaining int
+	reset     time.Duration
+	retry     time.Duration
+}
+
+func (rl *RateLimiter) take(ctx context.Context, key string, limit RateLimit) (bucketResult, error) {
+	refill := limit.refillPerMs()
+	res, err := tokenBucketScript.Run(ctx, rl.client, []string{key}, limit.Burst, refill).Slice()
+	if err != nil {
+		return bucketResult{}, err
+	}
+	if len(res) != 2 {
+		return bucketResult{}, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return bucketResult{}, fmt.Errorf("unexpected token count %q: %w", tokensStr, err)
+	}
+
+	out := bucketResult{
+		limit:     limit,
+		allowed:   allowed == 1,
+		remaining: int(math.Floor(tokens)),
+		reset:     time.Duration(math.Ceil((float64(limit.Burst)-tokens)/refill)) * time.Millisecond,
+	}
+	if !out.allowed {
+		out.retry = time.Duration(math.Ceil((1-tokens)/refill)) * time.Millisecond
+	}
+	return out, nil
+}
+
+// Limit returns middleware enforcing rule. It must run after AuthMiddleWare
+// for the per-user bucket to apply. Redis failures fail open so an outage
+// does not take the API down with it.
+func (rl *RateLimiter) Limit(rule RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rl == nil || (rule.User == nil && rule.IP == nil) {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var results []bucketResult
+
+			// The IP bucket is checked first so a request it denies does not
+			// also use up the user's tokens
+			if rule.IP != nil && rule.IP.valid() {
+				key := fmt.Sprintf("%s:%s:ip:%s", rl.prefix, rule.Route, ClientIP(r, rl.trusted))
+				res, err := rl.take(r.Context(), key, *rule.IP)
+				if err != nil {
+					logrus.WithError(err).WithField("route", rule.Route).Warn("rate limiter unavailable; allowing request")
+					next.ServeHTTP(w, r)
+					return
+				}
+				results = append(results, res)
+			}
+
+			ipDenied := len(results) > 0 && !results[0].allowed
+			if rule.User != nil && rule.User.valid() && !ipDenied {
+				if userID, ok := r.Context().Value(ContextKey("userId")).(string); ok && userID != "" {
+					key := fmt.Sprintf("%s:%s:user:%s", rl.prefix, rule.Route, userID)
+					res, err := rl.take(r.Context(), key, *rule.User)
+					if err != nil {
+						logrus.WithError(err).WithField("route", rule.Route).Warn("rate limiter unavailable; allowing request")
+						next.ServeHTTP(w, r)
+						return
+					}
+					results = append(results, res)
+				}
+			}
+
+			if len(results) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Report the most restrictive bucket: a denied one first, otherwise
+			// the one with the fewest tokens left.
+			worst := results[0]
+			for _, res := range results[1:] {
+				if (!res.allowed && worst.allowed) ||
+					(res.allowed == worst.allowed && res.remaining < worst.remaining) {
+					worst = res
+				}
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(worst.limit.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(worst.remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(worst.reset)))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", worst.limit.Burst, ceilSeconds(worst.limit.Per)))
+
+			if !worst.allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(worst.retry)))
+				WriteError(w, http.StatusTooManyRequests, "Too many requests", "Rate limit exceeded, please retry later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ClientIP returns the originating client address. X-Forwarded-For and
+// X-Real-IP are only honoured when the request comes from a trusted proxy,
+// as anyone can send them; X-Forwarded-For is then read from the right, and
+// the first hop that is not a trusted proxy is the client.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		if !isTrustedProxy(hops[i], trusted) || i == 0 {
+			return hops[i]
+		}
+	}
+	if len(hops) == 0 {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+	return remote
+}
+
+func isTrustedProxy(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package httplib
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RateLimit
+	}{
+		{"10/1m", RateLimit{Burst: 10, Per: time.Minute}},
+		{" 5/30s ", RateLimit{Burst: 5, Per: 30 * time.Second}},
+		{"100/1h30m", RateLimit{Burst: 100, Per: 90 * time.Minute}},
+		{"1/1ms", RateLimit{Burst: 1, Per: time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.in)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitInvalid(t *testing.T) {
+	for _, in := range []string{"", "10", "10/", "/1m", "0/1m", "-1/1m", "ten/1m", "10/minute", "10/1us", "10/-1m"} {
+		t.Run(in, func(t *testing.T) {
+			if got, err := ParseRateLimit(in); err == nil {
+				t.Errorf("Expected an error, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := ParseTrustedProxies(" 10.0.0.0/8, 127.0.0.1 ,,::1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(nets) != 3 {
+		t.Fatalf("Expected 3 networks, got %d", len(nets))
+	}
+	for _, addr := range []string{"10.1.2.3", "127.0.0.1", "::1"} {
+		if !isTrustedProxy(addr, nets) {
+			t.Errorf("Expected %s to be trusted", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.2", "11.0.0.1", "::2", "not-an-ip"} {
+		if isTrustedProxy(addr, nets) {
+			t.Errorf("Expected %s not to be trusted", addr)
+		}
+	}
+
+	for _, in := range []string{"10.0.0.0/33", "localhost"} {
+		if _, err := ParseTrustedProxies(in); err == nil {
+			t.Errorf("Expected an error for %q", in)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Failed to parse proxies: %v", err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		realIP string
+		want   string
+	}{
+		{name: "direct client", remote: "203.0.113.7:5555", want: "203.0.113.7"},
+		{name: "untrusted remote ignores headers", remote: "203.0.113.7:5555", xff: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "single proxy", remote: "10.0.0.2:80", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed leftmost hop", remote: "10.0.0.2:80", xff: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of proxies", remote: "10.0.0.2:80", xff: []string{"198.51.100.1, 10.0.0.5", "10.0.0.6"}, want: "198.51.100.1"},
+		{name: "all hops trusted", remote: "10.0.0.2:80", xff: []string{"10.0.0.9, 10.0.0.5"}, want: "10.0.0.9"},
+		{name: "junk hop", remote: "10.0.0.2:80", xff: []string{"198.51.100.1, junk"}, want: "10.0.0.2"},
+		{name: "real IP without forwarded for", remote: "10.0.0.2:80", realIP: "198.51.100.3", want: "198.51.100.3"},
+		{name: "invalid real IP", remote: "10.0.0.2:80", realIP: "junk", want: "10.0.0.2"},
+		{name: "remote without port", remote: "203.0.113.7", want: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r, trusted); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -6,11 +6,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
+	httplib "github.com/kunal768/cmpe202/http-lib"
 	"github.com/kunal768/cmpe202/listing-service/internal/blob"
+	"github.com/kunal768/cmpe202/listing-service/internal/books"
+	"github.com/kunal768/cmpe202/listing-service/internal/common"
 	"github.com/kunal768/cmpe202/listing-service/internal/gemini"
 	"github.com/kunal768/cmpe202/listing-service/internal/listing"
 	"github.com/kunal768/cmpe202/listing-service/internal/platform"
@@ -24,7 +30,13 @@ func main() {
 	defer pool.Close()
 
 	// Repo Database Interface Layer being passed to the handler layer
-	store := &listing.Store{P: pool}
+	store := &listing.Store{P: pool, ExpiryDays: common.ParseInt(os.Getenv("LISTING_EXPIRY_DAYS"), listing.DefaultExpiryDays)}
+	if ttl, err := time.ParseDuration(os.Getenv("OFFER_TTL")); err == nil && ttl > 0 {
+		store.OfferTTL = ttl
+	}
+	if retention, err := time.ParseDuration(os.Getenv("LISTING_DELETE_RETENTION")); err == nil && retention > 0 {
+		store.DeleteRetention = retention
+	}
 
 	// --- Gemini AI Client ---
 	aiClient := gemini.NewClient()
@@ -42,11 +54,28 @@ func main() {
 		panic(err)
 	}
 
-	handlers := &listing.Handlers{S: store, AI: aiClient, BlobSvc: blobService}
+	// --- ISBN lookups: Open Library unless a local fixture file is configured ---
+	var bookProvider books.BookMetadataProvider = books.NewHTTPProvider(os.Getenv("BOOK_METADATA_URL"))
+	if path := os.Getenv("BOOK_METADATA_FIXTURES"); path != "" {
+		fixtures, err := books.NewFixtureProvider(path)
+		if err != nil {
+			log.Fatalf("invalid BOOK_METADATA_FIXTURES: %v", err)
+		}
+		bookProvider = fixtures
+	}
+
+	handlers := &listing.Handlers{S: store, AI: aiClient, BlobSvc: blobService, Books: bookProvider}
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger) // <-- built-in logger
 
+	// Liveness and readiness probes (outside /listings so they skip X-Request-ID enforcement)
+	health := httplib.NewHealth(2 * time.Second)
+	health.Register("postgres", pool.Ping)
+	r.Get("/health", health.LiveHandler)
+	r.Get("/health/live", health.LiveHandler)
+	r.Get("/health/ready", health.ReadyHandler)
+
 	orchestratorRequestID := os.Getenv("ORCH_REQUEST_ID")
 	r.Mount("/listings", listing.Routes(handlers, orchestratorRequestID))
 
@@ -58,10 +87,37 @@ func main() {
 		Handler: r,
 	}
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		panic(err)
+	// Drain in-flight requests on SIGINT/SIGTERM before closing the pool
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Archive listings that were not renewed in time and close lapsed offers
+	sweepInterval, err := time.ParseDuration(getenv("LISTING_EXPIRY_SWEEP_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("invalid LISTING_EXPIRY_SWEEP_INTERVAL: %v", err)
 	}
+	go store.RunExpiry(sigCtx, sweepInterval)
 
+	// Publish scheduled drafts once their publish time passes
+	publishInterval, err := time.ParseDuration(getenv("LISTING_PUBLISH_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("invalid LISTING_PUBLISH_INTERVAL: %v", err)
+	}
+	go store.RunPublisher(sigCtx, publishInterval)
+
+	// Purge deleted listings and their media once they can no longer be restored
+	purgeInterval, err := time.ParseDuration(getenv("LISTING_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("invalid LISTING_PURGE_INTERVAL: %v", err)
+	}
+	go store.RunPurge(sigCtx, purgeInterval, blobService)
+
+	if err := httplib.ListenAndServeGracefully(sigCtx, srv, health, httplib.PreStopDelayFromEnv(), 20*time.Second); err != nil {
+		log.Printf("listing-service did not shut down cleanly: %v", err)
+		pool.Close()
+		os.Exit(1)
+	}
+	log.Println("listing-service stopped")
 }
 
 func getenv(k, d string) string {
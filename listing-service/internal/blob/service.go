@@ -3,9 +3,11 @@ package blob
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
@@ -16,6 +18,7 @@ type svc struct {
 
 type BlobService interface {
 	GenerateUploadSAS(ctx context.Context, blobName string) (UploadSASResponse, error)
+	DeleteBlob(ctx context.Context, blobURL string) error
 }
 
 func NewBlobService(client *azblob.Client, credentials AzureBlobCredentials) BlobService {
@@ -71,3 +74,19 @@ func (svc *svc) GenerateUploadSAS(ctx context.Context, blobName string) (UploadS
 		BlobName:           blobName,
 	}, nil
 }
+
+// DeleteBlob deletes the blob behind a permanent public URL from our
+// container. URLs pointing anywhere else are ignored, as are blobs that
+// are already gone.
+func (svc *svc) DeleteBlob(ctx context.Context, blobURL string) error {
+	prefix := fmt.Sprintf("https://%s.blob.core.windows.net/%s/", svc.creds.AccountName, svc.creds.ContainerName)
+	blobName, ok := strings.CutPrefix(blobURL, prefix)
+	if !ok || blobName == "" {
+		return nil
+	}
+	_, err := svc.client.DeleteBlob(ctx, string(svc.creds.ContainerName), blobName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// FixtureProvider serves book metadata from a local JSON file, a map of
+// ISBN-13 to models.BookMetadata, so tests and offline setups do not call out
+// to the network. See testdata/books.json.
+type FixtureProvider struct {
+	books map[string]models.BookMetadata
+}
+
+// NewFixtureProvider loads the fixtures at path.
+func NewFixtureProvider(path string) (*FixtureProvider, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read book fixtures: %w", err)
+	}
+	var raw map[string]models.BookMetadata
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse book fixtures: %w", err)
+	}
+
+	// Keys may be written as ISBN-10 or with hyphens
+	books := make(map[string]models.BookMetadata, len(raw))
+	for key, m := range raw {
+		isbn, err := NormalizeISBN(key)
+		if err != nil {
+			return nil, fmt.Errorf("book fixture %q: %w", key, err)
+		}
+		m.ISBN = isbn
+		if m.Authors == nil {
+			m.Authors = []string{}
+		}
+		books[isbn] = m
+	}
+	return &FixtureProvider{books: books}, nil
+}
+
+func (p *FixtureProvider) LookupISBN(ctx context.Context, isbn string) (*models.BookMetadata, error) {
+	m, ok := p.books[isbn]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.FetchedAt = time.Now()
+	return &m, nil
+}
@@ -0,0 +1,107 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// DefaultOpenLibraryURL is the public Open Library API.
+const DefaultOpenLibraryURL = "https://openlibrary.org"
+
+// HTTPProvider looks books up in the Open Library books API.
+type HTTPProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHTTPProvider creates a provider for the Open Library API at baseURL,
+// DefaultOpenLibraryURL when empty.
+func NewHTTPProvider(baseURL string) *HTTPProvider {
+	if baseURL == "" {
+		baseURL = DefaultOpenLibraryURL
+	}
+	return &HTTPProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// openLibraryBook is the part of a jscmd=details entry we use.
+type openLibraryBook struct {
+	ThumbnailURL string `json:"thumbnail_url"`
+	Details      struct {
+		Title    string `json:"title"`
+		Subtitle string `json:"subtitle"`
+		Authors  []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		EditionName string   `json:"edition_name"`
+		Publishers  []string `json:"publishers"`
+		PublishDate string   `json:"publish_date"`
+		Covers      []int64  `json:"covers"`
+		Works       []struct {
+			Key string `json:"key"`
+		} `json:"works"`
+	} `json:"details"`
+}
+
+func (p *HTTPProvider) LookupISBN(ctx context.Context, isbn string) (*models.BookMetadata, error) {
+	q := url.Values{"bibkeys": {"ISBN:" + isbn}, "format": {"json"}, "jscmd": {"details"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/books?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating book lookup request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending book lookup request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("book lookup returned non-200 status: %s", resp.Status)
+	}
+
+	// Unknown ISBNs come back as an empty object
+	var found map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return nil, fmt.Errorf("error decoding book lookup response: %w", err)
+	}
+	book, ok := found["ISBN:"+isbn]
+	if !ok || book.Details.Title == "" {
+		return nil, ErrNotFound
+	}
+
+	d := book.Details
+	m := &models.BookMetadata{
+		ISBN:        isbn,
+		Title:       d.Title,
+		Authors:     []string{},
+		Edition:     d.EditionName,
+		PublishDate: d.PublishDate,
+		FetchedAt:   time.Now(),
+	}
+	if d.Subtitle != "" {
+		m.Title += ": " + d.Subtitle
+	}
+	for _, a := range d.Authors {
+		m.Authors = append(m.Authors, a.Name)
+	}
+	if len(d.Publishers) > 0 {
+		m.Publisher = d.Publishers[0]
+	}
+	if len(d.Works) > 0 {
+		m.WorkID = d.Works[0].Key
+	}
+	if len(d.Covers) > 0 && d.Covers[0] > 0 {
+		m.CoverURL = fmt.Sprintf("https://covers.openlibrary.org/b/id/%d-L.jpg", d.Covers[0])
+	}
+	return m, nil
+}
@@ -0,0 +1,74 @@
+// Package books looks up textbook metadata by ISBN.
+package books
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// ErrNotFound is returned when a provider has no record of an ISBN.
+var ErrNotFound = errors.New("book not found")
+
+// BookMetadataProvider resolves an ISBN-13 to the metadata of that edition.
+type BookMetadataProvider interface {
+	LookupISBN(ctx context.Context, isbn string) (*models.BookMetadata, error)
+}
+
+// NormalizeISBN strips separators from an ISBN-10 or ISBN-13, checks its
+// check digit and returns it as an ISBN-13, so both forms of an edition
+// compare equal.
+func NormalizeISBN(s string) (string, error) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, c := range s {
+			var d int
+			switch {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case c == 'X' && i == 9:
+				d = 10
+			default:
+				return "", errInvalidISBN
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return "", errInvalidISBN
+		}
+		isbn := "978" + s[:9]
+		return isbn + string(rune('0'+isbn13CheckDigit(isbn))), nil
+	case 13:
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return "", errInvalidISBN
+			}
+		}
+		if isbn13CheckDigit(s[:12]) != int(s[12]-'0') {
+			return "", errInvalidISBN
+		}
+		return s, nil
+	}
+	return "", errInvalidISBN
+}
+
+var errInvalidISBN = fmt.Errorf("isbn must be a valid ISBN-10 or ISBN-13")
+
+// isbn13CheckDigit computes the check digit of the first 12 digits of an
+// ISBN-13.
+func isbn13CheckDigit(digits string) int {
+	sum := 0
+	for i, c := range digits[:12] {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
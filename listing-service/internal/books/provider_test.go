@@ -0,0 +1,98 @@
+package books
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ISBN-13", "9780134685991", "9780134685991"},
+		{"ISBN-13 with hyphens", "978-0-13-468599-1", "9780134685991"},
+		{"ISBN-13 with spaces", " 978 0 13 468599 1 ", "9780134685991"},
+		{"ISBN-10", "0134685997", "9780134685991"},
+		{"ISBN-10 with hyphens", "0-262-03384-4", "9780262033848"},
+		{"ISBN-10 with X check digit", "080442957X", "9780804429573"},
+		{"ISBN-10 with lowercase x", "080442957x", "9780804429573"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeISBN(tt.in)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeISBNInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"too short", "013468599"},
+		{"too long", "97801346859912"},
+		{"ISBN-13 bad check digit", "9780134685990"},
+		{"ISBN-10 bad check digit", "0134685998"},
+		{"X outside the check digit", "01346X5997"},
+		{"ISBN-13 with X", "978013468599X"},
+		{"letters", "abcdefghij"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := NormalizeISBN(tt.in); err == nil {
+				t.Errorf("Expected an error, got %s", got)
+			}
+		})
+	}
+}
+
+func TestFixtureProvider(t *testing.T) {
+	p, err := NewFixtureProvider("testdata/books.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	t.Run("HyphenatedKeyIsNormalized", func(t *testing.T) {
+		m, err := p.LookupISBN(context.Background(), "9780262046305")
+		if err != nil {
+			t.Fatalf("Expected the 4th edition, got %v", err)
+		}
+		if m.ISBN != "9780262046305" || m.Edition != "4th ed." {
+			t.Errorf("Expected 9780262046305 4th ed., got %s %s", m.ISBN, m.Edition)
+		}
+		if m.FetchedAt.IsZero() {
+			t.Error("Expected fetched_at to be set")
+		}
+	})
+
+	t.Run("ISBN10LooksUpTheSameEdition", func(t *testing.T) {
+		isbn, err := NormalizeISBN("0-262-03384-4")
+		if err != nil {
+			t.Fatalf("Failed to normalize: %v", err)
+		}
+		m, err := p.LookupISBN(context.Background(), isbn)
+		if err != nil {
+			t.Fatalf("Expected the 3rd edition, got %v", err)
+		}
+		if m.WorkID != "fixture-clrs" || m.Edition != "3rd ed." {
+			t.Errorf("Expected fixture-clrs 3rd ed., got %s %s", m.WorkID, m.Edition)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := p.LookupISBN(context.Background(), "9780201633610")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -134,8 +134,15 @@ The JSON object should have the following optional fields:
 - "keywords": array of strings (Include the original keyword plus relevant synonyms or specific examples. Be creative.)
 - "min_price": number
 - "max_price": number
+- "condition": string (one of: %s; only when the user asks for a condition)
+- "tags": array of strings (only short labels the user explicitly asks for, e.g. "dorm", "free pickup")
+- "pickup_location": string (a campus spot the user wants to pick up from, e.g. "MLK Library")
+- "attributes": object of strings (only when the user names one; set "category" to the matching category)
+%s
 
 **Important Rule:** If the user query contains a specific identifier like a course code (e.g., 'CMPE202'), brand, or model number, focus the keywords on that specific identifier. Avoid adding general synonyms that would make the search too broad.
+Put identifiers that are attributes (ISBN, course code, brand, model, storage) into "attributes" rather than "keywords".
+Course codes are looked up in the course catalog, which knows the books each course uses: put the code in "attributes" and do not guess keywords for the course's subject or books.
 
 Here are some examples:
 
@@ -145,13 +152,17 @@ Your JSON response:
 
 User query: "textbook for cmpe202"
 Your JSON response:
-{"category": "textbooks", "keywords": ["cmpe202", "algorithms"]}
+{"category": "TEXTBOOK", "attributes": {"course_code": "CMPE202"}}
+
+User query: "used iphone with 256gb in good condition"
+Your JSON response:
+{"category": "GADGET", "keywords": ["iphone"], "attributes": {"brand": "Apple", "storage": "256GB"}, "condition": "GOOD"}
 
 User query: "something for my dorm room"
 Your JSON response:
 {"category": "essentials", "keywords": ["dorm", "room", "lamp", "desk", "chair", "microwave"]}
 
-When you have conversation history, use it to understand context and refine your search parameters. For example, if the user previously asked about "textbooks" and now asks "something cheaper", you should search for textbooks with a lower price range.`, CategoriesAsString())
+When you have conversation history, use it to understand context and refine your search parameters. For example, if the user previously asked about "textbooks" and now asks "something cheaper", you should search for textbooks with a lower price range.`, CategoriesAsString(), ConditionsAsString(), AttributesAsString())
 }
 
 func getPrompt(userQuery string) string {
@@ -188,3 +199,29 @@ func CategoriesAsString() string {
 	}
 	return strings.Join(cats, ",")
 }
+
+func ConditionsAsString() string {
+	conds := make([]string, len(models.AllConditions))
+	for i, c := range models.AllConditions {
+		conds[i] = fmt.Sprintf(`"%s"`, c)
+	}
+	return strings.Join(conds, ",")
+}
+
+// AttributesAsString describes the attribute keys of each category, one
+// prompt line per category.
+func AttributesAsString() string {
+	var lines []string
+	for _, cat := range models.AllCategories {
+		specs := models.CategoryAttributes[cat]
+		if len(specs) == 0 {
+			continue
+		}
+		keys := make([]string, len(specs))
+		for i, spec := range specs {
+			keys[i] = fmt.Sprintf(`"%s" (%s)`, spec.Key, spec.Description)
+		}
+		lines = append(lines, fmt.Sprintf("  - for %s: %s", cat, strings.Join(keys, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
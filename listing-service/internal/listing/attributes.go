@@ -0,0 +1,211 @@
+package listing
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/books"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+var (
+	courseCodePattern = regexp.MustCompile(`^[A-Z]{2,6}[0-9]{1,4}[A-Z]?$`)
+	storagePattern    = regexp.MustCompile(`^[0-9]+(MB|GB|TB)$`)
+)
+
+// fieldError is a validation failure found by the store, reported to the
+// client as a 400 on Field.
+type fieldError struct {
+	httplib.FieldError
+}
+
+func (e *fieldError) Error() string {
+	return e.Message
+}
+
+// attributeSpec finds the spec of key among the attributes of any category.
+func attributeSpec(key string) (models.AttributeSpec, bool) {
+	for _, specs := range models.CategoryAttributes {
+		for _, spec := range specs {
+			if spec.Key == key {
+				return spec, true
+			}
+		}
+	}
+	return models.AttributeSpec{}, false
+}
+
+// attributeKeys lists the attribute keys cat accepts, for error messages.
+func attributeKeys(cat models.Category) string {
+	var keys []string
+	for _, spec := range models.CategoryAttributes[cat] {
+		keys = append(keys, spec.Key)
+	}
+	if len(keys) == 0 {
+		return "none"
+	}
+	return strings.Join(keys, ", ")
+}
+
+// canonicalAttribute reports whether normalizeAttributeValue reduces the
+// values of key to one spelling, so they can be matched exactly.
+func canonicalAttribute(key string) bool {
+	return key == models.AttrISBN || key == models.AttrCourseCode || key == models.AttrStorage
+}
+
+// normalizeAttributeValue canonicalises one attribute value so that stored
+// values and filters compare equal, e.g. "0-13-468599-7" and "9780134685991".
+func normalizeAttributeValue(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > models.MaxAttributeValueLen {
+		return "", fmt.Errorf("%s must be at most %d characters", key, models.MaxAttributeValueLen)
+	}
+	switch key {
+	case models.AttrISBN:
+		// ISBN-10s are stored as ISBN-13 so both forms match
+		isbn, err := books.NormalizeISBN(value)
+		if err != nil {
+			return "", err
+		}
+		value = isbn
+	case models.AttrCourseCode:
+		value = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(value))
+		if !courseCodePattern.MatchString(value) {
+			return "", fmt.Errorf("course_code must look like CMPE202")
+		}
+	case models.AttrStorage:
+		value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+		if !storagePattern.MatchString(value) {
+			return "", fmt.Errorf("storage must look like 256GB")
+		}
+	}
+	return value, nil
+}
+
+// normalizeAttributes validates attrs against the attributes cat accepts,
+// dropping empty values. The result is never nil.
+func normalizeAttributes(cat models.Category, attrs models.Attributes) (models.Attributes, *httplib.FieldError) {
+	out := models.Attributes{}
+	for key, value := range attrs {
+		if !slices.ContainsFunc(models.CategoryAttributes[cat], func(s models.AttributeSpec) bool { return s.Key == key }) {
+			return nil, &httplib.FieldError{Field: "attributes." + key, Code: "invalid",
+				Message: fmt.Sprintf("%s listings do not take %q; expected one of: %s", cat, key, attributeKeys(cat))}
+		}
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		v, err := normalizeAttributeValue(key, value)
+		if err != nil {
+			return nil, &httplib.FieldError{Field: "attributes." + key, Code: "invalid", Message: err.Error()}
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// normalizeTag lowercases t and collapses its whitespace.
+func normalizeTag(t string) string {
+	return strings.ToLower(strings.Join(strings.Fields(t), " "))
+}
+
+// normalizeTags trims, lowercases and de-duplicates tags. The result is never
+// nil.
+func normalizeTags(tags []string) ([]string, *httplib.FieldError) {
+	out := []string{}
+	for _, t := range tags {
+		t = normalizeTag(t)
+		if t == "" || slices.Contains(out, t) {
+			continue
+		}
+		if len(t) > models.MaxTagLength {
+			return nil, &httplib.FieldError{Field: "tags", Code: "invalid",
+				Message: fmt.Sprintf("tags must be at most %d characters", models.MaxTagLength)}
+		}
+		out = append(out, t)
+	}
+	if len(out) > models.MaxTags {
+		return nil, &httplib.FieldError{Field: "tags", Code: "invalid",
+			Message: fmt.Sprintf("a listing takes at most %d tags", models.MaxTags)}
+	}
+	return out, nil
+}
+
+// validateCondition checks c against models.AllConditions; nil is valid.
+func validateCondition(c *models.Condition) *httplib.FieldError {
+	if c == nil || slices.Contains(models.AllConditions, *c) {
+		return nil
+	}
+	return &httplib.FieldError{Field: "condition", Code: "invalid",
+		Message: fmt.Sprintf("condition must be one of %s", conditionsAsString())}
+}
+
+// normalizePickup trims the pickup location, turning a blank one into nil.
+func normalizePickup(p *string) (*string, *httplib.FieldError) {
+	if p == nil {
+		return nil, nil
+	}
+	v := strings.TrimSpace(*p)
+	if v == "" {
+		return nil, nil
+	}
+	if len(v) > models.MaxPickupLength {
+		return nil, &httplib.FieldError{Field: "pickup_location", Code: "invalid",
+			Message: fmt.Sprintf("pickup_location must be at most %d characters", models.MaxPickupLength)}
+	}
+	return &v, nil
+}
+
+func conditionsAsString() string {
+	names := make([]string, len(models.AllConditions))
+	for i, c := range models.AllConditions {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
+
+// normalizeFilters canonicalises the attribute filters of f. Unknown keys and
+// values that could never match are reported so a typo does not silently
+// return nothing; lenient drops them instead, for filters derived by the AI.
+func normalizeFilters(f *models.ListFilters, lenient bool) *httplib.FieldError {
+	if fe := validateCondition(f.Condition); fe != nil {
+		if !lenient {
+			return fe
+		}
+		f.Condition = nil
+	}
+	if f.Pickup != nil && strings.TrimSpace(*f.Pickup) == "" {
+		f.Pickup = nil
+	}
+	tags := []string{}
+	for _, t := range f.Tags {
+		if t = normalizeTag(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	f.Tags = tags
+	attrs := models.Attributes{}
+	for key, value := range f.Attributes {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		if _, ok := attributeSpec(key); !ok {
+			if lenient {
+				continue
+			}
+			return &httplib.FieldError{Field: "attr." + key, Code: "invalid", Message: fmt.Sprintf("unknown attribute %q", key)}
+		}
+		v, err := normalizeAttributeValue(key, value)
+		if err != nil {
+			if lenient {
+				continue
+			}
+			return &httplib.FieldError{Field: "attr." + key, Code: "invalid", Message: err.Error()}
+		}
+		attrs[key] = v
+	}
+	f.Attributes = attrs
+	return nil
+}
@@ -0,0 +1,56 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// Audit actions recorded by listing-service
+const (
+	auditListingUpdate  = "listing.update"
+	auditListingArchive = "listing.archive"
+	auditListingDelete  = "listing.delete"
+	auditListingRestore = "listing.restore"
+	auditMediaUpdate    = "listing_media.update"
+	auditMediaDelete    = "listing_media.delete"
+	auditFlagUpdate     = "flag.update"
+	auditFlagDelete     = "flag.delete"
+)
+
+// auditEvent is a row of the append-only audit_events table.
+type auditEvent struct {
+	ActorUserID string
+	ActorRole   string
+	Action      string
+	TargetType  string
+	TargetID    any
+	Reason      string
+	Before      any
+	After       any
+}
+
+// recordAudit writes ev inside tx so the audit row commits or rolls back
+// together with the change it describes.
+func recordAudit(ctx context.Context, tx pgx.Tx, ev auditEvent) error {
+	return httplib.RecordAudit(ctx, tx, httplib.AuditEvent{
+		ActorUserID: ev.ActorUserID,
+		ActorRole:   ev.ActorRole,
+		Action:      ev.Action,
+		TargetType:  ev.TargetType,
+		TargetID:    fmt.Sprint(ev.TargetID),
+		Reason:      ev.Reason,
+		Before:      ev.Before,
+		After:       ev.After,
+	})
+}
+
+// isAdminOverride reports whether an admin is acting on a listing owned by
+// someone else, which is what makes a listing write privileged.
+func isAdminOverride(l models.Listing, userID string, userRole string) bool {
+	return userRole == string(httplib.ADMIN) && l.UserID.String() != userID
+}
@@ -0,0 +1,108 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/books"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// BookCacheTTL is how long looked up book metadata is reused before the
+// provider is asked again.
+const BookCacheTTL = 30 * 24 * time.Hour
+
+// bookColumns is the column list selected for book metadata; scan it with bookFields.
+const bookColumns = "isbn, COALESCE(work_id, ''), title, authors, COALESCE(edition, ''), COALESCE(publisher, ''), COALESCE(publish_date, ''), COALESCE(cover_url, ''), fetched_at"
+
+func bookFields(m *models.BookMetadata) []any {
+	return []any{&m.ISBN, &m.WorkID, &m.Title, &m.Authors, &m.Edition, &m.Publisher, &m.PublishDate, &m.CoverURL, &m.FetchedAt}
+}
+
+// GetBook returns the cached metadata of isbn, or nil when it was never
+// looked up.
+func (s *Store) GetBook(ctx context.Context, isbn string) (*models.BookMetadata, error) {
+	var m models.BookMetadata
+	err := s.P.QueryRow(ctx, `SELECT `+bookColumns+` FROM book_metadata WHERE isbn=$1`, isbn).Scan(bookFields(&m)...)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load book metadata: %w", err)
+	}
+	return &m, nil
+}
+
+// SaveBook caches m, replacing an earlier lookup of the same ISBN.
+func (s *Store) SaveBook(ctx context.Context, m models.BookMetadata) error {
+	_, err := s.P.Exec(ctx, `
+		INSERT INTO book_metadata (isbn, work_id, title, authors, edition, publisher, publish_date, cover_url, fetched_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9)
+		ON CONFLICT (isbn) DO UPDATE SET
+			work_id=EXCLUDED.work_id, title=EXCLUDED.title, authors=EXCLUDED.authors, edition=EXCLUDED.edition,
+			publisher=EXCLUDED.publisher, publish_date=EXCLUDED.publish_date, cover_url=EXCLUDED.cover_url,
+			fetched_at=EXCLUDED.fetched_at
+	`, m.ISBN, m.WorkID, m.Title, m.Authors, m.Edition, m.Publisher, m.PublishDate, m.CoverURL, m.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save book metadata: %w", err)
+	}
+	return nil
+}
+
+// lookupBook returns the metadata of isbn from the cache, asking the
+// provider when it is missing or older than BookCacheTTL. A stale entry is
+// still returned when the provider is unavailable.
+func (h *Handlers) lookupBook(ctx context.Context, isbn string) (*models.BookMetadata, error) {
+	cached, err := h.S.GetBook(ctx, isbn)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && time.Since(cached.FetchedAt) < BookCacheTTL {
+		return cached, nil
+	}
+	if h.Books == nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, books.ErrNotFound
+	}
+
+	m, err := h.Books.LookupISBN(ctx, isbn)
+	if err != nil {
+		if cached != nil && !errors.Is(err, books.ErrNotFound) {
+			log.Printf("book lookup for %s failed, using cached metadata: %v", isbn, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := h.S.SaveBook(ctx, *m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// rememberBook caches the metadata of a listed ISBN in the background, so
+// ISBN searches can match the listing from its other editions.
+func (h *Handlers) rememberBook(attrs models.Attributes) {
+	if isbn := attrs[models.AttrISBN]; isbn != "" {
+		h.rememberBooks([]string{isbn})
+	}
+}
+
+// rememberBooks looks up isbns one after another in the background.
+func (h *Handlers) rememberBooks(isbns []string) {
+	go func() {
+		for _, isbn := range isbns {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			if _, err := h.lookupBook(ctx, isbn); err != nil && !errors.Is(err, books.ErrNotFound) {
+				log.Printf("failed to look up book %s: %v", isbn, err)
+			}
+			cancel()
+		}
+	}()
+}
@@ -0,0 +1,262 @@
+package listing
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// maxImportSize caps the body of a bulk import.
+const maxImportSize = 2 << 20
+
+// rowError builds the report entry of a failed import row.
+func rowError(row int, fe httplib.FieldError) models.ImportRowError {
+	return models.ImportRowError{Row: row, Field: fe.Field, Code: fe.Code, Message: fe.Message}
+}
+
+// decodeImportJSON reads a JSON array of listings.
+func decodeImportJSON(r io.Reader) ([]models.CreateParams, error) {
+	var ps []models.CreateParams
+	if err := json.NewDecoder(r).Decode(&ps); err != nil {
+		return nil, fmt.Errorf("body must be a JSON array of listings: %w", err)
+	}
+	return ps, nil
+}
+
+// decodeImportCSV reads CSV rows in the layout of models.ImportColumns.
+// Cells that cannot be parsed are reported per row; the other cells of the
+// row are still read so that validation can report them too.
+func decodeImportCSV(r io.Reader) ([]models.CreateParams, []models.ImportRowError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing CSV header")
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(models.ImportColumns, name) {
+			return nil, nil, fmt.Errorf("unknown column %q; expected %s", name, strings.Join(models.ImportColumns, ","))
+		}
+		if _, dup := cols[name]; dup {
+			return nil, nil, fmt.Errorf("column %q appears twice", name)
+		}
+		cols[name] = i
+	}
+	for _, name := range []string{"title", "price", "category"} {
+		if _, ok := cols[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var ps []models.CreateParams
+	var errs []models.ImportRowError
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			// The row still counts so later rows keep their numbers
+			errs = append(errs, models.ImportRowError{Row: row, Field: "row", Code: "invalid", Message: perr.Err.Error()})
+			ps = append(ps, models.CreateParams{})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cell := func(name string) string {
+			if i, ok := cols[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		p := models.CreateParams{Title: cell("title"), Category: models.Category(strings.ToUpper(cell("category")))}
+		if v := cell("price"); v != "" {
+			price, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = append(errs, models.ImportRowError{Row: row, Field: "price", Code: "invalid", Message: "price must be a whole number of cents"})
+			}
+			p.Price = price
+		}
+		if v := cell("description"); v != "" {
+			p.Description = &v
+		}
+		if v := cell("condition"); v != "" {
+			c := models.Condition(strings.ToUpper(v))
+			p.Condition = &c
+		}
+		if v := cell("pickup_location"); v != "" {
+			p.PickupLocation = &v
+		}
+		if v := cell("tags"); v != "" {
+			p.Tags = strings.Split(v, ";")
+		}
+		for _, pair := range strings.Split(cell("attributes"), ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				errs = append(errs, models.ImportRowError{Row: row, Field: "attributes", Code: "invalid",
+					Message: fmt.Sprintf("%q is not a key=value pair", pair)})
+				continue
+			}
+			if p.Attributes == nil {
+				p.Attributes = models.Attributes{}
+			}
+			p.Attributes[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+		ps = append(ps, p)
+	}
+	return ps, errs, nil
+}
+
+// validateImport checks and normalises every row the way CreateHandler does
+// a single listing, adding the rows' errors to those found while decoding.
+// Rows that could not be decoded are not checked again.
+func validateImport(ps []models.CreateParams, decodeErrs []models.ImportRowError) []models.ImportRowError {
+	errs := slices.Clone(decodeErrs)
+	for i := range ps {
+		row := i + 1
+		if slices.ContainsFunc(decodeErrs, func(e models.ImportRowError) bool { return e.Row == row }) {
+			continue
+		}
+		fields := requiredFields(ps[i])
+		if ps[i].Category != "" && !slices.Contains(models.AllCategories, ps[i].Category) {
+			fields = append(fields, httplib.FieldError{Field: "category", Code: "invalid",
+				Message: fmt.Sprintf("category must be one of %s", categoriesAsString())})
+		}
+		if len(fields) == 0 {
+			if fe := normalizeDetails(&ps[i]); fe != nil {
+				fields = append(fields, *fe)
+			}
+		}
+		for _, fe := range fields {
+			errs = append(errs, rowError(row, fe))
+		}
+	}
+	slices.SortStableFunc(errs, func(a, b models.ImportRowError) int { return a.Row - b.Row })
+	return errs
+}
+
+func categoriesAsString() string {
+	names := make([]string, len(models.AllCategories))
+	for i, c := range models.AllCategories {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
+
+// encodeExportCSV writes listings in the layout decodeImportCSV reads.
+func encodeExportCSV(w io.Writer, ls []models.Listing) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(models.ImportColumns); err != nil {
+		return err
+	}
+	for _, l := range ls {
+		var desc, cond, pickup string
+		if l.Description != nil {
+			desc = *l.Description
+		}
+		if l.Condition != nil {
+			cond = string(*l.Condition)
+		}
+		if l.PickupLocation != nil {
+			pickup = *l.PickupLocation
+		}
+		var attrs []string
+		for _, key := range slices.Sorted(maps.Keys(l.Attributes)) {
+			attrs = append(attrs, key+"="+l.Attributes[key])
+		}
+		err := cw.Write([]string{l.Title, desc, strconv.FormatInt(l.Price, 10), string(l.Category), cond, pickup,
+			strings.Join(l.Tags, ";"), strings.Join(attrs, ";")})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportParams turns listings into the rows a JSON import accepts.
+func exportParams(ls []models.Listing) []models.CreateParams {
+	out := make([]models.CreateParams, len(ls))
+	for i, l := range ls {
+		out[i] = models.CreateParams{
+			Title:          l.Title,
+			Description:    l.Description,
+			Price:          l.Price,
+			Category:       l.Category,
+			Condition:      l.Condition,
+			PickupLocation: l.PickupLocation,
+			Tags:           l.Tags,
+			Attributes:     l.Attributes,
+			Draft:          l.Status == models.StDraft,
+			PublishAt:      l.PublishAt,
+		}
+	}
+	return out
+}
+
+// CreateMany creates the listings of a bulk import in one transaction, so a
+// failure, including a row repeating another listing, creates none of them.
+func (s *Store) CreateMany(ctx context.Context, userID string, ps []models.CreateParams) ([]models.Listing, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	out := make([]models.Listing, 0, len(ps))
+	for i, p := range ps {
+		l, err := insertListing(ctx, tx, s.expiryDays(), userID, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create listing of row %d: %w", i+1, err)
+		}
+		// Rows are checked against each other as well as existing listings
+		if err := fingerprintListing(ctx, tx, l.ID, true); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		out = append(out, l)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return out, nil
+}
+
+// ExportListings returns every listing userID owns, oldest first so a
+// re-import keeps their order.
+func (s *Store) ExportListings(ctx context.Context, userID string) ([]models.Listing, error) {
+	rows, err := s.P.Query(ctx, `SELECT `+listingColumns+` FROM listings WHERE user_id=$1::uuid AND deleted_at IS NULL ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listings: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.Listing{}
+	for rows.Next() {
+		var l models.Listing
+		if err := rows.Scan(listingFields(&l)...); err != nil {
+			return nil, fmt.Errorf("failed to scan listing: %w", err)
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,229 @@
+package listing
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/books"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+var errCourseNotFound = errors.New("course not found")
+
+// courseCSVHeader is the header a catalog import must start with. The ISBN
+// columns hold ISBN-10s or ISBN-13s separated by semicolons or spaces.
+var courseCSVHeader = []string{"code", "title", "required_isbns", "optional_isbns"}
+
+// parseCourseCSV reads a catalog import, reporting every invalid row rather
+// than stopping at the first.
+func parseCourseCSV(r io.Reader) ([]models.Course, []httplib.FieldError) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(courseCSVHeader)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, []httplib.FieldError{{Field: "line 1", Code: "invalid", Message: "missing CSV header"}}
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	if !slices.Equal(header, courseCSVHeader) {
+		return nil, []httplib.FieldError{{Field: "line 1", Code: "invalid",
+			Message: "header must be " + strings.Join(courseCSVHeader, ",")}}
+	}
+
+	var courses []models.Course
+	var fields []httplib.FieldError
+	seen := map[string]int{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			fields = append(fields, httplib.FieldError{Field: fmt.Sprintf("line %d", perr.Line), Code: "invalid",
+				Message: perr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, []httplib.FieldError{{Field: "body", Code: "invalid", Message: err.Error()}}
+		}
+		line, _ := cr.FieldPos(0)
+		lineField := fmt.Sprintf("line %d", line)
+
+		code, err := normalizeAttributeValue(models.AttrCourseCode, record[0])
+		if err != nil {
+			fields = append(fields, httplib.FieldError{Field: lineField, Code: "invalid", Message: err.Error()})
+			continue
+		}
+		if first, dup := seen[code]; dup {
+			fields = append(fields, httplib.FieldError{Field: lineField, Code: "duplicate",
+				Message: fmt.Sprintf("%s was already listed on line %d", code, first)})
+			continue
+		}
+		seen[code] = line
+
+		c := models.Course{Code: code, Title: strings.TrimSpace(record[1]), Books: []models.CourseBook{}}
+		if c.Title == "" {
+			fields = append(fields, httplib.FieldError{Field: lineField, Code: "required", Message: "title is required"})
+			continue
+		}
+		for col := 2; col <= 3; col++ {
+			required := col == 2
+			for _, raw := range strings.FieldsFunc(record[col], func(r rune) bool { return r == ';' || r == ' ' }) {
+				isbn, err := books.NormalizeISBN(raw)
+				if err != nil {
+					fields = append(fields, httplib.FieldError{Field: lineField, Code: "invalid",
+						Message: fmt.Sprintf("%s: %q is not a valid ISBN", courseCSVHeader[col], raw)})
+					continue
+				}
+				// A book listed as both required and optional counts as required
+				if i := slices.IndexFunc(c.Books, func(b models.CourseBook) bool { return b.ISBN == isbn }); i >= 0 {
+					c.Books[i].Required = c.Books[i].Required || required
+					continue
+				}
+				c.Books = append(c.Books, models.CourseBook{ISBN: isbn, Required: required})
+			}
+		}
+		courses = append(courses, c)
+	}
+	if len(courses) == 0 && len(fields) == 0 {
+		fields = append(fields, httplib.FieldError{Field: "line 2", Code: "required", Message: "the CSV has no courses"})
+	}
+	return courses, fields
+}
+
+// ImportCourses creates or updates the given courses, replacing the book
+// lists of those already in the catalog. Courses not in the import are kept.
+func (s *Store) ImportCourses(ctx context.Context, courses []models.Course) (models.CourseImportResult, error) {
+	var res models.CourseImportResult
+
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return res, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, c := range courses {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO courses (code, title) VALUES ($1, $2)
+			ON CONFLICT (code) DO UPDATE SET title=EXCLUDED.title, updated_at=NOW()
+		`, c.Code, c.Title)
+		if err != nil {
+			return res, fmt.Errorf("failed to save course %s: %w", c.Code, err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM course_books WHERE course_code=$1`, c.Code); err != nil {
+			return res, fmt.Errorf("failed to clear books of course %s: %w", c.Code, err)
+		}
+		for _, b := range c.Books {
+			_, err := tx.Exec(ctx, `INSERT INTO course_books (course_code, isbn, required) VALUES ($1, $2, $3)`,
+				c.Code, b.ISBN, b.Required)
+			if err != nil {
+				return res, fmt.Errorf("failed to save book %s of course %s: %w", b.ISBN, c.Code, err)
+			}
+			res.Books++
+		}
+		res.Courses++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return res, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+// GetCourse returns a course with its books, required first.
+func (s *Store) GetCourse(ctx context.Context, code string) (models.Course, error) {
+	c := models.Course{Books: []models.CourseBook{}}
+	err := s.P.QueryRow(ctx, `SELECT code, title, created_at, updated_at FROM courses WHERE code=$1`, code).
+		Scan(&c.Code, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return c, errCourseNotFound
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to load course: %w", err)
+	}
+
+	rows, err := s.P.Query(ctx, `
+		SELECT isbn, required FROM course_books WHERE course_code=$1 ORDER BY required DESC, isbn
+	`, code)
+	if err != nil {
+		return c, fmt.Errorf("failed to load course books: %w", err)
+	}
+	var isbns []string
+	for rows.Next() {
+		var cb models.CourseBook
+		if err := rows.Scan(&cb.ISBN, &cb.Required); err != nil {
+			rows.Close()
+			return c, fmt.Errorf("failed to scan course book: %w", err)
+		}
+		c.Books = append(c.Books, cb)
+		isbns = append(isbns, cb.ISBN)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c, fmt.Errorf("failed to load course books: %w", err)
+	}
+
+	// Attach whatever metadata has been cached; books never looked up stay bare
+	rows, err = s.P.Query(ctx, `SELECT `+bookColumns+` FROM book_metadata WHERE isbn = ANY($1)`, isbns)
+	if err != nil {
+		return c, fmt.Errorf("failed to load book metadata: %w", err)
+	}
+	defer rows.Close()
+	known := map[string]*models.BookMetadata{}
+	for rows.Next() {
+		var m models.BookMetadata
+		if err := rows.Scan(bookFields(&m)...); err != nil {
+			return c, fmt.Errorf("failed to scan book metadata: %w", err)
+		}
+		known[m.ISBN] = &m
+	}
+	for i := range c.Books {
+		c.Books[i].Book = known[c.Books[i].ISBN]
+	}
+	return c, rows.Err()
+}
+
+// CourseExists reports whether code is in the catalog.
+func (s *Store) CourseExists(ctx context.Context, code string) (bool, error) {
+	var exists bool
+	err := s.P.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM courses WHERE code=$1)`, code).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up course: %w", err)
+	}
+	return exists, nil
+}
+
+// resolveCourse turns a course_code attribute filter derived by the AI into a
+// catalog course filter, so the search finds the course's books rather than
+// only listings tagged with the code. Keywords repeating the code are dropped
+// since they would rarely appear in a book's title. Codes not in the catalog
+// are left as attribute filters.
+func (h *Handlers) resolveCourse(ctx context.Context, f *models.ListFilters) error {
+	code := f.Attributes[models.AttrCourseCode]
+	if code == "" {
+		return nil
+	}
+	exists, err := h.S.CourseExists(ctx, code)
+	if err != nil || !exists {
+		return err
+	}
+	f.Course = &code
+	delete(f.Attributes, models.AttrCourseCode)
+	f.Keywords = slices.DeleteFunc(f.Keywords, func(k string) bool {
+		c, err := normalizeAttributeValue(models.AttrCourseCode, k)
+		return err == nil && c == code
+	})
+	return nil
+}
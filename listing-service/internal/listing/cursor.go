@@ -0,0 +1,90 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxPageSize caps every keyset page regardless of the requested limit.
+const maxPageSize = 100
+
+// pageCursor is the position of the last row of a page. Only the sort keys
+// of the ordering that produced it are set; ID always breaks ties.
+type pageCursor struct {
+	Rank      *float32   `json:"r,omitempty"`
+	CreatedAt *time.Time `json:"t,omitempty"`
+	Price     *int64     `json:"p,omitempty"`
+	ID        int64      `json:"id"`
+}
+
+// encode renders c as the opaque next_cursor handed to clients.
+func (c pageCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor produced by encode. An empty string is the
+// first page and yields nil.
+func decodeCursor(s string) (*pageCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// pageLimit clamps a requested page size, using def when none was given.
+func pageLimit(limit, def int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+// keysetColumn is one ORDER BY term together with the cursor's value for it.
+type keysetColumn struct {
+	expr  string
+	desc  bool
+	value any
+}
+
+func (c keysetColumn) orderBy() string {
+	if c.desc {
+		return c.expr + " DESC"
+	}
+	return c.expr + " ASC"
+}
+
+// keysetCondition returns a predicate selecting the rows that sort after the
+// cursor under cols, numbering its placeholders from $n.
+func keysetCondition(cols []keysetColumn, n int) (string, []any) {
+	ors := make([]string, 0, len(cols))
+	args := make([]any, 0, len(cols))
+	for i, col := range cols {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = $%d", cols[j].expr, n+j))
+		}
+		op := ">"
+		if col.desc {
+			op = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s $%d", col.expr, op, n+i))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		args = append(args, col.value)
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
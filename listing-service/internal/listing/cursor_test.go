@@ -0,0 +1,124 @@
+package listing
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	rank := float32(0.75)
+	createdAt := time.Date(2025, 3, 14, 15, 9, 26, 535000000, time.UTC)
+	price := int64(4200)
+	tests := []struct {
+		name   string
+		cursor pageCursor
+	}{
+		{"ID only", pageCursor{ID: 7}},
+		{"created_at", pageCursor{CreatedAt: &createdAt, ID: 8}},
+		{"price", pageCursor{Price: &price, ID: 9}},
+		{"rank", pageCursor{Rank: &rank, CreatedAt: &createdAt, ID: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor.encode())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.cursor) {
+				t.Errorf("Expected %+v, got %+v", tt.cursor, *got)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	t.Run("EmptyIsFirstPage", func(t *testing.T) {
+		c, err := decodeCursor("")
+		if err != nil || c != nil {
+			t.Errorf("Expected nil cursor and no error, got %v, %v", c, err)
+		}
+	})
+
+	invalid := map[string]string{
+		"not base64":     "!!!",
+		"not JSON":       base64.RawURLEncoding.EncodeToString([]byte("id=7")),
+		"missing ID":     base64.RawURLEncoding.EncodeToString([]byte(`{"p":100}`)),
+		"wrong type":     base64.RawURLEncoding.EncodeToString([]byte(`{"id":"7"}`)),
+		"padded base64":  base64.URLEncoding.EncodeToString([]byte(`{"id":7}`)),
+		"standard alpha": "eyJpZCI6N30+/w",
+	}
+	for name, s := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if c, err := decodeCursor(s); err == nil {
+				t.Errorf("Expected an error, got %+v", c)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		cols     []keysetColumn
+		n        int
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "single column",
+			cols:     []keysetColumn{{expr: "id", value: int64(5)}},
+			n:        1,
+			wantSQL:  "((id > $1))",
+			wantArgs: []any{int64(5)},
+		},
+		{
+			name: "newest first",
+			cols: []keysetColumn{
+				{expr: "created_at", desc: true, value: "t"},
+				{expr: "id", desc: true, value: int64(5)},
+			},
+			n:        3,
+			wantSQL:  "((created_at < $3) OR (created_at = $3 AND id < $4))",
+			wantArgs: []any{"t", int64(5)},
+		},
+		{
+			name: "mixed directions",
+			cols: []keysetColumn{
+				{expr: "rank", desc: true, value: float32(0.5)},
+				{expr: "price", value: int64(100)},
+				{expr: "id", value: int64(5)},
+			},
+			n:        2,
+			wantSQL:  "((rank < $2) OR (rank = $2 AND price > $3) OR (rank = $2 AND price = $3 AND id > $4))",
+			wantArgs: []any{float32(0.5), int64(100), int64(5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := keysetCondition(tt.cols, tt.n)
+			if sql != tt.wantSQL {
+				t.Errorf("Expected %s, got %s", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestPageLimit(t *testing.T) {
+	tests := []struct{ limit, def, want int }{
+		{0, 20, 20},
+		{-1, 20, 20},
+		{10, 20, 10},
+		{maxPageSize, 20, maxPageSize},
+		{maxPageSize + 1, 20, maxPageSize},
+	}
+	for _, tt := range tests {
+		if got := pageLimit(tt.limit, tt.def); got != tt.want {
+			t.Errorf("pageLimit(%d, %d): expected %d, got %d", tt.limit, tt.def, tt.want, got)
+		}
+	}
+}
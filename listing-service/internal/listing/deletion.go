@@ -0,0 +1,167 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/blob"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+var errRestoreExpired = errors.New("listing can no longer be restored")
+
+func (s *Store) deleteRetention() time.Duration {
+	if s.DeleteRetention > 0 {
+		return s.DeleteRetention
+	}
+	return models.DefaultDeleteRetention
+}
+
+// Restore brings back a deleted listing within the retention window with
+// the status it had when it was deleted. A draft whose scheduled publish time
+// passed while it was deleted comes back unscheduled rather than going live
+// on the publisher's next tick. Admins restoring someone else's listing must
+// give a reason, which is recorded in the audit log.
+func (s *Store) Restore(ctx context.Context, id int64, userID string, userRole string, reason string) (models.Listing, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		before    models.Listing
+		deletedAt time.Time
+	)
+	err = tx.QueryRow(ctx, `SELECT `+listingColumns+`, deleted_at FROM listings WHERE id=$1 AND deleted_at IS NOT NULL FOR UPDATE`, id).
+		Scan(append(listingFields(&before), &deletedAt)...)
+	if err == pgx.ErrNoRows {
+		return models.Listing{}, fmt.Errorf("listing not found")
+	}
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to load listing: %w", err)
+	}
+	if userRole != string(httplib.ADMIN) && before.UserID.String() != userID {
+		return models.Listing{}, fmt.Errorf("listing not found")
+	}
+	override := isAdminOverride(before, userID, userRole)
+	if override && reason == "" {
+		return models.Listing{}, fmt.Errorf("reason is required")
+	}
+	if time.Since(deletedAt) > s.deleteRetention() {
+		return models.Listing{}, errRestoreExpired
+	}
+
+	var after models.Listing
+	err = tx.QueryRow(ctx, `
+		UPDATE listings SET deleted_at=NULL, deleted_by=NULL, version=version+1, updated_at=NOW(),
+			publish_at=CASE WHEN publish_at <= NOW() THEN NULL ELSE publish_at END
+		WHERE id=$1
+		RETURNING `+listingColumns, id).Scan(listingFields(&after)...)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to restore listing: %w", err)
+	}
+
+	if override {
+		err := recordAudit(ctx, tx, auditEvent{
+			ActorUserID: userID, ActorRole: userRole, Action: auditListingRestore,
+			TargetType: "listing", TargetID: id, Reason: reason, Before: before, After: after,
+		})
+		if err != nil {
+			return models.Listing{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Listing{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// GetDeletedListings returns the user's deleted listings that can still be
+// restored, most recently deleted first.
+func (s *Store) GetDeletedListings(ctx context.Context, userID string) ([]models.Listing, error) {
+	rows, err := s.P.Query(ctx, `
+		SELECT `+listingColumns+`, deleted_at
+		FROM listings
+		WHERE user_id=$1::uuid AND deleted_at > NOW() - make_interval(secs => $2)
+		ORDER BY deleted_at DESC, id DESC
+	`, userID, s.deleteRetention().Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted listings: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.Listing{}
+	for rows.Next() {
+		var l models.Listing
+		if err := rows.Scan(append(listingFields(&l), &l.DeletedAt)...); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted listing: %w", err)
+		}
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, s.attachCovers(ctx, listingRefs(out))
+}
+
+// PurgeDeleted permanently removes listings deleted longer ago than the
+// retention window, cascading to their flags, media rows and saves. It
+// returns how many were removed and the media URLs only they referenced;
+// blob names are per seller and file name, so a seller's other listings may
+// share a blob.
+func (s *Store) PurgeDeleted(ctx context.Context) (int64, []string, error) {
+	var (
+		n    int64
+		urls []string
+	)
+	err := s.P.QueryRow(ctx, `
+		WITH purged AS (
+			DELETE FROM listings WHERE deleted_at <= NOW() - make_interval(secs => $1)
+			RETURNING id
+		)
+		SELECT COUNT(DISTINCT p.id), COALESCE(array_agg(DISTINCT m.media_url) FILTER (
+			WHERE m.media_url IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM listing_media o
+				WHERE o.media_url = m.media_url AND o.listing_id NOT IN (SELECT id FROM purged))
+		), '{}')
+		FROM purged p
+		LEFT JOIN listing_media m ON m.listing_id = p.id
+	`, s.deleteRetention().Seconds()).Scan(&n, &urls)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to purge deleted listings: %w", err)
+	}
+	return n, urls, nil
+}
+
+// RunPurge purges expired deleted listings and their blobs every interval
+// until ctx is done. Blobs that fail to delete are logged and left behind.
+func (s *Store) RunPurge(ctx context.Context, interval time.Duration, blobs blob.BlobService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, urls, err := s.PurgeDeleted(ctx)
+			if err != nil {
+				log.Printf("Failed to purge deleted listings: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Purged %d deleted listings", n)
+			}
+			for _, url := range urls {
+				if err := blobs.DeleteBlob(ctx, url); err != nil {
+					log.Printf("Failed to delete blob %s: %v", url, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// Trending returns the available listings with the highest trending score,
+// optionally within one category. Views are flushed to listing_views by the
+// orchestrator, so the most recent ones may not count yet.
+func (s *Store) Trending(ctx context.Context, category *models.Category, limit int) ([]models.TrendingListing, error) {
+	since := time.Now().Add(-models.TrendingWindow)
+	args := []any{models.TrendingViewWeight, models.TrendingSaveWeight, models.TrendingChatWeight, since,
+		models.TrendingHalfLife.Seconds()}
+	q := `
+		WITH events AS (
+			SELECT listing_id, views * $1::float8 AS weight, bucket AS at FROM listing_views WHERE bucket >= $4
+			UNION ALL
+			SELECT listing_id, $2::float8, created_at FROM saved_listings WHERE created_at >= $4
+			UNION ALL
+			SELECT listing_id, $3::float8, created_at FROM listing_chats WHERE created_at >= $4
+		), scores AS (
+			SELECT listing_id, SUM(weight * power(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - at), 0) / $5::float8)) AS score
+			FROM events
+			GROUP BY listing_id
+		)
+		SELECT ` + listingColumnsAs("l") + `, s.score
+		FROM scores s
+		JOIN listings l ON l.id = s.listing_id
+		WHERE l.status = 'AVAILABLE' AND l.deleted_at IS NULL`
+	if category != nil {
+		args = append(args, *category)
+		q += fmt.Sprintf(" AND l.category = $%d", len(args))
+	}
+	q += fmt.Sprintf(" ORDER BY s.score DESC, l.id DESC LIMIT %d", pageLimit(limit, 20))
+
+	rows, err := s.P.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trending listings: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.TrendingListing{}
+	for rows.Next() {
+		var t models.TrendingListing
+		if err := rows.Scan(append(listingFields(&t.Listing), &t.Score)...); err != nil {
+			return nil, fmt.Errorf("failed to scan trending listing: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	refs := make([]*models.Listing, len(out))
+	for i := range out {
+		refs[i] = &out[i].Listing
+	}
+	return out, s.attachCovers(ctx, refs)
+}
+
+// attachViews sets the view counts of ls, for listings shown to their owner.
+func (s *Store) attachViews(ctx context.Context, ls []models.Listing) error {
+	if len(ls) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(ls))
+	for i, l := range ls {
+		ids[i] = l.ID
+	}
+	rows, err := s.P.Query(ctx, `
+		SELECT listing_id, SUM(views) FROM listing_views WHERE listing_id = ANY($1) GROUP BY listing_id
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query view counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[int64]int64{}
+	for rows.Next() {
+		var id, n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return fmt.Errorf("failed to scan view count: %w", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range ls {
+		n := counts[ls[i].ID]
+		ls[i].Views = &n
+	}
+	return nil
+}
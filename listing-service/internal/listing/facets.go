@@ -0,0 +1,107 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// Facets counts the facets requested in f over the listings matching f.
+// Paging (limit, offset, cursor) does not apply; each facet ignores its own
+// filter.
+func (s *Store) Facets(ctx context.Context, f *models.ListFilters) (*models.Facets, error) {
+	search := searchText(f)
+	out := &models.Facets{}
+	for _, name := range f.Facets {
+		var err error
+		switch name {
+		case models.FacetCategory:
+			out.Category, err = s.valueFacet(ctx, f, search, "category")
+		case models.FacetStatus:
+			out.Status, err = s.valueFacet(ctx, f, search, "status")
+		case models.FacetPrice:
+			out.Price, err = s.priceFacet(ctx, f, search)
+		default:
+			err = fmt.Errorf("unknown facet %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// valueFacet counts the matching listings per distinct value of column,
+// which is also the name of the facet it backs.
+func (s *Store) valueFacet(ctx context.Context, f *models.ListFilters, search string, column string) ([]models.FacetCount, error) {
+	where, args := filterClauses(f, search, column)
+	q := fmt.Sprintf("SELECT %s::text, COUNT(*) FROM listings", column)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += fmt.Sprintf(" GROUP BY %s ORDER BY COUNT(*) DESC, %s", column, column)
+
+	rows, err := s.P.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count %s facet: %w", column, err)
+	}
+	defer rows.Close()
+
+	out := []models.FacetCount{}
+	for rows.Next() {
+		var fc models.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, fc)
+	}
+	return out, rows.Err()
+}
+
+// priceFacet counts the matching listings per price bucket. Every bucket is
+// returned, including empty ones, so clients can render a stable range list.
+func (s *Store) priceFacet(ctx context.Context, f *models.ListFilters, search string) ([]models.PriceBucket, error) {
+	bounds := f.PriceBuckets
+	if len(bounds) == 0 {
+		bounds = models.DefaultPriceBuckets
+	}
+	if bounds[0] > 0 {
+		bounds = append([]int64{0}, bounds...)
+	}
+
+	where, args := filterClauses(f, search, models.FacetPrice)
+	// width_bucket returns i for bounds[i-1] <= price < bounds[i]
+	q := fmt.Sprintf("SELECT width_bucket(price, $%d::bigint[]) AS bucket, COUNT(*) FROM listings", len(args)+1)
+	args = append(args, bounds)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " GROUP BY bucket"
+
+	rows, err := s.P.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count price facet: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]models.PriceBucket, len(bounds))
+	for i := range bounds {
+		out[i].Min = bounds[i]
+		if i+1 < len(bounds) {
+			max := bounds[i+1]
+			out[i].Max = &max
+		}
+	}
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket >= 1 && bucket <= len(out) {
+			out[bucket-1].Count = count
+		}
+	}
+	return out, rows.Err()
+}
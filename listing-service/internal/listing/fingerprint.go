@@ -0,0 +1,183 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// maxDuplicateDistance is how many of the 64 fingerprint bits two
+	// listings may differ in and still count as near-duplicates. It must
+	// stay below fingerprintBands for the band lookup to find them.
+	maxDuplicateDistance = 6
+	fingerprintBands     = 8
+	// minScamTokens is how many description words a listing needs before a
+	// text match with another seller's listing is flagged; short listings of
+	// the same textbook or gadget legitimately look alike.
+	minScamTokens = 10
+)
+
+// duplicateError is returned when a seller's listing nearly repeats another
+// of their listings, reported to the client as a 409.
+type duplicateError struct {
+	ListingID int64
+}
+
+func (e *duplicateError) Error() string {
+	return fmt.Sprintf("this repeats your listing %d; edit or renew that listing instead", e.ListingID)
+}
+
+// fingerprintTokens lowercases s and splits it into words of at least two
+// letters or digits.
+func fingerprintTokens(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if len(w) >= 2 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// listingFingerprint computes the SimHash of a listing from the words and
+// word pairs of its title and description and from its media URLs, with
+// the title and media weighing more. It also returns the fingerprint's
+// bands and the number of description words.
+func listingFingerprint(title, description string, media []string) (int64, []int32, int) {
+	features := map[string]int{}
+	addText := func(words []string, weight int) {
+		for i, w := range words {
+			features[w] += weight
+			if i > 0 {
+				features[words[i-1]+" "+w] += weight
+			}
+		}
+	}
+	addText(fingerprintTokens(title), 2)
+	desc := fingerprintTokens(description)
+	addText(desc, 1)
+	for _, url := range media {
+		features["media:"+url] += 3
+	}
+
+	var sums [64]int
+	for f, weight := range features {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for b := range sums {
+			if sum>>b&1 == 1 {
+				sums[b] += weight
+			} else {
+				sums[b] -= weight
+			}
+		}
+	}
+	var fp uint64
+	for b, v := range sums {
+		if v > 0 {
+			fp |= 1 << b
+		}
+	}
+
+	bands := make([]int32, fingerprintBands)
+	for i := range bands {
+		bands[i] = int32(i<<8) | int32(fp>>(8*i)&0xFF)
+	}
+	return int64(fp), bands, len(desc)
+}
+
+// fingerprintListing stores the fingerprints of listing id from its current
+// title, description and media. With rejectOwn set, a near-duplicate among
+// the seller's other listings fails with a *duplicateError. A published
+// listing whose text nearly repeats another seller's is flagged for
+// moderators as a possible scam, once while the flag is open. Media URLs
+// name the uploader, so they only count among a seller's own listings.
+func fingerprintListing(ctx context.Context, tx pgx.Tx, id int64, rejectOwn bool) error {
+	var (
+		title       string
+		description *string
+		userID      string
+		draft       bool
+	)
+	err := tx.QueryRow(ctx, `SELECT title, description, user_id::text, status = 'DRAFT' FROM listings WHERE id=$1`, id).
+		Scan(&title, &description, &userID, &draft)
+	if err != nil {
+		return fmt.Errorf("failed to load listing for fingerprint: %w", err)
+	}
+	rows, err := tx.Query(ctx, `SELECT media_url FROM listing_media WHERE listing_id=$1 ORDER BY id`, id)
+	if err != nil {
+		return fmt.Errorf("failed to load listing media for fingerprint: %w", err)
+	}
+	media, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to load listing media for fingerprint: %w", err)
+	}
+	desc := ""
+	if description != nil {
+		desc = *description
+	}
+
+	fp, bands, descWords := listingFingerprint(title, desc, media)
+	textFP, textBands, _ := listingFingerprint(title, desc, nil)
+	_, err = tx.Exec(ctx, `
+		UPDATE listings SET fingerprint=$1, fingerprint_bands=$2, text_fingerprint=$3, text_fingerprint_bands=$4
+		WHERE id=$5
+	`, fp, bands, textFP, textBands, id)
+	if err != nil {
+		return fmt.Errorf("failed to save listing fingerprint: %w", err)
+	}
+
+	const near = `fingerprint_bands && $2 AND bit_count((fingerprint # $3)::bit(64)) <= $4`
+	if rejectOwn {
+		var dup int64
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM listings
+			WHERE id <> $1 AND user_id = $5::uuid AND status <> 'SOLD' AND deleted_at IS NULL AND `+near+`
+			ORDER BY created_at DESC LIMIT 1
+		`, id, bands, fp, maxDuplicateDistance, userID).Scan(&dup)
+		if err == nil {
+			return &duplicateError{ListingID: dup}
+		}
+		if err != pgx.ErrNoRows {
+			return fmt.Errorf("failed to check for duplicate listings: %w", err)
+		}
+	}
+
+	if draft || descWords < minScamTokens {
+		return nil
+	}
+	var original int64
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM listings
+		WHERE id <> $1 AND user_id <> $5::uuid AND status <> 'DRAFT' AND deleted_at IS NULL
+		  AND text_fingerprint_bands && $2 AND bit_count((text_fingerprint # $3)::bit(64)) <= $4
+		ORDER BY created_at LIMIT 1
+	`, id, textBands, textFP, maxDuplicateDistance, userID).Scan(&original)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check for copied listings: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO flagged_listings (listing_id, reason, details)
+		SELECT $1, 'SCAM', $2
+		WHERE NOT EXISTS (
+			SELECT 1 FROM flagged_listings
+			WHERE listing_id = $1 AND reporter_user_id IS NULL AND status IN ('OPEN', 'UNDER_REVIEW')
+		)
+	`, id, fmt.Sprintf("Automatic check: nearly repeats listing %d of another seller", original))
+	if err != nil {
+		return fmt.Errorf("failed to flag copied listing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package listing
+
+import (
+	"math/bits"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+const fingerprintDescription = "Barely used hardcover copy of the third edition, no highlighting or " +
+	"notes inside, cover has a small scuff on the back corner. Pick up on campus " +
+	"near the library any weekday afternoon, cash or transfer works for me."
+
+func fingerprintDistance(a, b int64) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+func sharesBand(a, b []int32) bool {
+	return slices.ContainsFunc(a, func(band int32) bool { return slices.Contains(b, band) })
+}
+
+func TestFingerprintTokens(t *testing.T) {
+	got := fingerprintTokens("MacBook Pro 14\" (M3, 16GB) - a steal!")
+	want := []string{"macbook", "pro", "14", "m3", "16gb", "steal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestListingFingerprint(t *testing.T) {
+	title := "Introduction to Algorithms 3rd edition"
+	fp, bands, words := listingFingerprint(title, fingerprintDescription, nil)
+
+	t.Run("Deterministic", func(t *testing.T) {
+		again, againBands, _ := listingFingerprint(title, fingerprintDescription, nil)
+		if again != fp || !reflect.DeepEqual(againBands, bands) {
+			t.Errorf("Expected the same fingerprint, got %x and %x", fp, again)
+		}
+	})
+
+	t.Run("CountsDescriptionWords", func(t *testing.T) {
+		if want := len(fingerprintTokens(fingerprintDescription)); words != want {
+			t.Errorf("Expected %d description words, got %d", want, words)
+		}
+	})
+
+	t.Run("BandsSplitTheFingerprint", func(t *testing.T) {
+		if len(bands) != fingerprintBands {
+			t.Fatalf("Expected %d bands, got %d", fingerprintBands, len(bands))
+		}
+		var rebuilt uint64
+		for i, band := range bands {
+			if int(band>>8) != i {
+				t.Errorf("Expected band %d to be tagged with its index, got %d", i, band>>8)
+			}
+			rebuilt |= uint64(band&0xFF) << (8 * i)
+		}
+		if int64(rebuilt) != fp {
+			t.Errorf("Expected bands to rebuild %x, got %x", fp, rebuilt)
+		}
+	})
+
+	t.Run("IgnoresCaseAndPunctuation", func(t *testing.T) {
+		other, _, _ := listingFingerprint("INTRODUCTION TO ALGORITHMS, 3rd Edition!", fingerprintDescription, nil)
+		if other != fp {
+			t.Errorf("Expected %x, got %x", fp, other)
+		}
+	})
+
+	t.Run("SmallEditIsNearDuplicate", func(t *testing.T) {
+		edited := fingerprintDescription + " Price is firm."
+		other, otherBands, _ := listingFingerprint(title, edited, nil)
+		if d := fingerprintDistance(fp, other); d > maxDuplicateDistance {
+			t.Errorf("Expected distance at most %d, got %d", maxDuplicateDistance, d)
+		}
+		if !sharesBand(bands, otherBands) {
+			t.Error("Expected the near-duplicate to share a band")
+		}
+	})
+
+	t.Run("DifferentListingIsNotNearDuplicate", func(t *testing.T) {
+		other, _, _ := listingFingerprint("Mini fridge for dorm room",
+			"Compact fridge with a small freezer shelf, works perfectly and keeps drinks cold. "+
+				"Moving out at the end of the semester so it has to go this week.", nil)
+		if d := fingerprintDistance(fp, other); d <= maxDuplicateDistance {
+			t.Errorf("Expected distance above %d, got %d", maxDuplicateDistance, d)
+		}
+	})
+
+	t.Run("MediaChangesTheFingerprint", func(t *testing.T) {
+		other, _, _ := listingFingerprint(title, fingerprintDescription, []string{"https://blob.example/a.jpg"})
+		if other == fp {
+			t.Error("Expected media to contribute to the fingerprint")
+		}
+	})
+}
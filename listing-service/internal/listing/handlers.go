@@ -2,16 +2,22 @@ package listing
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	httplib "github.com/kunal768/cmpe202/http-lib"
 	"github.com/kunal768/cmpe202/listing-service/internal/blob"
+	"github.com/kunal768/cmpe202/listing-service/internal/books"
 	"github.com/kunal768/cmpe202/listing-service/internal/common"
 	"github.com/kunal768/cmpe202/listing-service/internal/gemini"
 	"github.com/kunal768/cmpe202/listing-service/internal/models"
@@ -22,6 +28,8 @@ type Handlers struct {
 	AI      *gemini.Client
 	S       *Store
 	BlobSvc blob.BlobService
+	// Books looks up textbook metadata by ISBN; nil only serves cached books
+	Books books.BookMetadataProvider
 }
 
 func (h *Handlers) CreateHandler(w http.ResponseWriter, r *http.Request) {
@@ -35,38 +43,315 @@ func (h *Handlers) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		platform.Error(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	if p.Title == "" || p.Price <= 0 || p.Category == "" {
-		platform.Error(w, http.StatusBadRequest, "title, price, category required")
+	if fields := requiredFields(p); len(fields) > 0 {
+		platform.ValidationError(w, "title, price, category required", fields...)
+		return
+	}
+	if fe := normalizeDetails(&p); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
 		return
 	}
 
 	l, err := h.S.Create(r.Context(), userID, p)
 	if err != nil {
+		var dup *duplicateError
+		if errors.As(err, &dup) {
+			platform.Error(w, http.StatusConflict, dup.Error())
+			return
+		}
 		platform.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	h.rememberBook(l.Attributes)
 	platform.JSON(w, http.StatusCreated, l)
 }
 
+// ImportListingsHandler creates many listings from a JSON array or, with
+// Content-Type text/csv, from CSV rows. Every row is validated before any is
+// created, and all are created in one transaction; dry_run=true only returns
+// the validation report.
+func (h *Handlers) ImportListingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var ps []models.CreateParams
+	var decodeErrs []models.ImportRowError
+	switch mediaType {
+	case "text/csv":
+		ps, decodeErrs, err = decodeImportCSV(body)
+	case "", "application/json":
+		ps, err = decodeImportJSON(body)
+	default:
+		platform.Error(w, http.StatusUnsupportedMediaType, "import must be application/json or text/csv")
+		return
+	}
+	if err != nil {
+		platform.ValidationError(w, err.Error(), httplib.FieldError{Field: "body", Code: "invalid", Message: err.Error()})
+		return
+	}
+	if len(ps) == 0 || len(ps) > models.MaxImportRows {
+		msg := fmt.Sprintf("an import takes 1 to %d listings", models.MaxImportRows)
+		platform.ValidationError(w, msg, httplib.FieldError{Field: "body", Code: "out_of_range", Message: msg})
+		return
+	}
+
+	report := models.ImportReport{DryRun: dryRun, Rows: len(ps), Errors: validateImport(ps, decodeErrs), Listings: []models.Listing{}}
+	invalid := map[int]bool{}
+	for _, e := range report.Errors {
+		invalid[e.Row] = true
+	}
+	report.Valid = len(ps) - len(invalid)
+	if report.Errors == nil {
+		report.Errors = []models.ImportRowError{}
+	}
+	if dryRun {
+		platform.JSON(w, http.StatusOK, report)
+		return
+	}
+	if len(invalid) > 0 {
+		fields := make([]httplib.FieldError, len(report.Errors))
+		for i, e := range report.Errors {
+			fields[i] = httplib.FieldError{Field: fmt.Sprintf("rows[%d].%s", e.Row, e.Field), Code: e.Code, Message: e.Message}
+		}
+		platform.ValidationError(w, fmt.Sprintf("%d of %d rows are invalid; nothing was imported", len(invalid), len(ps)), fields...)
+		return
+	}
+
+	if report.Listings, err = h.S.CreateMany(r.Context(), userID, ps); err != nil {
+		if errors.As(err, new(*duplicateError)) {
+			platform.Error(w, http.StatusConflict, err.Error()+"; nothing was imported")
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var isbns []string
+	for _, l := range report.Listings {
+		if isbn := l.Attributes[models.AttrISBN]; isbn != "" {
+			isbns = append(isbns, isbn)
+		}
+	}
+	h.rememberBooks(isbns)
+	platform.JSON(w, http.StatusCreated, report)
+}
+
+// ExportListingsHandler returns all of the caller's listings as JSON or, with
+// format=csv, as CSV, in the layout ImportListingsHandler accepts.
+func (h *Handlers) ExportListingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.FormatJSON
+	}
+	if format != models.FormatJSON && format != models.FormatCSV {
+		platform.ValidationError(w, "format must be json or csv",
+			httplib.FieldError{Field: "format", Code: "invalid", Message: "format must be json or csv"})
+		return
+	}
+
+	ls, err := h.S.ExportListings(r.Context(), userID)
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="listings.%s"`, format))
+	if format == models.FormatJSON {
+		platform.JSON(w, http.StatusOK, exportParams(ls))
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	if err := encodeExportCSV(w, ls); err != nil {
+		log.Printf("failed to write listing export: %v", err)
+	}
+}
+
 func (h *Handlers) GetHandler(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	l, err := h.S.Get(r.Context(), id)
-	if err != nil {
+	// Drafts are only visible to their owner
+	if err != nil || (l.Status == models.StDraft && r.Header.Get("X-User-ID") != l.UserID.String()) {
 		platform.Error(w, http.StatusNotFound, "not found")
 		return
 	}
+	etag := httplib.VersionETag(l.Version)
+	// The owner's copy carries the view count, which changes without a
+	// version bump, so it is never answered with 304
+	owner := r.Header.Get("X-User-ID") == l.UserID.String()
+	if !owner && httplib.NotModified(r, etag) {
+		httplib.WriteNotModified(w, etag)
+		return
+	}
+	// Price changes bump the version, so the ETag also covers the history
+	if l.PriceHistory, err = h.S.GetPriceHistory(r.Context(), id); err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if owner {
+		ls := []models.Listing{l}
+		if err := h.S.attachViews(r.Context(), ls); err != nil {
+			platform.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		l = ls[0]
+	}
+	w.Header().Set("ETag", etag)
 	platform.JSON(w, http.StatusOK, l)
 }
 
+// requiredFields reports the missing required fields of a new listing.
+func requiredFields(p models.CreateParams) []httplib.FieldError {
+	var fields []httplib.FieldError
+	if p.Title == "" {
+		fields = append(fields, httplib.FieldError{Field: "title", Code: "required", Message: "title is required"})
+	}
+	if p.Price <= 0 {
+		fields = append(fields, httplib.FieldError{Field: "price", Code: "out_of_range", Message: "price must be greater than 0"})
+	}
+	if p.Category == "" {
+		fields = append(fields, httplib.FieldError{Field: "category", Code: "required", Message: "category is required"})
+	}
+	return fields
+}
+
+// normalizeDetails validates and canonicalises the condition, pickup
+// location, tags and attributes of a new listing and checks its publish time.
+func normalizeDetails(p *models.CreateParams) *httplib.FieldError {
+	if fe := validateCondition(p.Condition); fe != nil {
+		return fe
+	}
+	if fe := validateSchedule(p.PublishAt); fe != nil {
+		return fe
+	}
+	var fe *httplib.FieldError
+	if p.PickupLocation, fe = normalizePickup(p.PickupLocation); fe != nil {
+		return fe
+	}
+	if p.Tags, fe = normalizeTags(p.Tags); fe != nil {
+		return fe
+	}
+	if p.Attributes, fe = normalizeAttributes(p.Category, p.Attributes); fe != nil {
+		return fe
+	}
+	return nil
+}
+
+// reasonRequired rejects a destructive admin action sent without a reason.
+func reasonRequired(w http.ResponseWriter) {
+	platform.ValidationError(w, "a reason is required for this action",
+		httplib.FieldError{Field: "reason", Code: "required", Message: "reason is required"})
+}
+
+// pageParams reads the limit and cursor query parameters of a keyset page.
+func pageParams(r *http.Request) models.PageParams {
+	q := r.URL.Query()
+	return models.PageParams{Limit: common.ParseInt(q.Get("limit"), 0), Cursor: q.Get("cursor")}
+}
+
+// writePage writes items with the next cursor in X-Next-Cursor, keeping the
+// body a plain array for existing clients.
+func writePage(w http.ResponseWriter, items any, next string) {
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+	platform.JSON(w, http.StatusOK, items)
+}
+
+func invalidCursor(w http.ResponseWriter) {
+	platform.ValidationError(w, "cursor is invalid",
+		httplib.FieldError{Field: "cursor", Code: "invalid", Message: "cursor is malformed or was issued for a different sort"})
+}
+
+// validateFacets checks requested facet names and price bucket bounds,
+// returning the offending field if any.
+func validateFacets(names []string, buckets []int64) *httplib.FieldError {
+	for _, name := range names {
+		if !slices.Contains(models.AllFacets, name) {
+			return &httplib.FieldError{Field: "facets", Code: "invalid",
+				Message: fmt.Sprintf("unknown facet %q; expected one of %s", name, strings.Join(models.AllFacets, ", "))}
+		}
+	}
+	for i, b := range buckets {
+		if b < 0 || (i > 0 && b <= buckets[i-1]) {
+			return &httplib.FieldError{Field: "price_buckets", Code: "invalid",
+				Message: "price_buckets must be non-negative and strictly ascending"}
+		}
+	}
+	return nil
+}
+
+// facetParams reads the comma separated facets and price_buckets query
+// parameters, writing a 400 when either is invalid.
+func facetParams(w http.ResponseWriter, r *http.Request) ([]string, []int64, bool) {
+	q := r.URL.Query()
+	var names []string
+	for _, name := range strings.Split(q.Get("facets"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	var buckets []int64
+	for _, b := range strings.Split(q.Get("price_buckets"), ",") {
+		if b = strings.TrimSpace(b); b == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			platform.ValidationError(w, "price_buckets is invalid",
+				httplib.FieldError{Field: "price_buckets", Code: "invalid", Message: "price_buckets must be a comma separated list of cents"})
+			return nil, nil, false
+		}
+		buckets = append(buckets, v)
+	}
+	if fe := validateFacets(names, buckets); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
+		return nil, nil, false
+	}
+	return names, buckets, true
+}
+
+// ifMatchVersion reads the expected listing version from If-Match, writing a
+// 400 when the header is malformed.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	v, err := httplib.IfMatchVersion(r)
+	if err != nil {
+		platform.ValidationError(w, err.Error(), httplib.FieldError{Field: "If-Match", Code: "invalid", Message: err.Error()})
+		return nil, false
+	}
+	return v, true
+}
+
 func (h *Handlers) ListHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	f := models.ListFilters{
 		Limit:  common.ParseInt(q.Get("limit"), 20),
 		Offset: common.ParseInt(q.Get("offset"), 0),
+		Cursor: q.Get("cursor"),
 		Sort:   q.Get("sort"),
 	}
-	if s := q.Get("keywords"); s != "" {
-		f.Keywords = strings.Fields(s)
+	// Counting is on for the first page unless turned off, and off for the
+	// following pages unless asked for
+	switch q.Get("include_count") {
+	case "true":
+		f.WithCount = true
+	case "false":
+	default:
+		f.WithCount = f.Cursor == ""
+	}
+	f.Query = q.Get("keywords")
+	// A search for an ISBN finds every edition of that book
+	if isbn, err := books.NormalizeISBN(f.Query); err == nil {
+		f.Query = ""
+		f.Attributes = models.Attributes{models.AttrISBN: isbn}
 	}
 	if s := q.Get("category"); s != "" {
 		c := models.Category(s)
@@ -84,13 +369,59 @@ func (h *Handlers) ListHandler(w http.ResponseWriter, r *http.Request) {
 		v := common.ParseInt64(s, 0)
 		f.MaxPrice = &v
 	}
+	if s := q.Get("condition"); s != "" {
+		c := models.Condition(s)
+		f.Condition = &c
+	}
+	if s := q.Get("pickup_location"); s != "" {
+		f.Pickup = &s
+	}
+	if s := q.Get("tags"); s != "" {
+		f.Tags = strings.Split(s, ",")
+	}
+	if s := q.Get("course"); s != "" {
+		code, err := normalizeAttributeValue(models.AttrCourseCode, s)
+		if err != nil {
+			platform.ValidationError(w, err.Error(), httplib.FieldError{Field: "course", Code: "invalid", Message: err.Error()})
+			return
+		}
+		f.Course = &code
+	}
+	// Attribute filters are attr.<key>=<value>, e.g. attr.course_code=CMPE202
+	for key, values := range q {
+		if name, ok := strings.CutPrefix(key, "attr."); ok && len(values) > 0 {
+			if f.Attributes == nil {
+				f.Attributes = models.Attributes{}
+			}
+			f.Attributes[name] = values[0]
+		}
+	}
+	if fe := normalizeFilters(&f, false); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
+		return
+	}
+
+	var ok bool
+	if f.Facets, f.PriceBuckets, ok = facetParams(w, r); !ok {
+		return
+	}
 
-	items, totalCount, err := h.S.List(r.Context(), &f)
+	page, err := h.S.List(r.Context(), &f)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
 		platform.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	platform.JSON(w, http.StatusOK, map[string]any{"items": items, "count": totalCount})
+	if len(f.Facets) > 0 {
+		if page.Facets, err = h.S.Facets(r.Context(), &f); err != nil {
+			platform.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	platform.JSON(w, http.StatusOK, page)
 }
 
 func (h *Handlers) UpdateHandler(w http.ResponseWriter, r *http.Request) {
@@ -101,19 +432,102 @@ func (h *Handlers) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 	var p models.UpdateParams
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
 		platform.Error(w, http.StatusBadRequest, "invalid json")
 		return
 	}
+	if fe := validateCondition(p.Condition); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
+		return
+	}
+	if fe := validateSchedule(p.PublishAt); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
+		return
+	}
+	if p.PickupLocation != nil {
+		if _, fe := normalizePickup(p.PickupLocation); fe != nil {
+			platform.ValidationError(w, fe.Message, *fe)
+			return
+		}
+	}
+	if p.Tags != nil {
+		tags, fe := normalizeTags(*p.Tags)
+		if fe != nil {
+			platform.ValidationError(w, fe.Message, *fe)
+			return
+		}
+		p.Tags = &tags
+	}
 
 	log.Println("SQL update try from updatehandler")
-	l, err := h.S.Update(r.Context(), id, userID, userRole, p)
+	l, err := h.S.Update(r.Context(), id, userID, userRole, ifMatch, r.URL.Query().Get("reason"), p)
 	if err != nil {
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
+		if err.Error() == "listing not found" {
+			platform.Error(w, http.StatusNotFound, "listing not found")
+			return
+		}
+		if errors.Is(err, errInvalidTransition) {
+			platform.Error(w, http.StatusConflict, err.Error())
+			return
+		}
+		var dup *duplicateError
+		if errors.As(err, &dup) {
+			platform.Error(w, http.StatusConflict, dup.Error())
+			return
+		}
+		var fe *fieldError
+		if errors.As(err, &fe) {
+			platform.ValidationError(w, fe.Message, fe.FieldError)
+			return
+		}
 		platform.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if p.Attributes != nil {
+		h.rememberBook(l.Attributes)
+	}
 	log.Println("SQL update passed from updatehandler")
+	w.Header().Set("ETag", httplib.VersionETag(l.Version))
+	platform.JSON(w, http.StatusOK, l)
+}
+
+// RenewHandler extends a listing's expiry, relisting it if it was archived.
+func (h *Handlers) RenewHandler(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	if err != nil {
+		return
+	}
+
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	l, err := h.S.Renew(r.Context(), id, userID, userRole, ifMatch)
+	if err != nil {
+		switch {
+		case err.Error() == "listing version mismatch":
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+		case err.Error() == "listing not found":
+			platform.Error(w, http.StatusNotFound, "listing not found")
+		case errors.Is(err, errInvalidTransition):
+			platform.Error(w, http.StatusConflict, err.Error())
+		default:
+			platform.Error(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	w.Header().Set("ETag", httplib.VersionETag(l.Version))
 	platform.JSON(w, http.StatusOK, l)
 }
 
@@ -125,19 +539,91 @@ func (h *Handlers) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	reason := r.URL.Query().Get("reason")
 	if r.URL.Query().Get("hard") == "true" {
-		if err := h.S.Delete(r.Context(), id, userID, userRole); err != nil {
-			platform.Error(w, 500, err.Error())
+		err = h.S.Delete(r.Context(), id, userID, userRole, reason)
+	} else {
+		err = h.S.Archive(r.Context(), id, userID, userRole, reason)
+	}
+	if err != nil {
+		switch {
+		case err.Error() == "listing not found":
+			platform.Error(w, http.StatusNotFound, err.Error())
+		case err.Error() == "reason is required":
+			reasonRequired(w)
+		case errors.Is(err, errInvalidTransition):
+			platform.Error(w, http.StatusConflict, err.Error())
+		default:
 			log.Println("Error on delete handler")
-			return
+			platform.Error(w, http.StatusInternalServerError, err.Error())
 		}
-	} else {
-		if err := h.S.Archive(r.Context(), id, userID, userRole); err != nil {
-			platform.Error(w, 500, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// RestoreHandler brings back a deleted listing while it is within the
+// retention window.
+func (h *Handlers) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	if err != nil {
+		return
+	}
+
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	l, err := h.S.Restore(r.Context(), id, userID, userRole, r.URL.Query().Get("reason"))
+	if err != nil {
+		switch {
+		case err.Error() == "listing not found":
+			platform.Error(w, http.StatusNotFound, err.Error())
+		case err.Error() == "reason is required":
+			reasonRequired(w)
+		case errors.Is(err, errRestoreExpired):
+			platform.Error(w, http.StatusGone, err.Error())
+		default:
+			platform.Error(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	w.Header().Set("ETag", httplib.VersionETag(l.Version))
+	platform.JSON(w, http.StatusOK, l)
+}
+
+// HistoryHandler returns the edit history of a listing to its owner or an
+// admin.
+func (h *Handlers) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	if err != nil {
+		return
+	}
+
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	revisions, err := h.S.GetHistory(r.Context(), id, userID, userRole)
+	if err != nil {
+		if err.Error() == "listing not found" {
+			platform.Error(w, http.StatusNotFound, err.Error())
 			return
 		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-	platform.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	platform.JSON(w, http.StatusOK, revisions)
+}
+
+// DeletedListingsHandler lists the user's deleted listings that can still
+// be restored.
+func (h *Handlers) DeletedListingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	ls, err := h.S.GetDeletedListings(r.Context(), userID)
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, ls)
 }
 
 func (h *Handlers) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
@@ -147,14 +633,20 @@ func (h *Handlers) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
 			Role    string `json:"role"`
 			Content string `json:"content"`
 		} `json:"conversation_history,omitempty"`
+		Facets       []string `json:"facets,omitempty"`
+		PriceBuckets []int64  `json:"price_buckets,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		platform.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	if req.Query == "" {
-		http.Error(w, "Query cannot be empty", http.StatusBadRequest)
+		platform.Error(w, http.StatusBadRequest, "Query cannot be empty")
+		return
+	}
+	if fe := validateFacets(req.Facets, req.PriceBuckets); fe != nil {
+		platform.ValidationError(w, fe.Message, *fe)
 		return
 	}
 
@@ -172,7 +664,7 @@ func (h *Handlers) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
 	searchParams, err := h.AI.GetSearchParams(r.Context(), req.Query, conversationHistory)
 	if err != nil {
 		log.Printf("ERROR getting search params from AI: %v", err)
-		http.Error(w, "Failed to understand query", http.StatusInternalServerError)
+		platform.Error(w, http.StatusInternalServerError, "Failed to understand query")
 		return
 	}
 
@@ -183,19 +675,34 @@ func (h *Handlers) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
 	if searchParams.MaxPrice != nil {
 		*searchParams.MaxPrice = *searchParams.MaxPrice * 100
 	}
+	// The model may invent attributes or values; drop what could never match
+	normalizeFilters(searchParams, true)
+	if err := h.resolveCourse(r.Context(), searchParams); err != nil {
+		log.Printf("ERROR resolving course: %v", err)
+		platform.Error(w, http.StatusInternalServerError, "Failed to retrieve listings")
+		return
+	}
 
-	listings, _, err := h.S.List(r.Context(), searchParams)
+	page, err := h.S.List(r.Context(), searchParams)
 	if err != nil {
 		log.Printf("ERROR finding listings in database: %v", err)
-		http.Error(w, "Failed to retrieve listings", http.StatusInternalServerError)
+		platform.Error(w, http.StatusInternalServerError, "Failed to retrieve listings")
 		return
 	}
 
-	log.Printf("Found %d listings for query '%s'", len(listings), req.Query)
+	log.Printf("Found %d listings for query '%s'", len(page.Items), req.Query)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(listings)
+	if len(req.Facets) > 0 {
+		searchParams.Facets, searchParams.PriceBuckets = req.Facets, req.PriceBuckets
+		if page.Facets, err = h.S.Facets(r.Context(), searchParams); err != nil {
+			log.Printf("ERROR counting facets: %v", err)
+			platform.Error(w, http.StatusInternalServerError, "Failed to retrieve listings")
+			return
+		}
+	}
+
+	// The filters the AI derived are returned so the search can be saved and re-run
+	platform.JSON(w, http.StatusOK, map[string]any{"items": page.Items, "filters": searchParams, "facets": page.Facets})
 }
 
 func (h *Handlers) GetUserListsHandler(w http.ResponseWriter, r *http.Request) {
@@ -204,14 +711,22 @@ func (h *Handlers) GetUserListsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	l, err := h.S.GetUserLists(r.Context(), userID)
+	l, next, err := h.S.GetUserLists(r.Context(), userID, pageParams(r))
 
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
 		platform.Error(w, http.StatusNotFound, "not found")
 		return
 	}
+	if err := h.S.attachViews(r.Context(), l); err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	platform.JSON(w, http.StatusOK, l)
+	writePage(w, l, next)
 }
 
 func (h *Handlers) GetListingsByUserIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -235,13 +750,17 @@ func (h *Handlers) GetListingsByUserIDHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	// Fetch listings by user ID
-	l, err := h.S.GetListingsByUserID(r.Context(), targetUserID)
+	l, next, err := h.S.GetListingsByUserID(r.Context(), targetUserID, pageParams(r))
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
 		platform.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	platform.JSON(w, http.StatusOK, l)
+	writePage(w, l, next)
 }
 
 func (h *Handlers) AddMediaURLHandler(w http.ResponseWriter, r *http.Request) {
@@ -258,6 +777,11 @@ func (h *Handlers) AddMediaURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	// Decode JSON body
 	var p models.AddMediaParams
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
@@ -265,16 +789,29 @@ func (h *Handlers) AddMediaURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate array is not empty
-	if len(p.MediaUrls) == 0 {
-		platform.Error(w, http.StatusBadRequest, "media_urls array cannot be empty")
+	// Plain URLs are accepted alongside media with details
+	media := p.Media
+	for _, u := range p.MediaUrls {
+		media = append(media, models.MediaInput{URL: u})
+	}
+	if len(media) == 0 {
+		platform.Error(w, http.StatusBadRequest, "media_urls or media is required")
 		return
 	}
 
 	// Call repository method to add media URLs
-	err = h.S.AddMediaUrls(r.Context(), id, userID, p.MediaUrls)
+	version, err := h.S.AddMediaUrls(r.Context(), id, userID, ifMatch, media)
 	if err != nil {
+		var fe *fieldError
+		if errors.As(err, &fe) {
+			platform.ValidationError(w, fe.Message, fe.FieldError)
+			return
+		}
 		// Check error type to return appropriate status code
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
 		if err.Error() == "listing not found" {
 			platform.Error(w, http.StatusNotFound, "listing not found")
 			return
@@ -291,9 +828,11 @@ func (h *Handlers) AddMediaURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(version))
 	platform.JSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Media URLs added successfully",
-		"count":   len(p.MediaUrls),
+		"count":   len(media),
+		"version": version,
 	})
 }
 
@@ -313,22 +852,22 @@ func (h *Handlers) UploadUserMedia(w http.ResponseWriter, r *http.Request) {
 	err = r.ParseMultipartForm(models.MaxFileUploadSize)
 	if err != nil {
 		if err.Error() == "http: request body too large" {
-			http.Error(w, "Request body exceeds total size limit.", http.StatusRequestEntityTooLarge)
+			platform.Error(w, http.StatusRequestEntityTooLarge, "Request body exceeds total size limit.")
 			return
 		}
-		http.Error(w, fmt.Sprintf("Error parsing form: %v", err), http.StatusBadRequest)
+		platform.Error(w, http.StatusBadRequest, fmt.Sprintf("Error parsing form: %v", err))
 		return
 	}
 
 	// 3. Get the map of all files uploaded under the name "media"
 	files := r.MultipartForm.File["media"]
 	if len(files) == 0 {
-		http.Error(w, "No files uploaded under the key 'media'", http.StatusBadRequest)
+		platform.Error(w, http.StatusBadRequest, "No files uploaded under the key 'media'")
 		return
 	}
 
 	if len(files) > models.MaxFilesToProcess {
-		http.Error(w, fmt.Sprintf("Too many files uploaded. Max allowed: %d.", models.MaxFilesToProcess), http.StatusBadRequest)
+		platform.Error(w, http.StatusBadRequest, fmt.Sprintf("Too many files uploaded. Max allowed: %d.", models.MaxFilesToProcess))
 		return
 	}
 
@@ -359,7 +898,7 @@ func (h *Handlers) UploadUserMedia(w http.ResponseWriter, r *http.Request) {
 		sasResponse, err := h.BlobSvc.GenerateUploadSAS(ctx, uniqueBlobName)
 		if err != nil {
 			fmt.Printf("Error generating SAS for %s: %v\n", fileHeader.Filename, err)
-			http.Error(w, "Error generating SAS link.", http.StatusInternalServerError)
+			platform.Error(w, http.StatusInternalServerError, "Error generating SAS link.")
 			return
 		}
 
@@ -526,11 +1065,11 @@ func (h *Handlers) UpdateFlagListingHandler(w http.ResponseWriter, r *http.Reque
 	log.Println("Got update request model")
 
 	// Create the flag
-	flaggedListing, err := h.S.UpdateFlagListing(r.Context(), flagID, userID, req)
+	flaggedListing, err := h.S.UpdateFlagListing(r.Context(), flagID, userID, userRole, r.URL.Query().Get("reason"), req)
 	if err != nil {
 		log.Printf("Error flagging listing: %v", err)
-		if err.Error() == "listing not found" {
-			platform.Error(w, http.StatusNotFound, "listing not found")
+		if err.Error() == "flag not found" {
+			platform.Error(w, http.StatusNotFound, "flag not found")
 			return
 		}
 		platform.Error(w, http.StatusInternalServerError, "failed to flag listing")
@@ -543,7 +1082,7 @@ func (h *Handlers) UpdateFlagListingHandler(w http.ResponseWriter, r *http.Reque
 // DeleteFlagListingHandler handles deleting a flagged listing (admin only)
 func (h *Handlers) DeleteFlagListingHandler(w http.ResponseWriter, r *http.Request) {
 	// Validate user is authenticated and get role
-	_, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	userID, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
 	if err != nil {
 		return
 	}
@@ -568,12 +1107,16 @@ func (h *Handlers) DeleteFlagListingHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Delete the flag
-	err = h.S.DeleteFlagListing(r.Context(), flagID)
+	err = h.S.DeleteFlagListing(r.Context(), flagID, userID, userRole, r.URL.Query().Get("reason"))
 	if err != nil {
 		if err.Error() == "flag not found" {
 			platform.Error(w, http.StatusNotFound, "flag not found")
 			return
 		}
+		if err.Error() == "reason is required" {
+			reasonRequired(w)
+			return
+		}
 		log.Printf("Error deleting flagged listing: %v", err)
 		platform.Error(w, http.StatusInternalServerError, "failed to delete flagged listing")
 		return
@@ -630,7 +1173,7 @@ func (h *Handlers) GetMediaUrlsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch media URLs from repository
-	media, err := h.S.GetMediaUrls(r.Context(), listingID)
+	media, err := h.S.GetMediaUrls(r.Context(), listingID, r.Header.Get("X-User-ID"))
 	if err != nil {
 		log.Printf("Error fetching media URLs: %v", err)
 		platform.Error(w, http.StatusInternalServerError, "failed to fetch media URLs")
@@ -675,6 +1218,11 @@ func (h *Handlers) UpdateMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	// Decode JSON body
 	var p models.UpdateMediaParams
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
@@ -682,15 +1230,28 @@ func (h *Handlers) UpdateMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if p.NewURL == "" {
-		platform.Error(w, http.StatusBadRequest, "new_url is required")
+	if p.NewURL == "" && p.AltText == nil && p.Caption == nil {
+		platform.Error(w, http.StatusBadRequest, "new_url, alt_text or caption is required")
 		return
 	}
 
 	// Call repository method to update media URL
-	err = h.S.UpdateMediaUrl(r.Context(), mediaID, listingID, userID, userRole, p.NewURL)
+	version, err := h.S.UpdateMediaUrl(r.Context(), mediaID, listingID, userID, userRole, ifMatch, r.URL.Query().Get("reason"), p)
 	if err != nil {
+		var fe *fieldError
+		if errors.As(err, &fe) {
+			platform.ValidationError(w, fe.Message, fe.FieldError)
+			return
+		}
 		// Check error type to return appropriate status code
+		if err.Error() == "reason is required" {
+			reasonRequired(w)
+			return
+		}
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
 		if err.Error() == "listing not found" || err.Error() == "media not found" {
 			platform.Error(w, http.StatusNotFound, err.Error())
 			return
@@ -703,8 +1264,10 @@ func (h *Handlers) UpdateMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(version))
 	platform.JSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Media URL updated successfully",
+		"version": version,
 	})
 }
 
@@ -729,6 +1292,11 @@ func (h *Handlers) DeleteMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	// Decode JSON body to get media URL
 	var req struct {
 		MediaURL string `json:"media_url"`
@@ -744,9 +1312,17 @@ func (h *Handlers) DeleteMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Call repository method to delete media URL
-	err = h.S.DeleteMediaUrl(r.Context(), listingID, userID, userRole, req.MediaURL)
+	version, err := h.S.DeleteMediaUrl(r.Context(), listingID, userID, userRole, ifMatch, r.URL.Query().Get("reason"), req.MediaURL)
 	if err != nil {
 		// Check error type to return appropriate status code
+		if err.Error() == "reason is required" {
+			reasonRequired(w)
+			return
+		}
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
 		if err.Error() == "listing not found" || err.Error() == "media URL not found" {
 			platform.Error(w, http.StatusNotFound, err.Error())
 			return
@@ -759,8 +1335,164 @@ func (h *Handlers) DeleteMediaUrlHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(version))
 	platform.JSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Media URL deleted successfully",
+		"version": version,
+	})
+}
+
+// DeleteMediaHandler handles deleting a single media item by ID
+func (h *Handlers) DeleteMediaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	if err != nil {
+		return
+	}
+
+	listingID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+		return
+	}
+	mediaID, err := strconv.ParseInt(chi.URLParam(r, "media_id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid media ID")
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := h.S.DeleteMedia(r.Context(), listingID, mediaID, userID, userRole, ifMatch, r.URL.Query().Get("reason"))
+	if err != nil {
+		if err.Error() == "reason is required" {
+			reasonRequired(w)
+			return
+		}
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
+		if err.Error() == "listing not found" || err.Error() == "media not found" {
+			platform.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "listing does not belong to user" {
+			platform.Error(w, http.StatusForbidden, err.Error())
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(version))
+	platform.JSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Media deleted successfully",
+		"version": version,
+	})
+}
+
+// ReorderMediaHandler sets the gallery order of a listing's media
+func (h *Handlers) ReorderMediaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	listingID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var p models.ReorderMediaParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	version, err := h.S.ReorderMedia(r.Context(), listingID, userID, ifMatch, p.MediaIDs)
+	if err != nil {
+		var fe *fieldError
+		if errors.As(err, &fe) {
+			platform.ValidationError(w, fe.Message, fe.FieldError)
+			return
+		}
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
+		if err.Error() == "listing not found" {
+			platform.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "listing does not belong to user" {
+			platform.Error(w, http.StatusForbidden, err.Error())
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(version))
+	platform.JSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Media reordered successfully",
+		"version": version,
+	})
+}
+
+// SetCoverHandler makes a media item the cover image of its listing
+func (h *Handlers) SetCoverHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	listingID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+		return
+	}
+	mediaID, err := strconv.ParseInt(chi.URLParam(r, "media_id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid media ID")
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := h.S.SetCover(r.Context(), listingID, mediaID, userID, ifMatch)
+	if err != nil {
+		if err.Error() == "listing version mismatch" {
+			platform.Error(w, http.StatusPreconditionFailed, "listing was modified by someone else; reload it and try again")
+			return
+		}
+		if err.Error() == "listing not found" || err.Error() == "media not found" {
+			platform.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "listing does not belong to user" {
+			platform.Error(w, http.StatusForbidden, err.Error())
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(version))
+	platform.JSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Cover updated successfully",
+		"version": version,
 	})
 }
 
@@ -877,12 +1609,440 @@ func (h *Handlers) GetSavedListingsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Fetch saved listings from repository
-	savedListings, err := h.S.GetSavedListings(r.Context(), userID)
+	savedListings, next, err := h.S.GetSavedListings(r.Context(), userID, pageParams(r))
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
 		log.Printf("Error fetching saved listings: %v", err)
 		platform.Error(w, http.StatusInternalServerError, "failed to fetch saved listings")
 		return
 	}
 
-	platform.JSON(w, http.StatusOK, savedListings)
+	writePage(w, savedListings, next)
+}
+
+// offerError maps offer store errors to problems.
+func offerError(w http.ResponseWriter, err error) {
+	switch {
+	case err.Error() == "listing not found", errors.Is(err, errOfferNotFound):
+		platform.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errOwnListing), errors.Is(err, errOfferNotYours):
+		platform.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, errListingNotAvailable), errors.Is(err, errOfferExists), errors.Is(err, errOfferClosed):
+		platform.Error(w, http.StatusConflict, err.Error())
+	default:
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// CreateOfferHandler makes an offer on the listing in the URL.
+func (h *Handlers) CreateOfferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	listingID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+		return
+	}
+	var p models.CreateOfferParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if p.Amount <= 0 {
+		platform.ValidationError(w, "amount must be positive",
+			httplib.FieldError{Field: "amount", Code: "invalid", Message: "amount must be a positive number of cents"})
+		return
+	}
+
+	o, err := h.S.CreateOffer(r.Context(), listingID, userID, p)
+	if err != nil {
+		offerError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusCreated, o)
+}
+
+// RespondOfferHandler accepts, rejects, counters or withdraws an offer.
+func (h *Handlers) RespondOfferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	offerID, err := strconv.ParseInt(chi.URLParam(r, "offer_id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid offer ID")
+		return
+	}
+	var p models.RespondOfferParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	switch p.Action {
+	case models.OfferActionAccept, models.OfferActionReject, models.OfferActionWithdraw:
+	case models.OfferActionCounter:
+		if p.Amount <= 0 {
+			platform.ValidationError(w, "amount must be positive",
+				httplib.FieldError{Field: "amount", Code: "invalid", Message: "a counter-offer needs a positive amount in cents"})
+			return
+		}
+	default:
+		platform.ValidationError(w, "action is invalid",
+			httplib.FieldError{Field: "action", Code: "invalid", Message: "action must be one of accept, reject, counter, withdraw"})
+		return
+	}
+
+	res, err := h.S.RespondOffer(r.Context(), offerID, userID, p)
+	if err != nil {
+		offerError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, res)
+}
+
+// GetOffersHandler lists the caller's offers, optionally narrowed by role
+// ("buyer" or "seller") and listing_id.
+func (h *Handlers) GetOffersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	q := r.URL.Query()
+	role := q.Get("role")
+	if role != "" && role != "buyer" && role != "seller" {
+		platform.ValidationError(w, "role is invalid",
+			httplib.FieldError{Field: "role", Code: "invalid", Message: "role must be buyer or seller"})
+		return
+	}
+	var listingID *int64
+	if s := q.Get("listing_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+			return
+		}
+		listingID = &id
+	}
+
+	offers, next, err := h.S.GetOffers(r.Context(), userID, role, listingID, pageParams(r))
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if offers == nil {
+		offers = []models.Offer{}
+	}
+	writePage(w, offers, next)
+}
+
+// transactionError maps sale and transaction store errors to problems.
+func transactionError(w http.ResponseWriter, err error) {
+	switch {
+	case err.Error() == "listing not found", errors.Is(err, errTransactionNotFound), errors.Is(err, errBuyerNotFound):
+		platform.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errOwnPurchase):
+		platform.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errInvalidTransition), errors.Is(err, errReservedForOther), errors.Is(err, errAlreadyReceived),
+		errors.Is(err, errAlreadySold):
+		platform.Error(w, http.StatusConflict, err.Error())
+	default:
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// RecordSaleHandler marks the listing in the URL sold to a buyer.
+func (h *Handlers) RecordSaleHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	listingID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid listing ID")
+		return
+	}
+	var p models.RecordSaleParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if p.BuyerID == uuid.Nil {
+		platform.ValidationError(w, "buyer_id is required",
+			httplib.FieldError{Field: "buyer_id", Code: "required", Message: "buyer_id is required"})
+		return
+	}
+	if p.Price != nil && *p.Price < 0 {
+		platform.ValidationError(w, "price must not be negative",
+			httplib.FieldError{Field: "price", Code: "invalid", Message: "price must be zero or more cents"})
+		return
+	}
+
+	t, err := h.S.RecordSale(r.Context(), listingID, userID, p)
+	if err != nil {
+		transactionError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusCreated, t)
+}
+
+// ConfirmReceiptHandler lets the buyer confirm they received the item.
+func (h *Handlers) ConfirmReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	transactionID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		platform.Error(w, http.StatusBadRequest, "invalid transaction ID")
+		return
+	}
+
+	t, err := h.S.ConfirmReceipt(r.Context(), transactionID, userID)
+	if err != nil {
+		transactionError(w, err)
+		return
+	}
+	platform.JSON(w, http.StatusOK, t)
+}
+
+// GetTransactionsHandler lists the caller's purchases and sales, optionally
+// narrowed by role ("buyer" or "seller").
+func (h *Handlers) GetTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ValidateUserAndRoleAuth(w, r)
+	if err != nil {
+		return
+	}
+
+	role := r.URL.Query().Get("role")
+	if role != "" && role != "buyer" && role != "seller" {
+		platform.ValidationError(w, "role is invalid",
+			httplib.FieldError{Field: "role", Code: "invalid", Message: "role must be buyer or seller"})
+		return
+	}
+
+	txns, next, err := h.S.GetTransactions(r.Context(), userID, role, pageParams(r))
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if txns == nil {
+		txns = []models.Transaction{}
+	}
+	writePage(w, txns, next)
+}
+
+// BookLookupHandler fills in a textbook listing from the ISBN in the URL.
+func (h *Handlers) BookLookupHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := common.ValidateUserAndRoleAuth(w, r); err != nil {
+		return
+	}
+
+	isbn, err := books.NormalizeISBN(chi.URLParam(r, "isbn"))
+	if err != nil {
+		platform.ValidationError(w, err.Error(),
+			httplib.FieldError{Field: "isbn", Code: "invalid", Message: err.Error()})
+		return
+	}
+
+	book, err := h.lookupBook(r.Context(), isbn)
+	if err != nil {
+		if errors.Is(err, books.ErrNotFound) {
+			platform.Error(w, http.StatusNotFound, "no book found for this ISBN")
+			return
+		}
+		platform.Error(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	attrs := models.Attributes{models.AttrISBN: isbn}
+	if book.Edition != "" {
+		attrs[models.AttrEdition] = truncate(book.Edition, models.MaxAttributeValueLen)
+	}
+	prefill := models.BookPrefill{
+		Book: *book,
+		Listing: models.CreateParams{
+			Title:      book.Title,
+			Category:   models.CatTextbook,
+			Attributes: attrs,
+		},
+		CoverURL: book.CoverURL,
+	}
+	if len(book.Authors) > 0 {
+		desc := "By " + strings.Join(book.Authors, ", ")
+		prefill.Listing.Description = &desc
+	}
+	platform.JSON(w, http.StatusOK, prefill)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// maxCourseImportSize caps the CSV body of a catalog import.
+const maxCourseImportSize = 1 << 20
+
+// courseCode reads the course code in the URL, writing a 400 when it is not
+// a valid code.
+func courseCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	code, err := normalizeAttributeValue(models.AttrCourseCode, chi.URLParam(r, "code"))
+	if err != nil {
+		platform.ValidationError(w, err.Error(), httplib.FieldError{Field: "code", Code: "invalid", Message: err.Error()})
+		return "", false
+	}
+	return code, true
+}
+
+// ImportCoursesHandler adds or replaces catalog courses from a CSV body. Any
+// invalid row rejects the whole import.
+func (h *Handlers) ImportCoursesHandler(w http.ResponseWriter, r *http.Request) {
+	_, userRole, err := common.ValidateUserAndRoleAuthWithRole(w, r)
+	if err != nil {
+		return
+	}
+	if userRole != string(httplib.ADMIN) {
+		platform.Error(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	courses, fields := parseCourseCSV(http.MaxBytesReader(w, r.Body, maxCourseImportSize))
+	if len(fields) > 0 {
+		platform.ValidationError(w, "invalid course catalog", fields...)
+		return
+	}
+
+	res, err := h.S.ImportCourses(r.Context(), courses)
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Cache the books up front so course searches match their other editions
+	var isbns []string
+	for _, c := range courses {
+		for _, b := range c.Books {
+			isbns = append(isbns, b.ISBN)
+		}
+	}
+	h.rememberBooks(isbns)
+
+	platform.JSON(w, http.StatusOK, res)
+}
+
+func (h *Handlers) GetCourseHandler(w http.ResponseWriter, r *http.Request) {
+	code, ok := courseCode(w, r)
+	if !ok {
+		return
+	}
+	c, err := h.S.GetCourse(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, errCourseNotFound) {
+			platform.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, c)
+}
+
+// CourseListingsHandler returns the available listings of a course's books.
+func (h *Handlers) CourseListingsHandler(w http.ResponseWriter, r *http.Request) {
+	code, ok := courseCode(w, r)
+	if !ok {
+		return
+	}
+	exists, err := h.S.CourseExists(r.Context(), code)
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !exists {
+		platform.Error(w, http.StatusNotFound, errCourseNotFound.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	available := models.StAvailable
+	f := models.ListFilters{
+		Course: &code,
+		Status: &available,
+		Limit:  common.ParseInt(q.Get("limit"), 20),
+		Cursor: q.Get("cursor"),
+		Sort:   q.Get("sort"),
+	}
+	page, err := h.S.List(r.Context(), &f)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			invalidCursor(w)
+			return
+		}
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, page)
+}
+
+// TrendingHandler returns the available listings ranked by a time-decayed
+// score of their recent views, saves and chats started.
+func (h *Handlers) TrendingHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var category *models.Category
+	if s := q.Get("category"); s != "" {
+		c := models.Category(s)
+		if !slices.Contains(models.AllCategories, c) {
+			platform.ValidationError(w, "category is invalid", httplib.FieldError{Field: "category", Code: "invalid",
+				Message: fmt.Sprintf("category must be one of %s", categoriesAsString())})
+			return
+		}
+		category = &c
+	}
+	ls, err := h.S.Trending(r.Context(), category, common.ParseInt(q.Get("limit"), 20))
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, ls)
+}
+
+// SimilarHandler recommends available listings like the one in the URL. The
+// caller's own listings are left out.
+func (h *Handlers) SimilarHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	viewerID := r.Header.Get("X-User-ID")
+	l, err := h.S.Get(r.Context(), id)
+	if err != nil || (l.Status == models.StDraft && viewerID != l.UserID.String()) {
+		platform.Error(w, http.StatusNotFound, "not found")
+		return
+	}
+	ls, err := h.S.Similar(r.Context(), id, viewerID, common.ParseInt(r.URL.Query().Get("limit"), 8))
+	if err != nil {
+		platform.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	platform.JSON(w, http.StatusOK, ls)
 }
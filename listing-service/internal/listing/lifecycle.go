@@ -0,0 +1,277 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// DefaultExpiryDays is how long a listing stays active without being renewed.
+const DefaultExpiryDays = 30
+
+var errInvalidTransition = errors.New("invalid status transition")
+
+// errSaleRequired is returned when a listing is marked SOLD by editing it;
+// selling goes through RecordSale so every sale has a buyer and a
+// transaction.
+var errSaleRequired = fmt.Errorf("%w: record the sale with POST /sell/{id} to mark a listing SOLD", errInvalidTransition)
+
+// errRelistSold is returned when a listing that was sold, and archived
+// since, would be put back on sale; a sale is final.
+var errRelistSold = fmt.Errorf("%w: a sold listing cannot be relisted", errInvalidTransition)
+
+// transitions lists the statuses a seller may move a listing to from each
+// status. REPORTED is entered by flagging and only moderators may set or
+// clear it, see canTransition. SOLD is only entered by RecordSale.
+var transitions = map[models.Status][]models.Status{
+	models.StAvailable: {models.StPending, models.StArchived},
+	models.StPending:   {models.StAvailable, models.StArchived},
+	models.StSold:      {models.StArchived},
+	models.StArchived:  {models.StAvailable},
+	models.StDraft:     {models.StAvailable},
+}
+
+// publishSets are the extra SET clauses of publishing a draft: it shows up
+// as new and is no longer scheduled. The expiry period starts as for any
+// listing becoming active.
+const publishSets = "created_at=NOW(), publish_at=NULL"
+
+// validateSchedule checks that a publish time is in the future; nil is valid.
+func validateSchedule(publishAt *time.Time) *httplib.FieldError {
+	if publishAt == nil || publishAt.After(time.Now()) {
+		return nil
+	}
+	return &httplib.FieldError{Field: "publish_at", Code: "out_of_range", Message: "publish_at must be in the future"}
+}
+
+// canTransition reports whether a listing may move from one status to
+// another. Moderators can additionally put any listing under review and
+// release a reported listing as AVAILABLE or ARCHIVED.
+func canTransition(from, to models.Status, moderator bool) bool {
+	if from == to {
+		return true
+	}
+	if from == models.StReported || to == models.StReported {
+		return moderator && (to == models.StReported || to == models.StAvailable || to == models.StArchived)
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func transitionError(from, to models.Status) error {
+	return fmt.Errorf("%w: a %s listing cannot be moved to %s", errInvalidTransition, from, to)
+}
+
+// checkRelist refuses to make listing id active again once it has been
+// sold, whatever status it has reached since.
+func checkRelist(ctx context.Context, tx pgx.Tx, id int64) error {
+	var sold bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM transactions WHERE listing_id=$1)`, id).Scan(&sold)
+	if err != nil {
+		return fmt.Errorf("failed to check for a sale: %w", err)
+	}
+	if sold {
+		return errRelistSold
+	}
+	return nil
+}
+
+// isActive reports whether a listing in status s counts down to expiry.
+func isActive(s models.Status) bool {
+	return s == models.StAvailable || s == models.StPending
+}
+
+func (s *Store) expiryDays() int {
+	if s.ExpiryDays > 0 {
+		return s.ExpiryDays
+	}
+	return DefaultExpiryDays
+}
+
+// Renew pushes a listing's expiry out by the expiry period and clears any
+// sent reminder. An archived listing is relisted as AVAILABLE unless it was
+// sold; sold and reported listings cannot be renewed.
+func (s *Store) Renew(ctx context.Context, id int64, userID string, userRole string, ifMatch *int64) (models.Listing, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockListing(ctx, tx, id, userID, userRole, ifMatch)
+	if err != nil {
+		return models.Listing{}, err
+	}
+	status := before.Status
+	if status == models.StArchived {
+		if err := checkRelist(ctx, tx, id); err != nil {
+			return models.Listing{}, err
+		}
+		status = models.StAvailable
+	}
+	if !isActive(status) {
+		return models.Listing{}, fmt.Errorf("%w: a %s listing cannot be renewed", errInvalidTransition, before.Status)
+	}
+
+	var l models.Listing
+	err = tx.QueryRow(ctx, `
+		UPDATE listings
+		SET status=$2, expires_at=NOW() + make_interval(days => $3), expiry_reminded_at=NULL,
+			version=version+1, updated_at=NOW()
+		WHERE id=$1
+		RETURNING `+listingColumns, id, status, s.expiryDays()).Scan(listingFields(&l)...)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Listing{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return l, nil
+}
+
+// ExpireListings archives every active listing whose expiry has passed and
+// returns how many were archived.
+func (s *Store) ExpireListings(ctx context.Context) (int64, error) {
+	tag, err := s.P.Exec(ctx, `
+		UPDATE listings
+		SET status='ARCHIVED', reserved_for=NULL, version=version+1, updated_at=NOW()
+		WHERE status IN ('AVAILABLE', 'PENDING') AND expires_at <= NOW() AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire listings: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PublishDueDrafts publishes every draft whose publish time has passed and
+// returns how many were published. Each draft is published in its own
+// transaction, so one that fails does not hold back the others.
+func (s *Store) PublishDueDrafts(ctx context.Context) (int64, error) {
+	rows, err := s.P.Query(ctx, `
+		SELECT id FROM listings
+		WHERE status='DRAFT' AND publish_at <= NOW() AND deleted_at IS NULL
+		ORDER BY publish_at
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query scheduled listings: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, fmt.Errorf("failed to query scheduled listings: %w", err)
+	}
+
+	var n int64
+	for _, id := range ids {
+		published, err := s.publishDraft(ctx, id)
+		if err != nil {
+			log.Printf("Failed to publish scheduled listing %d: %v", id, err)
+			continue
+		}
+		if published {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// publishDraft publishes a due draft the way a seller publishing it would:
+// it is checked against other sellers' listings and the edit is recorded.
+// publish_notice_pending has the orchestrator announce it to saved
+// searches. A draft that was edited, deleted or published in the meantime
+// is left alone.
+func (s *Store) publishDraft(ctx context.Context, id int64) (bool, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var before models.Listing
+	err = tx.QueryRow(ctx, `
+		SELECT `+listingColumns+` FROM listings
+		WHERE id=$1 AND status='DRAFT' AND publish_at <= NOW() AND deleted_at IS NULL
+		FOR UPDATE SKIP LOCKED
+	`, id).Scan(listingFields(&before)...)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load listing: %w", err)
+	}
+
+	var after models.Listing
+	err = tx.QueryRow(ctx, `
+		UPDATE listings
+		SET status='AVAILABLE', `+publishSets+`,
+			expires_at=NOW() + make_interval(days => $2), expiry_reminded_at=NULL,
+			publish_notice_pending=TRUE, version=version+1, updated_at=NOW()
+		WHERE id=$1
+		RETURNING `+listingColumns, id, s.expiryDays()).Scan(listingFields(&after)...)
+	if err != nil {
+		return false, fmt.Errorf("failed to publish listing: %w", err)
+	}
+	if err := fingerprintListing(ctx, tx, id, false); err != nil {
+		return false, err
+	}
+	if err := recordRevision(ctx, tx, before, after, "", ""); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RunPublisher publishes due drafts every interval until ctx is done.
+func (s *Store) RunPublisher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PublishDueDrafts(ctx)
+			if err != nil {
+				log.Printf("Failed to publish scheduled listings: %v", err)
+			} else if n > 0 {
+				log.Printf("Published %d scheduled listings", n)
+			}
+		}
+	}
+}
+
+// RunExpiry archives expired listings and closes lapsed offers every
+// interval until ctx is done.
+func (s *Store) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpireListings(ctx)
+			if err != nil {
+				log.Printf("Failed to archive expired listings: %v", err)
+			} else if n > 0 {
+				log.Printf("Archived %d expired listings", n)
+			}
+			if err := s.ExpireOffers(ctx); err != nil {
+				log.Printf("Failed to close expired offers: %v", err)
+			}
+		}
+	}
+}
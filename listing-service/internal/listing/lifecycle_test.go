@@ -0,0 +1,53 @@
+package listing
+
+import (
+	"testing"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to  models.Status
+		moderator bool
+		want      bool
+	}{
+		{models.StAvailable, models.StAvailable, false, true},
+		{models.StAvailable, models.StPending, false, true},
+		{models.StAvailable, models.StArchived, false, true},
+		{models.StAvailable, models.StSold, false, false},
+		{models.StAvailable, models.StDraft, false, false},
+		{models.StPending, models.StAvailable, false, true},
+		{models.StPending, models.StArchived, false, true},
+		{models.StPending, models.StSold, false, false},
+		{models.StSold, models.StArchived, false, true},
+		{models.StSold, models.StAvailable, false, false},
+		{models.StSold, models.StAvailable, true, false},
+		{models.StArchived, models.StAvailable, false, true},
+		{models.StArchived, models.StPending, false, false},
+		{models.StDraft, models.StAvailable, false, true},
+		{models.StDraft, models.StPending, false, false},
+
+		// Only moderators can put a listing under review or release it
+		{models.StAvailable, models.StReported, false, false},
+		{models.StAvailable, models.StReported, true, true},
+		{models.StSold, models.StReported, true, true},
+		{models.StReported, models.StAvailable, false, false},
+		{models.StReported, models.StAvailable, true, true},
+		{models.StReported, models.StArchived, true, true},
+		{models.StReported, models.StPending, true, false},
+		{models.StReported, models.StSold, true, false},
+		{models.StReported, models.StReported, false, true},
+	}
+	for _, tt := range tests {
+		name := string(tt.from) + "->" + string(tt.to)
+		if tt.moderator {
+			name += " as moderator"
+		}
+		t.Run(name, func(t *testing.T) {
+			if got := canTransition(tt.from, tt.to, tt.moderator); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+const (
+	maxAltTextLength = 300
+	maxCaptionLength = 500
+)
+
+// validateMedia checks the description and file details of a media item;
+// prefix qualifies the reported field names.
+func validateMedia(prefix string, m models.MediaInput) *httplib.FieldError {
+	field := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+	switch {
+	case m.AltText != nil && len(*m.AltText) > maxAltTextLength:
+		return &httplib.FieldError{Field: field("alt_text"), Code: "too_long",
+			Message: fmt.Sprintf("alt_text must be at most %d characters", maxAltTextLength)}
+	case m.Caption != nil && len(*m.Caption) > maxCaptionLength:
+		return &httplib.FieldError{Field: field("caption"), Code: "too_long",
+			Message: fmt.Sprintf("caption must be at most %d characters", maxCaptionLength)}
+	case m.MimeType != nil && !strings.HasPrefix(*m.MimeType, "image/") && !strings.HasPrefix(*m.MimeType, "video/"):
+		return &httplib.FieldError{Field: field("mime_type"), Code: "invalid", Message: "mime_type must be an image or video type"}
+	case m.Width != nil && *m.Width <= 0, m.Height != nil && *m.Height <= 0:
+		return &httplib.FieldError{Field: field("width"), Code: "out_of_range", Message: "width and height must be greater than 0"}
+	case m.SizeBytes != nil && *m.SizeBytes <= 0:
+		return &httplib.FieldError{Field: field("size_bytes"), Code: "out_of_range", Message: "size_bytes must be greater than 0"}
+	}
+	return nil
+}
+
+// ensureCover makes the first media of a listing its cover when it has
+// none, e.g. after its first upload or after the cover was deleted.
+func ensureCover(ctx context.Context, tx pgx.Tx, listingID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE listing_media SET is_cover = TRUE
+		WHERE id = (SELECT id FROM listing_media WHERE listing_id = $1 ORDER BY position, id LIMIT 1)
+		AND NOT EXISTS (SELECT 1 FROM listing_media WHERE listing_id = $1 AND is_cover)
+	`, listingID)
+	if err != nil {
+		return fmt.Errorf("failed to set listing cover: %w", err)
+	}
+	return nil
+}
+
+// compactMedia renumbers a listing's media from 0 in their current order
+// after some were removed, and replaces a removed cover.
+func compactMedia(ctx context.Context, tx pgx.Tx, listingID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE listing_media m SET position = o.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) - 1 AS position
+			FROM listing_media WHERE listing_id = $1
+		) o
+		WHERE m.id = o.id AND m.position <> o.position
+	`, listingID)
+	if err != nil {
+		return fmt.Errorf("failed to reorder media: %w", err)
+	}
+	return ensureCover(ctx, tx, listingID)
+}
+
+// ReorderMedia sets the gallery order of a listing; ids must hold every
+// media ID of the listing exactly once.
+func (s *Store) ReorderMedia(ctx context.Context, listingID int64, userID string, ifMatch *int64, ids []int64) (int64, error) {
+	return s.withVersionBump(ctx, listingID, userID, "", ifMatch, func(tx pgx.Tx, _ string) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE listing_media m SET position = o.ord - 1
+			FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, ord)
+			WHERE m.id = o.id AND m.listing_id = $1
+		`, listingID, ids)
+		if err != nil {
+			return fmt.Errorf("failed to reorder media: %w", err)
+		}
+		var total int64
+		if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM listing_media WHERE listing_id=$1`, listingID).Scan(&total); err != nil {
+			return fmt.Errorf("failed to count media: %w", err)
+		}
+		// Unknown or repeated IDs leave rows unmatched or the count short
+		if tag.RowsAffected() != total || int64(len(ids)) != total {
+			return &fieldError{httplib.FieldError{Field: "media_ids", Code: "invalid",
+				Message: "media_ids must list every media item of the listing exactly once"}}
+		}
+		return nil
+	})
+}
+
+// SetCover makes one media item the cover of its listing.
+func (s *Store) SetCover(ctx context.Context, listingID int64, mediaID int64, userID string, ifMatch *int64) (int64, error) {
+	return s.withVersionBump(ctx, listingID, userID, "", ifMatch, func(tx pgx.Tx, _ string) error {
+		var exists bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM listing_media WHERE id=$1 AND listing_id=$2)`, mediaID, listingID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to verify media: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("media not found")
+		}
+		// Cleared first so the one-cover index never sees two
+		if _, err := tx.Exec(ctx, `UPDATE listing_media SET is_cover = FALSE WHERE listing_id=$1 AND is_cover AND id <> $2`, listingID, mediaID); err != nil {
+			return fmt.Errorf("failed to clear listing cover: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE listing_media SET is_cover = TRUE WHERE id=$1`, mediaID); err != nil {
+			return fmt.Errorf("failed to set listing cover: %w", err)
+		}
+		return nil
+	})
+}
+
+// listingRefs returns pointers to the listings of ls, for attachCovers.
+func listingRefs(ls []models.Listing) []*models.Listing {
+	refs := make([]*models.Listing, len(ls))
+	for i := range ls {
+		refs[i] = &ls[i]
+	}
+	return refs
+}
+
+// attachCovers sets the cover image of every listing in one query, so list
+// responses carry a thumbnail without a media request per listing.
+func (s *Store) attachCovers(ctx context.Context, ls []*models.Listing) error {
+	if len(ls) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(ls))
+	for i, l := range ls {
+		ids[i] = l.ID
+	}
+	rows, err := s.P.Query(ctx, `
+		SELECT listing_id, id, media_url, alt_text, width, height
+		FROM listing_media
+		WHERE listing_id = ANY($1) AND is_cover
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query listing covers: %w", err)
+	}
+	defer rows.Close()
+
+	covers := map[int64]*models.Cover{}
+	for rows.Next() {
+		var (
+			listingID int64
+			c         models.Cover
+		)
+		if err := rows.Scan(&listingID, &c.MediaID, &c.MediaURL, &c.AltText, &c.Width, &c.Height); err != nil {
+			return fmt.Errorf("failed to scan listing cover: %w", err)
+		}
+		covers[listingID] = &c
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, l := range ls {
+		l.Cover = covers[l.ID]
+	}
+	return nil
+}
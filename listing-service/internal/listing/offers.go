@@ -0,0 +1,287 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+var (
+	errOfferNotFound       = errors.New("offer not found")
+	errOwnListing          = errors.New("you cannot make an offer on your own listing")
+	errListingNotAvailable = errors.New("listing is not available")
+	errOfferExists         = errors.New("you already have an open offer on this listing")
+	errOfferClosed         = errors.New("offer is no longer open")
+	errOfferNotYours       = errors.New("only the other party can respond to this offer")
+)
+
+// offerColumns is the column list selected for an offer; scan it with offerFields.
+const offerColumns = "id, listing_id, buyer_id, seller_id, from_user_id, parent_offer_id, amount, message, status, expires_at, created_at, updated_at"
+
+// offerFields returns the scan destinations matching offerColumns.
+func offerFields(o *models.Offer) []any {
+	return []any{&o.ID, &o.ListingID, &o.BuyerID, &o.SellerID, &o.FromUserID, &o.ParentOfferID, &o.Amount, &o.Message, &o.Status, &o.ExpiresAt, &o.CreatedAt, &o.UpdatedAt}
+}
+
+func (s *Store) offerTTL() time.Duration {
+	if s.OfferTTL > 0 {
+		return s.OfferTTL
+	}
+	return models.DefaultOfferTTL
+}
+
+// CreateOffer opens a negotiation on an available listing. A buyer has at
+// most one open offer per listing.
+func (s *Store) CreateOffer(ctx context.Context, listingID int64, buyerID string, p models.CreateOfferParams) (models.Offer, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.Offer{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var sellerID string
+	var status models.Status
+	err = tx.QueryRow(ctx, `SELECT user_id::text, status FROM listings WHERE id=$1 AND deleted_at IS NULL FOR SHARE`, listingID).Scan(&sellerID, &status)
+	if err == pgx.ErrNoRows {
+		return models.Offer{}, fmt.Errorf("listing not found")
+	}
+	if err != nil {
+		return models.Offer{}, fmt.Errorf("failed to load listing: %w", err)
+	}
+	if sellerID == buyerID {
+		return models.Offer{}, errOwnListing
+	}
+	if status != models.StAvailable {
+		return models.Offer{}, errListingNotAvailable
+	}
+
+	// A lapsed offer no longer blocks a new one
+	if err := expireOffers(ctx, tx, `AND listing_id=$1 AND buyer_id=$2::uuid`, listingID, buyerID); err != nil {
+		return models.Offer{}, err
+	}
+
+	var o models.Offer
+	err = tx.QueryRow(ctx, `
+		INSERT INTO offers (listing_id, buyer_id, seller_id, from_user_id, amount, message, expires_at)
+		VALUES ($1, $2::uuid, $3::uuid, $2::uuid, $4, $5, NOW() + make_interval(secs => $6))
+		RETURNING `+offerColumns,
+		listingID, buyerID, sellerID, p.Amount, p.Message, s.offerTTL().Seconds()).Scan(offerFields(&o)...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.Offer{}, errOfferExists
+		}
+		return models.Offer{}, fmt.Errorf("failed to create offer: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Offer{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return o, nil
+}
+
+// RespondOffer applies a buyer or seller response to an open offer. The
+// party who made the offer may only withdraw it; the other party accepts,
+// rejects or counters. Accepting moves the listing to PENDING, reserved for
+// the buyer, and declines every other open offer on it.
+func (s *Store) RespondOffer(ctx context.Context, offerID int64, userID string, p models.RespondOfferParams) (models.OfferResult, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var o models.Offer
+	err = tx.QueryRow(ctx, `SELECT `+offerColumns+` FROM offers WHERE id=$1 FOR UPDATE`, offerID).Scan(offerFields(&o)...)
+	if err == pgx.ErrNoRows {
+		return models.OfferResult{}, errOfferNotFound
+	}
+	if err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to load offer: %w", err)
+	}
+	if o.BuyerID.String() != userID && o.SellerID.String() != userID {
+		return models.OfferResult{}, errOfferNotFound
+	}
+	if o.Status != models.OfferPending || !o.ExpiresAt.After(time.Now()) {
+		return models.OfferResult{}, errOfferClosed
+	}
+	ownOffer := o.FromUserID.String() == userID
+	if ownOffer != (p.Action == models.OfferActionWithdraw) {
+		return models.OfferResult{}, errOfferNotYours
+	}
+
+	var res models.OfferResult
+	switch p.Action {
+	case models.OfferActionWithdraw:
+		res.Offer, err = setOfferStatus(ctx, tx, offerID, models.OfferWithdrawn)
+	case models.OfferActionReject:
+		res.Offer, err = setOfferStatus(ctx, tx, offerID, models.OfferRejected)
+	case models.OfferActionCounter:
+		if _, err = setOfferStatus(ctx, tx, offerID, models.OfferCountered); err != nil {
+			break
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO offers (listing_id, buyer_id, seller_id, from_user_id, parent_offer_id, amount, message, expires_at)
+			VALUES ($1, $2, $3, $4::uuid, $5, $6, $7, NOW() + make_interval(secs => $8))
+			RETURNING `+offerColumns,
+			o.ListingID, o.BuyerID, o.SellerID, userID, o.ID, p.Amount, p.Message, s.offerTTL().Seconds()).Scan(offerFields(&res.Offer)...)
+	case models.OfferActionAccept:
+		res, err = acceptOffer(ctx, tx, o)
+	default:
+		return models.OfferResult{}, fmt.Errorf("invalid offer action")
+	}
+	if err != nil {
+		return models.OfferResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+func acceptOffer(ctx context.Context, tx pgx.Tx, o models.Offer) (models.OfferResult, error) {
+	var status models.Status
+	err := tx.QueryRow(ctx, `SELECT status FROM listings WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, o.ListingID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return models.OfferResult{}, errListingNotAvailable
+	}
+	if err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to load listing: %w", err)
+	}
+	if status != models.StAvailable {
+		return models.OfferResult{}, errListingNotAvailable
+	}
+
+	accepted, err := setOfferStatus(ctx, tx, o.ID, models.OfferAccepted)
+	if err != nil {
+		return models.OfferResult{}, err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE listings
+		SET status='PENDING', reserved_for=$2, version=version+1, updated_at=NOW()
+		WHERE id=$1
+	`, o.ListingID, o.BuyerID)
+	if err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to reserve listing: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE offers SET status='REJECTED', updated_at=NOW()
+		WHERE listing_id=$1 AND status='PENDING'
+		RETURNING `+offerColumns, o.ListingID)
+	if err != nil {
+		return models.OfferResult{}, fmt.Errorf("failed to decline other offers: %w", err)
+	}
+	declined, err := scanOffers(rows)
+	if err != nil {
+		return models.OfferResult{}, err
+	}
+	return models.OfferResult{Offer: accepted, Declined: declined}, nil
+}
+
+func setOfferStatus(ctx context.Context, tx pgx.Tx, offerID int64, status models.OfferStatus) (models.Offer, error) {
+	var o models.Offer
+	err := tx.QueryRow(ctx, `UPDATE offers SET status=$2, updated_at=NOW() WHERE id=$1 RETURNING `+offerColumns, offerID, status).
+		Scan(offerFields(&o)...)
+	if err != nil {
+		return models.Offer{}, fmt.Errorf("failed to update offer: %w", err)
+	}
+	return o, nil
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// expireOffers closes lapsed open offers matching the extra conditions.
+func expireOffers(ctx context.Context, db execer, cond string, args ...any) error {
+	_, err := db.Exec(ctx, `
+		UPDATE offers SET status='EXPIRED', updated_at=NOW()
+		WHERE status='PENDING' AND expires_at <= NOW() `+cond, args...)
+	if err != nil {
+		return fmt.Errorf("failed to expire offers: %w", err)
+	}
+	return nil
+}
+
+// ExpireOffers closes every open offer past its expiry.
+func (s *Store) ExpireOffers(ctx context.Context) error {
+	return expireOffers(ctx, s.P, "")
+}
+
+// GetOffers returns the offers userID made or received, newest first. role
+// narrows them to "buyer" or "seller"; listingID to a single listing.
+func (s *Store) GetOffers(ctx context.Context, userID string, role string, listingID *int64, p models.PageParams) ([]models.Offer, string, error) {
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != nil && cursor.CreatedAt == nil {
+		return nil, "", fmt.Errorf("invalid cursor")
+	}
+	limit := pageLimit(p.Limit, maxPageSize)
+
+	q := `SELECT ` + offerColumns + ` FROM offers WHERE `
+	switch role {
+	case "buyer":
+		q += `buyer_id=$1::uuid`
+	case "seller":
+		q += `seller_id=$1::uuid`
+	default:
+		q += `(buyer_id=$1::uuid OR seller_id=$1::uuid)`
+	}
+	// Offers on deleted listings are hidden with them
+	q += ` AND listing_id IN (SELECT id FROM listings WHERE deleted_at IS NULL)`
+	args := []any{userID}
+	if listingID != nil {
+		args = append(args, *listingID)
+		q += fmt.Sprintf(" AND listing_id=$%d", len(args))
+	}
+	if cursor != nil {
+		cond, cargs := keysetCondition([]keysetColumn{
+			{expr: "created_at", desc: true, value: *cursor.CreatedAt},
+			{expr: "id", desc: true, value: cursor.ID},
+		}, len(args)+1)
+		q += " AND " + cond
+		args = append(args, cargs...)
+	}
+	q += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", limit+1)
+
+	rows, err := s.P.Query(ctx, q, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := scanOffers(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(out) > limit {
+		out = out[:limit]
+		last := out[limit-1]
+		next = pageCursor{CreatedAt: &last.CreatedAt, ID: last.ID}.encode()
+	}
+	return out, next, nil
+}
+
+func scanOffers(rows pgx.Rows) ([]models.Offer, error) {
+	defer rows.Close()
+	var out []models.Offer
+	for rows.Next() {
+		var o models.Offer
+		if err := rows.Scan(offerFields(&o)...); err != nil {
+			return nil, fmt.Errorf("failed to scan offer: %w", err)
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -20,227 +23,467 @@ type PgxPool interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
-type Store struct{ P PgxPool }
+type Store struct {
+	P PgxPool
+	// ExpiryDays is how long listings stay active before they are archived,
+	// DefaultExpiryDays when zero
+	ExpiryDays int
+	// OfferTTL is how long an offer stays open, models.DefaultOfferTTL when zero
+	OfferTTL time.Duration
+	// DeleteRetention is how long deleted listings can be restored before
+	// they are purged, models.DefaultDeleteRetention when zero
+	DeleteRetention time.Duration
+}
+
+// listingColumns is the column list selected for a listing; scan it with listingFields.
+const listingColumns = "id, title, description, price, category, user_id, status, created_at, updated_at, version, expires_at, reserved_for, condition, pickup_location, tags, attributes, publish_at"
+
+// listingColumnsAs qualifies listingColumns with a table alias for joins.
+func listingColumnsAs(alias string) string {
+	cols := strings.Split(listingColumns, ", ")
+	for i, c := range cols {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
 
+// listingFields returns the scan destinations matching listingColumns.
+func listingFields(l *models.Listing) []any {
+	return []any{&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt, &l.UpdatedAt, &l.Version, &l.ExpiresAt, &l.ReservedFor, &l.Condition, &l.PickupLocation, &l.Tags, &l.Attributes, &l.PublishAt}
+}
+
+// Create inserts a listing, failing with a *duplicateError when it nearly
+// repeats another listing of the same seller.
 func (s *Store) Create(ctx context.Context, userID string, p models.CreateParams) (models.Listing, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	l, err := insertListing(ctx, tx, s.expiryDays(), userID, p)
+	if err != nil {
+		return l, err
+	}
+	if err := fingerprintListing(ctx, tx, l.ID, true); err != nil {
+		return models.Listing{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Listing{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Println("listing repo create done: ", userID)
+	return l, nil
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertListing(ctx context.Context, db rowQuerier, expiryDays int, userID string, p models.CreateParams) (models.Listing, error) {
 	const q = `
 	WITH u AS (
 	SELECT user_id FROM users WHERE user_id = $5::uuid
 	)
-	INSERT INTO listings (title, description, price, category, user_id)
-	SELECT $1, $2, $3, $4, u.user_id
+	INSERT INTO listings (title, description, price, category, user_id, expires_at, condition, pickup_location, tags, attributes, status, publish_at)
+	SELECT $1, $2, $3, $4, u.user_id, NOW() + make_interval(days => $6), $7, $8, $9, $10, $11, $12
 	FROM u
-	RETURNING id, title, description, price, category, user_id, status, created_at;
-	`
+	RETURNING ` + listingColumns
+	status := models.StAvailable
+	if p.Draft || p.PublishAt != nil {
+		status = models.StDraft
+	}
 	var l models.Listing
-	err := s.P.QueryRow(ctx, q, p.Title, p.Description, p.Price, p.Category, userID).
-		Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt)
-	log.Println("listing repo create done: ", userID)
+	err := db.QueryRow(ctx, q, p.Title, p.Description, p.Price, p.Category, userID, expiryDays,
+		p.Condition, p.PickupLocation, p.Tags, p.Attributes, status, p.PublishAt).
+		Scan(listingFields(&l)...)
 	return l, err
 }
 
 func (s *Store) Get(ctx context.Context, id int64) (models.Listing, error) {
-	const q = `SELECT id,title,description,price,category,user_id,status,created_at FROM listings WHERE id=$1`
+	const q = `SELECT ` + listingColumns + ` FROM listings WHERE id=$1 AND deleted_at IS NULL`
 	var l models.Listing
 	err := s.P.QueryRow(ctx, q, id).
-		Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt)
+		Scan(listingFields(&l)...)
 
 	return l, err
 }
 
-func (s *Store) GetUserLists(ctx context.Context, user_id string) ([]models.Listing, error) {
-	const q = `SELECT id,title,description,price,category,user_id,status,created_at FROM listings WHERE user_id=$1::uuid`
-	var args []any
-	args = append(args, user_id)
-	rows, err := s.P.Query(ctx, q, args...)
+// GetPriceHistory returns the price changes of a listing, newest first.
+func (s *Store) GetPriceHistory(ctx context.Context, listingID int64) ([]models.PriceChange, error) {
+	rows, err := s.P.Query(ctx, `
+		SELECT old_price, new_price, changed_at
+		FROM listing_price_history
+		WHERE listing_id = $1
+		ORDER BY changed_at DESC, id DESC
+	`, listingID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query price history: %w", err)
 	}
 	defer rows.Close()
 
-	var out []models.Listing
+	var out []models.PriceChange
 	for rows.Next() {
-		var l models.Listing
-		if err := rows.Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt); err != nil {
-			return nil, err
+		var pc models.PriceChange
+		if err := rows.Scan(&pc.OldPrice, &pc.NewPrice, &pc.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price change: %w", err)
 		}
-		out = append(out, l)
+		out = append(out, pc)
 	}
 	return out, rows.Err()
 }
 
-func (s *Store) GetListingsByUserID(ctx context.Context, targetUserID string) ([]models.Listing, error) {
-	const q = `SELECT id,title,description,price,category,user_id,status,created_at FROM listings WHERE user_id=$1::uuid`
-	var args []any
-	args = append(args, targetUserID)
+// GetUserLists returns a page of the listings owned by user_id, newest first,
+// along with the cursor of the next page.
+func (s *Store) GetUserLists(ctx context.Context, user_id string, p models.PageParams) ([]models.Listing, string, error) {
+	return s.listingsByOwner(ctx, user_id, p, true)
+}
+
+// GetListingsByUserID is the admin view of another user's listings, which
+// leaves out their drafts.
+func (s *Store) GetListingsByUserID(ctx context.Context, targetUserID string, p models.PageParams) ([]models.Listing, string, error) {
+	return s.listingsByOwner(ctx, targetUserID, p, false)
+}
+
+func (s *Store) listingsByOwner(ctx context.Context, userID string, p models.PageParams, withDrafts bool) ([]models.Listing, string, error) {
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != nil && cursor.CreatedAt == nil {
+		return nil, "", fmt.Errorf("invalid cursor")
+	}
+	limit := pageLimit(p.Limit, maxPageSize)
+
+	q := `SELECT ` + listingColumns + ` FROM listings WHERE user_id=$1::uuid AND deleted_at IS NULL`
+	if !withDrafts {
+		q += ` AND status <> 'DRAFT'`
+	}
+	args := []any{userID}
+	if cursor != nil {
+		cond, cargs := keysetCondition([]keysetColumn{
+			{expr: "created_at", desc: true, value: *cursor.CreatedAt},
+			{expr: "id", desc: true, value: cursor.ID},
+		}, len(args)+1)
+		q += " AND " + cond
+		args = append(args, cargs...)
+	}
+	q += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", limit+1)
+
 	rows, err := s.P.Query(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
 	var out []models.Listing
 	for rows.Next() {
 		var l models.Listing
-		if err := rows.Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(listingFields(&l)...); err != nil {
+			return nil, "", err
 		}
 		out = append(out, l)
 	}
-	return out, rows.Err()
-}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
 
-func (s *Store) List(ctx context.Context, f *models.ListFilters) ([]models.Listing, int, error) {
-	hasKeywords := len(f.Keywords) > 0
+	next := ""
+	if len(out) > limit {
+		out = out[:limit]
+		last := out[limit-1]
+		next = pageCursor{CreatedAt: &last.CreatedAt, ID: last.ID}.encode()
+	}
+	if err := s.attachCovers(ctx, listingRefs(out)); err != nil {
+		return nil, "", err
+	}
+	return out, next, nil
+}
 
-	// First, prepare all keyword parameters for reuse
-	var keywordArgs []any
-	if hasKeywords {
-		for _, kw := range f.Keywords {
-			keywordPattern := "%" + kw + "%"
-			keywordArgs = append(keywordArgs, keywordPattern, keywordPattern) // One for title, one for description
+// searchHeadlineOptions trims ts_headline snippets to a couple of short
+// fragments around the matched terms.
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// searchText returns the websearch_to_tsquery input for f. A raw Query is
+// used as typed; Keywords (as produced by the AI search) are alternatives,
+// with multi-word keywords matched as phrases.
+func searchText(f *models.ListFilters) string {
+	if q := strings.TrimSpace(f.Query); q != "" {
+		return q
+	}
+	terms := make([]string, 0, len(f.Keywords))
+	for _, kw := range f.Keywords {
+		kw = strings.TrimSpace(strings.ReplaceAll(kw, `"`, ""))
+		if kw == "" {
+			continue
+		}
+		if strings.ContainsAny(kw, " \t") {
+			kw = `"` + kw + `"`
 		}
+		terms = append(terms, kw)
 	}
+	return strings.Join(terms, " or ")
+}
 
-	// Build WHERE clause (shared between COUNT and SELECT queries)
+// likeEscaper escapes the LIKE wildcards of a substring filter.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterClauses builds the WHERE terms and arguments of f, leaving out the
+// filter on the facet named by omit ("category", "status" or "price") so that
+// facet counts are not narrowed by their own selection. A non-empty search is
+// always bound to $1.
+func filterClauses(f *models.ListFilters, search string, omit string) ([]string, []any) {
 	var where []string
 	var args []any
 
-	// Add keyword parameters first (they're already prepared)
-	if hasKeywords {
-		args = append(args, keywordArgs...)
-		// Build WHERE conditions using the same parameters
-		var keywordConditions []string
-		kwParamNum := 1
-		for range f.Keywords {
-			keywordConditions = append(keywordConditions, fmt.Sprintf("(title ILIKE $%d OR description ILIKE $%d)", kwParamNum, kwParamNum+1))
-			kwParamNum += 2
-		}
-		where = append(where, "("+strings.Join(keywordConditions, " OR ")+")")
+	if search != "" {
+		args = append(args, search)
+		where = append(where, "search_vector @@ websearch_to_tsquery('english', $1)")
 	}
 
+	// Drafts are only shown to their owner, never in searches, and deleted
+	// listings to no one
+	where = append(where, "status <> 'DRAFT'", "deleted_at IS NULL")
+
 	// Add other filters (category, status, price)
 	currentParamNum := len(args) + 1
 
-	if f.Category != nil {
+	if f.Category != nil && omit != "category" {
 		where = append(where, fmt.Sprintf("category = $%d", currentParamNum))
 		args = append(args, *f.Category)
 		currentParamNum++
 	}
-	if f.Status != nil {
+	if f.Status != nil && omit != "status" {
 		where = append(where, fmt.Sprintf("status = $%d", currentParamNum))
 		args = append(args, *f.Status)
 		currentParamNum++
 	}
-	if f.MinPrice != nil {
+	if f.MinPrice != nil && omit != "price" {
 		where = append(where, fmt.Sprintf("price >= $%d", currentParamNum))
 		args = append(args, *f.MinPrice)
 		currentParamNum++
 	}
-	if f.MaxPrice != nil {
+	if f.MaxPrice != nil && omit != "price" {
 		where = append(where, fmt.Sprintf("price <= $%d", currentParamNum))
 		args = append(args, *f.MaxPrice)
 		currentParamNum++
 	}
-
-	// Build WHERE clause string
-	whereClause := ""
-	if len(where) > 0 {
-		whereClause = " WHERE " + strings.Join(where, " AND ")
+	if f.Condition != nil {
+		where = append(where, fmt.Sprintf("condition = $%d", currentParamNum))
+		args = append(args, *f.Condition)
+		currentParamNum++
+	}
+	if f.Pickup != nil {
+		where = append(where, fmt.Sprintf("pickup_location ILIKE '%%' || $%d || '%%'", currentParamNum))
+		args = append(args, likeEscaper.Replace(*f.Pickup))
+		currentParamNum++
+	}
+	if len(f.Tags) > 0 {
+		where = append(where, fmt.Sprintf("tags @> $%d::text[]", currentParamNum))
+		args = append(args, f.Tags)
+		currentParamNum++
+	}
+	// A course matches listings tagged with its code and those selling one of
+	// its books, in any edition
+	if f.Course != nil {
+		where = append(where, fmt.Sprintf(`(attributes->>'course_code' = $%[1]d OR attributes->>'isbn' IN (
+			SELECT cb.isbn FROM course_books cb WHERE cb.course_code = $%[1]d
+			UNION
+			SELECT b.isbn FROM book_metadata b
+			JOIN book_metadata q ON q.work_id = b.work_id
+			JOIN course_books cb ON cb.isbn = q.isbn
+			WHERE cb.course_code = $%[1]d))`, currentParamNum))
+		args = append(args, *f.Course)
+		currentParamNum++
+	}
+	// Canonical values (course code, storage) can use the GIN index;
+	// free-text ones like brand compare case-insensitively
+	exact := models.Attributes{}
+	for _, key := range slices.Sorted(maps.Keys(f.Attributes)) {
+		// An ISBN also matches the other editions of the same work
+		if key == models.AttrISBN {
+			where = append(where, fmt.Sprintf(`(attributes->>'isbn' = $%[1]d OR attributes->>'isbn' IN (
+				SELECT b.isbn FROM book_metadata b JOIN book_metadata q ON q.work_id = b.work_id WHERE q.isbn = $%[1]d))`, currentParamNum))
+			args = append(args, f.Attributes[key])
+			currentParamNum++
+			continue
+		}
+		if canonicalAttribute(key) {
+			exact[key] = f.Attributes[key]
+			continue
+		}
+		where = append(where, fmt.Sprintf("lower(attributes->>$%d) = lower($%d)", currentParamNum, currentParamNum+1))
+		args = append(args, key, f.Attributes[key])
+		currentParamNum += 2
 	}
+	if len(exact) > 0 {
+		where = append(where, fmt.Sprintf("attributes @> $%d::jsonb", currentParamNum))
+		args = append(args, exact)
+	}
+	return where, args
+}
+
+// List returns one keyset page of the listings matching f. With a search the
+// page is ordered by relevance first; every ordering ends with id so the
+// cursor position is unique.
+func (s *Store) List(ctx context.Context, f *models.ListFilters) (models.ListingPage, error) {
+	var page models.ListingPage
 
-	// First, execute COUNT query to get total number of matching listings
-	countQuery := "SELECT COUNT(*) FROM listings" + whereClause
-	var totalCount int
-	err := s.P.QueryRow(ctx, countQuery, args...).Scan(&totalCount)
+	search := searchText(f)
+	hasSearch := search != ""
+	cursor, err := decodeCursor(f.Cursor)
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 
-	// Build SELECT clause with keyword score if keywords are present
-	sb := strings.Builder{}
-	var selectFields []string
-	selectFields = append(selectFields, "id", "title", "description", "price", "category", "user_id", "status", "created_at")
-
-	var scoreParts []string
-	paramNum := 1
+	// Build WHERE clause (shared between COUNT and SELECT queries)
+	// The search query is always $1 so the SELECT can reuse it for ranking
+	const rankExpr = "ts_rank_cd(search_vector, websearch_to_tsquery('english', $1))"
+	where, args := filterClauses(f, search, "")
+	currentParamNum := len(args) + 1
 
-	if hasKeywords {
-		// Build score calculation: title matches weighted 2x, description matches weighted 1x
-		for i := 0; i < len(f.Keywords); i++ {
-			// Title match (weighted 2x) - uses paramNum
-			scoreParts = append(scoreParts, fmt.Sprintf("CASE WHEN title ILIKE $%d THEN 2 ELSE 0 END", paramNum))
-			paramNum++
-			// Description match (weighted 1x) - uses paramNum
-			scoreParts = append(scoreParts, fmt.Sprintf("CASE WHEN description ILIKE $%d THEN 1 ELSE 0 END", paramNum))
-			paramNum++
+	// The total is optional: it needs a second scan of every matching row
+	if f.WithCount {
+		countQuery := "SELECT COUNT(*) FROM listings"
+		if len(where) > 0 {
+			countQuery += " WHERE " + strings.Join(where, " AND ")
 		}
-		scoreExpr := "(" + strings.Join(scoreParts, " + ") + ") AS keyword_score"
-		selectFields = append(selectFields, scoreExpr)
+		var totalCount int
+		if err := s.P.QueryRow(ctx, countQuery, args...).Scan(&totalCount); err != nil {
+			return page, err
+		}
+		page.Count = &totalCount
 	}
 
-	sb.WriteString("SELECT " + strings.Join(selectFields, ", ") + " FROM listings")
-	sb.WriteString(whereClause)
-
-	// Build ORDER BY clause
-	// If keywords are present, order by keyword_score DESC first, then by the requested sort
-	// If no keywords, use the requested sort directly
-	var orderBy []string
-	if hasKeywords {
-		// Order by relevance score first (highest first)
-		orderBy = append(orderBy, "keyword_score DESC")
+	// Build the ordering; the cursor carries one value per column
+	var order []keysetColumn
+	if hasSearch {
+		order = append(order, keysetColumn{expr: rankExpr, desc: true})
 	}
-
-	// Then apply the requested sort
 	switch f.Sort {
 	case "price_asc":
-		orderBy = append(orderBy, "price ASC")
+		order = append(order, keysetColumn{expr: "price"})
 	case "price_desc":
-		orderBy = append(orderBy, "price DESC")
+		order = append(order, keysetColumn{expr: "price", desc: true})
 	default:
-		orderBy = append(orderBy, "created_at DESC")
+		order = append(order, keysetColumn{expr: "created_at", desc: true})
+	}
+	order = append(order, keysetColumn{expr: "id", desc: order[len(order)-1].desc})
+
+	if cursor != nil {
+		for i := range order {
+			switch order[i].expr {
+			case rankExpr:
+				if cursor.Rank == nil {
+					return page, fmt.Errorf("invalid cursor")
+				}
+				order[i].value = *cursor.Rank
+			case "price":
+				if cursor.Price == nil {
+					return page, fmt.Errorf("invalid cursor")
+				}
+				order[i].value = *cursor.Price
+			case "created_at":
+				if cursor.CreatedAt == nil {
+					return page, fmt.Errorf("invalid cursor")
+				}
+				order[i].value = *cursor.CreatedAt
+			case "id":
+				order[i].value = cursor.ID
+			}
+		}
+		cond, cargs := keysetCondition(order, currentParamNum)
+		where = append(where, cond)
+		args = append(args, cargs...)
+	}
+
+	// Build SELECT clause with rank and snippet if a search is present
+	sb := strings.Builder{}
+	selectFields := []string{listingColumns}
+	if hasSearch {
+		selectFields = append(selectFields,
+			rankExpr+" AS search_rank",
+			fmt.Sprintf("ts_headline('english', coalesce(description, title), websearch_to_tsquery('english', $1), '%s') AS snippet", searchHeadlineOptions),
+		)
 	}
 
+	sb.WriteString("SELECT " + strings.Join(selectFields, ", ") + " FROM listings")
+	if len(where) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(where, " AND "))
+	}
+
+	orderBy := make([]string, 0, len(order))
+	for _, col := range order {
+		orderBy = append(orderBy, col.orderBy())
+	}
 	sb.WriteString(" ORDER BY " + strings.Join(orderBy, ", "))
 
-	if f.Limit <= 0 || f.Limit > 100 {
-		f.Limit = 20
+	limit := pageLimit(f.Limit, 20)
+	sb.WriteString(fmt.Sprintf(" LIMIT %d", limit+1))
+	// OFFSET is kept for older clients that page by number
+	if cursor == nil && f.Offset > 0 {
+		sb.WriteString(fmt.Sprintf(" OFFSET %d", f.Offset))
 	}
-	sb.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", f.Limit, f.Offset))
 
 	log.Println("List SQL Query: \n", common.FormatQuery(sb.String(), args))
 
 	rows, err := s.P.Query(ctx, sb.String(), args...)
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 	defer rows.Close()
 
-	var out []models.Listing
+	var ranks []float32
 	for rows.Next() {
 		var l models.Listing
-		if hasKeywords {
-			// Scan includes keyword_score, but we don't need to store it
-			var keywordScore int
-			if err := rows.Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt, &keywordScore); err != nil {
-				return nil, 0, err
+		if hasSearch {
+			var rank float32
+			var snippet string
+			if err := rows.Scan(append(listingFields(&l), &rank, &snippet)...); err != nil {
+				return page, err
 			}
+			l.Snippet = &snippet
+			ranks = append(ranks, rank)
 		} else {
-			if err := rows.Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt); err != nil {
-				return nil, 0, err
+			if err := rows.Scan(listingFields(&l)...); err != nil {
+				return page, err
 			}
 		}
-		out = append(out, l)
+		page.Items = append(page.Items, l)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return page, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[limit-1]
+		next := pageCursor{ID: last.ID}
+		if hasSearch {
+			next.Rank = &ranks[limit-1]
+		}
+		if f.Sort == "price_asc" || f.Sort == "price_desc" {
+			next.Price = &last.Price
+		} else {
+			next.CreatedAt = &last.CreatedAt
+		}
+		page.NextCursor = next.encode()
+	}
+	if err := s.attachCovers(ctx, listingRefs(page.Items)); err != nil {
+		return page, err
 	}
-	return out, totalCount, nil
+	return page, nil
 }
 
-func (s *Store) Update(ctx context.Context, id int64, userID string, userRole string, p models.UpdateParams) (models.Listing, error) {
+// Update applies the non-nil fields of p. When ifMatch is set the write only
+// happens while the listing is still at that version; otherwise it fails with
+// "listing version mismatch". Status changes must follow the lifecycle in
+// canTransition. Every change is kept in the edit history, and admin edits of
+// someone else's listing are audited.
+func (s *Store) Update(ctx context.Context, id int64, userID string, userRole string, ifMatch *int64, reason string, p models.UpdateParams) (models.Listing, error) {
 	// Build dynamic SET clause with positional parameters
 	var sets []string
 	var args []any
@@ -266,132 +509,369 @@ func (s *Store) Update(ctx context.Context, id int64, userID string, userRole st
 		args = append(args, *p.Category)
 		i++
 	}
+	statusArg := -1
 	if p.Status != nil {
 		sets = append(sets, fmt.Sprintf("status=$%d", i))
 		args = append(args, *p.Status)
+		statusArg = len(args) - 1
+		i++
+	}
+	if p.Condition != nil {
+		sets = append(sets, fmt.Sprintf("condition=$%d", i))
+		args = append(args, *p.Condition)
+		i++
+	}
+	if p.PickupLocation != nil {
+		// A blank location clears it
+		sets = append(sets, fmt.Sprintf("pickup_location=NULLIF(TRIM($%d), '')", i))
+		args = append(args, *p.PickupLocation)
+		i++
+	}
+	if p.Tags != nil {
+		sets = append(sets, fmt.Sprintf("tags=$%d", i))
+		args = append(args, *p.Tags)
 		i++
 	}
 
-	if len(sets) == 0 {
-		return s.Get(ctx, id)
+	if p.PublishAt != nil {
+		sets = append(sets, fmt.Sprintf("publish_at=$%d", i))
+		args = append(args, *p.PublishAt)
+		i++
 	}
 
-	// WHERE placeholders use the next indexes
-	whereIDIdx := i
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	// Admin can update any listing, regular user can only update their own
-	var q string
-	if userRole == string(httplib.ADMIN) {
-		q = fmt.Sprintf(`
-			UPDATE listings
-			SET %s
-			WHERE id=$%d
-			RETURNING id, title, description, price, category, user_id, status, created_at
-		`, strings.Join(sets, ","), whereIDIdx)
-		args = append(args, id)
-	} else {
-		whereUserIdx := i + 1
-		q = fmt.Sprintf(`
-			UPDATE listings
-			SET %s
-			WHERE id=$%d AND user_id=$%d
-			RETURNING id, title, description, price, category, user_id, status, created_at
-		`, strings.Join(sets, ","), whereIDIdx, whereUserIdx)
-		args = append(args, id, userID)
+	// An empty update still only shows the listing to those who may edit it
+	before, err := lockListing(ctx, tx, id, userID, userRole, ifMatch)
+	if err != nil {
+		return models.Listing{}, err
+	}
+	if len(sets) == 0 && p.Attributes == nil {
+		return before, nil
+	}
+	sets = append(sets, "version=version+1", "updated_at=NOW()")
+	// Attributes are checked against the category the listing ends up in; a
+	// new category drops the attributes of the old one unless new ones are sent
+	category := before.Category
+	if p.Category != nil {
+		category = *p.Category
+	}
+	if p.Attributes != nil {
+		attrs, fe := normalizeAttributes(category, *p.Attributes)
+		if fe != nil {
+			return models.Listing{}, &fieldError{*fe}
+		}
+		sets = append(sets, fmt.Sprintf("attributes=$%d", i))
+		args = append(args, attrs)
+		i++
+	} else if category != before.Category {
+		sets = append(sets, "attributes='{}'::jsonb")
+	}
+	status := before.Status
+	if p.Status != nil {
+		status = *p.Status
+	}
+	if p.PublishAt != nil && status != models.StDraft {
+		return models.Listing{}, &fieldError{httplib.FieldError{Field: "publish_at", Code: "invalid",
+			Message: "only draft listings can be scheduled"}}
+	}
+	if p.Status != nil {
+		if *p.Status == models.StSold && before.Status != models.StSold {
+			return models.Listing{}, errSaleRequired
+		}
+		if !canTransition(before.Status, *p.Status, userRole == string(httplib.ADMIN)) {
+			return models.Listing{}, transitionError(before.Status, *p.Status)
+		}
+		switch {
+		case *p.Status == models.StReported && before.Status != models.StReported:
+			sets = append(sets, "reported_from=status")
+		case before.Status == models.StReported && *p.Status != models.StReported:
+			// Releasing a listing reported while its sale was under way
+			// puts it back to PENDING, keeping the buyer reservation
+			var from *models.Status
+			if err := tx.QueryRow(ctx, `SELECT reported_from FROM listings WHERE id=$1`, id).Scan(&from); err != nil {
+				return models.Listing{}, fmt.Errorf("failed to load listing: %w", err)
+			}
+			if *p.Status == models.StAvailable && from != nil && *from == models.StPending && before.ReservedFor != nil {
+				pending := models.StPending
+				p.Status = &pending
+				args[statusArg] = pending
+			}
+			sets = append(sets, "reported_from=NULL")
+		}
+		if before.Status == models.StDraft && *p.Status != models.StDraft {
+			sets = append(sets, publishSets)
+		}
+		// The buyer reservation only holds while the sale is under way
+		if *p.Status != models.StPending && *p.Status != models.StSold {
+			sets = append(sets, "reserved_for=NULL")
+		}
+		// Relisting starts a fresh expiry period
+		if !isActive(before.Status) && isActive(*p.Status) {
+			if err := checkRelist(ctx, tx, id); err != nil {
+				return models.Listing{}, err
+			}
+			sets = append(sets, fmt.Sprintf("expires_at=NOW() + make_interval(days => $%d)", i), "expiry_reminded_at=NULL")
+			args = append(args, s.expiryDays())
+			i++
+		}
 	}
 
+	q := fmt.Sprintf(`
+		UPDATE listings
+		SET %s
+		WHERE id=$%d
+		RETURNING %s
+	`, strings.Join(sets, ","), i, listingColumns)
+	args = append(args, id)
+
 	var l models.Listing
-	err := s.P.QueryRow(ctx, q, args...).
-		Scan(&l.ID, &l.Title, &l.Description, &l.Price, &l.Category, &l.UserID, &l.Status, &l.CreatedAt)
-	return l, err
-}
+	if err := tx.QueryRow(ctx, q, args...).Scan(listingFields(&l)...); err != nil {
+		return models.Listing{}, err
+	}
 
-func (s *Store) Archive(ctx context.Context, id int64, userid string, userRole string) error {
-	var args []any
-	args = append(args, id)
+	// Reworded listings are checked for reposts again; publishing or
+	// relisting one also checks it against other sellers' listings
+	reworded := p.Title != nil || p.Description != nil
+	if reworded || p.Status != nil {
+		if err := fingerprintListing(ctx, tx, id, reworded); err != nil {
+			return models.Listing{}, err
+		}
+	}
 
-	// Admin can archive any listing, regular user can only archive their own
-	var query string
-	if userRole == string(httplib.ADMIN) {
-		query = `UPDATE listings SET status='ARCHIVED' WHERE id=$1`
-	} else {
-		query = `UPDATE listings SET status='ARCHIVED' WHERE id=$1 AND user_id=$2`
-		args = append(args, userid)
+	if l.Price != before.Price {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO listing_price_history (listing_id, old_price, new_price, changed_by)
+			VALUES ($1, $2, $3, $4::uuid)
+		`, id, before.Price, l.Price, userID)
+		if err != nil {
+			return models.Listing{}, fmt.Errorf("failed to record price change: %w", err)
+		}
 	}
 
-	_, err := s.P.Exec(ctx, query, args...)
-	return err
-}
+	if err := recordRevision(ctx, tx, before, l, userID, userRole); err != nil {
+		return models.Listing{}, err
+	}
 
-func (s *Store) Delete(ctx context.Context, id int64, userid string, userRole string) error {
-	var args []any
-	args = append(args, id)
+	if isAdminOverride(before, userID, userRole) {
+		err := recordAudit(ctx, tx, auditEvent{
+			ActorUserID: userID, ActorRole: userRole, Action: auditListingUpdate,
+			TargetType: "listing", TargetID: id, Reason: reason, Before: before, After: l,
+		})
+		if err != nil {
+			return models.Listing{}, err
+		}
+	}
 
-	// Admin can delete any listing, regular user can only delete their own
-	var query string
-	if userRole == string(httplib.ADMIN) {
-		query = `DELETE FROM listings WHERE id=$1`
-	} else {
-		query = `DELETE FROM listings WHERE id=$1 AND user_id=$2`
-		args = append(args, userid)
+	if err := tx.Commit(ctx); err != nil {
+		return models.Listing{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return l, nil
+}
 
-	log.Println("Testing Delete Query: ", common.FormatQuery(query, args))
-	_, err := s.P.Exec(ctx, query, args...)
-	log.Println("Finished Delete Query: ", err)
-	return err
+// lockListing loads a listing for a write inside tx, enforcing ownership
+// (admins may write any listing) and the If-Match version when set.
+func lockListing(ctx context.Context, tx pgx.Tx, id int64, userID string, userRole string, ifMatch *int64) (models.Listing, error) {
+	var l models.Listing
+	err := tx.QueryRow(ctx, `SELECT `+listingColumns+` FROM listings WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(listingFields(&l)...)
+	if err == pgx.ErrNoRows {
+		return l, fmt.Errorf("listing not found")
+	}
+	if err != nil {
+		return l, fmt.Errorf("failed to load listing: %w", err)
+	}
+	if userRole != string(httplib.ADMIN) && l.UserID.String() != userID {
+		return l, fmt.Errorf("listing not found")
+	}
+	if ifMatch != nil && l.Version != *ifMatch {
+		return l, fmt.Errorf("listing version mismatch")
+	}
+	return l, nil
 }
 
-func (s *Store) AddMediaUrls(ctx context.Context, listingID int64, userID string, urls []string) error {
-	// First verify the listing exists and belongs to the user
-	var ownerID string
-	err := s.P.QueryRow(ctx, `SELECT user_id::text FROM listings WHERE id=$1`, listingID).Scan(&ownerID)
+// Archive takes a listing off the market as ARCHIVED, if its status allows
+// that (see canTransition). Admins archiving someone else's listing must
+// give a reason, which is recorded in the audit log.
+func (s *Store) Archive(ctx context.Context, id int64, userid string, userRole string, reason string) error {
+	tx, err := s.P.Begin(ctx)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return fmt.Errorf("listing not found")
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Admin can archive any listing, regular user can only archive their own
+	before, err := lockListing(ctx, tx, id, userid, userRole, nil)
+	if err != nil {
+		return err
+	}
+	// Sellers cannot archive a listing under review to relist it later
+	if !canTransition(before.Status, models.StArchived, userRole == string(httplib.ADMIN)) {
+		return transitionError(before.Status, models.StArchived)
+	}
+	override := isAdminOverride(before, userid, userRole)
+	if override && reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+
+	var after models.Listing
+	err = tx.QueryRow(ctx, `UPDATE listings SET status='ARCHIVED', reserved_for=NULL, version=version+1, updated_at=NOW() WHERE id=$1 RETURNING `+listingColumns, id).
+		Scan(listingFields(&after)...)
+	if err != nil {
+		return err
+	}
+
+	if override {
+		err := recordAudit(ctx, tx, auditEvent{
+			ActorUserID: userid, ActorRole: userRole, Action: auditListingArchive,
+			TargetType: "listing", TargetID: id, Reason: reason, Before: before, After: after,
+		})
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to verify listing ownership: %w", err)
 	}
+	return tx.Commit(ctx)
+}
 
-	if ownerID != userID {
-		return fmt.Errorf("listing does not belong to user")
+// Delete soft-deletes a listing: it disappears from every read but can be
+// restored until the retention window passes and RunPurge removes it.
+// Admins deleting someone else's listing must give a reason, which is
+// recorded in the audit log.
+func (s *Store) Delete(ctx context.Context, id int64, userid string, userRole string, reason string) error {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Insert all URLs into listing_media table
-	// Using a batch insert with VALUES clause for efficiency
-	if len(urls) == 0 {
-		return fmt.Errorf("no URLs provided")
+	// Admin can delete any listing, regular user can only delete their own
+	before, err := lockListing(ctx, tx, id, userid, userRole, nil)
+	if err != nil {
+		return err
+	}
+	override := isAdminOverride(before, userid, userRole)
+	if override && reason == "" {
+		return fmt.Errorf("reason is required")
 	}
 
-	// Build VALUES clause with positional parameters
-	var valueParts []string
-	var args []any
-	argIndex := 1
+	_, err = tx.Exec(ctx, `UPDATE listings SET deleted_at=NOW(), deleted_by=$2::uuid, version=version+1, updated_at=NOW() WHERE id=$1`, id, userid)
+	log.Println("Finished Delete Query: ", err)
+	if err != nil {
+		return err
+	}
 
-	for _, url := range urls {
-		if url == "" {
+	if override {
+		err := recordAudit(ctx, tx, auditEvent{
+			ActorUserID: userid, ActorRole: userRole, Action: auditListingDelete,
+			TargetType: "listing", TargetID: id, Reason: reason, Before: before,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// AddMediaUrls appends media to the end of a listing's gallery. The first
+// media of a listing becomes its cover.
+func (s *Store) AddMediaUrls(ctx context.Context, listingID int64, userID string, ifMatch *int64, media []models.MediaInput) (int64, error) {
+	if len(media) == 0 {
+		return 0, fmt.Errorf("no URLs provided")
+	}
+	var valid []models.MediaInput
+	for i, m := range media {
+		if m.URL == "" {
 			continue // Skip empty URLs
 		}
-		valueParts = append(valueParts, fmt.Sprintf("($%d, $%d)", argIndex, argIndex+1))
-		args = append(args, listingID, url)
-		argIndex += 2
+		if fe := validateMedia(fmt.Sprintf("media[%d]", i), m); fe != nil {
+			return 0, &fieldError{*fe}
+		}
+		valid = append(valid, m)
+	}
+	if len(valid) == 0 {
+		return 0, fmt.Errorf("no valid URLs provided")
 	}
 
-	if len(valueParts) == 0 {
-		return fmt.Errorf("no valid URLs provided")
+	// No role, so only the owner can add media
+	return s.withVersionBump(ctx, listingID, userID, "", ifMatch, func(tx pgx.Tx, _ string) error {
+		// The listing is locked, so positions cannot race
+		var next int
+		err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(position) + 1, 0) FROM listing_media WHERE listing_id=$1`, listingID).Scan(&next)
+		if err != nil {
+			return fmt.Errorf("failed to load media positions: %w", err)
+		}
+
+		// Build VALUES clause with positional parameters
+		var valueParts []string
+		args := []any{listingID}
+		for i, m := range valid {
+			n := len(args)
+			valueParts = append(valueParts, fmt.Sprintf("($1, $%d, $%d, NULLIF(TRIM($%d), ''), NULLIF(TRIM($%d), ''), $%d, $%d, $%d, $%d)",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+			args = append(args, m.URL, next+i, m.AltText, m.Caption, m.MimeType, m.Width, m.Height, m.SizeBytes)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO listing_media (listing_id, media_url, position, alt_text, caption, mime_type, width, height, size_bytes)
+			VALUES `+strings.Join(valueParts, ", "), args...)
+		if err != nil {
+			return fmt.Errorf("failed to insert media URLs: %w", err)
+		}
+		if err := ensureCover(ctx, tx, listingID); err != nil {
+			return err
+		}
+		// Media are part of the fingerprint a seller's reposts are found by
+		return fingerprintListing(ctx, tx, listingID, false)
+	})
+}
+
+// withVersionBump runs fn in a transaction that also bumps the listing
+// version, so media changes invalidate the listing's ETag. The listing is
+// locked and must not be deleted and must belong to userID unless userRole
+// is admin; fn is given its owner. When ifMatch is set and stale nothing is
+// written and "listing version mismatch" is returned.
+func (s *Store) withVersionBump(ctx context.Context, listingID int64, userID string, userRole string, ifMatch *int64, fn func(tx pgx.Tx, ownerID string) error) (int64, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	query := fmt.Sprintf(`
-		INSERT INTO listing_media (listing_id, media_url)
-		VALUES %s
-	`, strings.Join(valueParts, ", "))
+	var ownerID string
+	var version int64
+	err = tx.QueryRow(ctx, `SELECT user_id::text, version FROM listings WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, listingID).
+		Scan(&ownerID, &version)
+	if err == pgx.ErrNoRows {
+		return 0, fmt.Errorf("listing not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to verify listing ownership: %w", err)
+	}
+	if userRole != string(httplib.ADMIN) && ownerID != userID {
+		return 0, fmt.Errorf("listing does not belong to user")
+	}
+	if ifMatch != nil && version != *ifMatch {
+		return 0, fmt.Errorf("listing version mismatch")
+	}
 
-	_, err = s.P.Exec(ctx, query, args...)
+	err = tx.QueryRow(ctx, `
+		UPDATE listings SET version=version+1, updated_at=NOW()
+		WHERE id=$1 AND deleted_at IS NULL
+		RETURNING version
+	`, listingID).Scan(&version)
 	if err != nil {
-		return fmt.Errorf("failed to insert media URLs: %w", err)
+		return 0, fmt.Errorf("failed to bump listing version: %w", err)
 	}
 
-	return nil
+	if err := fn(tx, ownerID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return version, nil
 }
 
 // GetFlaggedListings retrieves all flagged listings with their associated listing details
@@ -410,16 +890,9 @@ func (s *Store) GetFlaggedListings(ctx context.Context, status *string) ([]model
 			fl.created_at,
 			fl.updated_at,
 			fl.resolved_at,
-			l.id,
-			l.title,
-			l.description,
-			l.price,
-			l.category,
-			l.user_id,
-			l.status,
-			l.created_at
+			` + listingColumnsAs("l") + `
 		FROM flagged_listings fl
-		JOIN listings l ON fl.listing_id = l.id
+		JOIN listings l ON fl.listing_id = l.id AND l.deleted_at IS NULL
 	`
 
 	var args []any
@@ -443,7 +916,7 @@ func (s *Store) GetFlaggedListings(ctx context.Context, status *string) ([]model
 		var fl models.FlaggedListing
 		var listing models.Listing
 
-		err := rows.Scan(
+		err := rows.Scan(append([]any{
 			&fl.FlagID,
 			&fl.ListingID,
 			&fl.ReporterUserID,
@@ -455,15 +928,7 @@ func (s *Store) GetFlaggedListings(ctx context.Context, status *string) ([]model
 			&fl.FlagCreatedAt,
 			&fl.FlagUpdatedAt,
 			&fl.FlagResolvedAt,
-			&listing.ID,
-			&listing.Title,
-			&listing.Description,
-			&listing.Price,
-			&listing.Category,
-			&listing.UserID,
-			&listing.Status,
-			&listing.CreatedAt,
-		)
+		}, listingFields(&listing)...)...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan flagged listing: %w", err)
 		}
@@ -475,13 +940,19 @@ func (s *Store) GetFlaggedListings(ctx context.Context, status *string) ([]model
 	return out, rows.Err()
 }
 
-// UpdateFlagListing updates a flagged listing
-func (s *Store) UpdateFlagListing(ctx context.Context, flagID int64, userID string, p models.UpdateFlagParams) (models.FlaggedListing, error) {
+// UpdateFlagListing updates a flagged listing, records the admin as its
+// reviewer and writes an audit event with the flag before and after.
+func (s *Store) UpdateFlagListing(ctx context.Context, flagID int64, userID string, userRole string, reason string, p models.UpdateFlagParams) (models.FlaggedListing, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.FlaggedListing{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	// First verify the flag exists
 	var flag models.FlaggedListing
-	err := s.P.QueryRow(ctx, `SELECT id,listing_id,reporter_user_id,reason,details,status,reviewer_user_id,resolution_notes,created_at,updated_at,resolved_at FROM flagged_listings WHERE id=$1`, flagID).
-		Scan(&flag.FlagID, &flag.ListingID, &flag.ReporterUserID, &flag.Reason, &flag.Details, &flag.Status, &flag.ReviewerUserID, &flag.ResolutionNotes, &flag.FlagCreatedAt, &flag.FlagUpdatedAt, &flag.FlagResolvedAt)
+	err = tx.QueryRow(ctx, `SELECT `+flagColumns+` FROM flagged_listings WHERE id=$1 FOR UPDATE`, flagID).
+		Scan(flagFields(&flag)...)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return models.FlaggedListing{}, fmt.Errorf("flag not found")
@@ -492,39 +963,48 @@ func (s *Store) UpdateFlagListing(ctx context.Context, flagID int64, userID stri
 	// Update the flag in flagged_listings table
 	const updateFlagQuery = `
 		UPDATE flagged_listings
-		SET status=$1, resolution_notes=$2, updated_at=now()
+		SET status=$1, resolution_notes=$2, reviewer_user_id=$4::uuid, updated_at=now()
 		WHERE id=$3
-		RETURNING id, listing_id, reporter_user_id, reason, details, status, reviewer_user_id, resolution_notes, created_at, updated_at, resolved_at
-	`
+		RETURNING ` + flagColumns
 
 	var updatedFlag models.FlaggedListing
-	err = s.P.QueryRow(ctx, updateFlagQuery, p.Status, p.ResolutionNotes, flagID).
-		Scan(
-			&updatedFlag.FlagID,
-			&updatedFlag.ListingID,
-			&updatedFlag.ReporterUserID,
-			&updatedFlag.Reason,
-			&updatedFlag.Details,
-			&updatedFlag.Status,
-			&updatedFlag.ReviewerUserID,
-			&updatedFlag.ResolutionNotes,
-			&updatedFlag.FlagCreatedAt,
-			&updatedFlag.FlagUpdatedAt,
-			&updatedFlag.FlagResolvedAt,
-		)
+	err = tx.QueryRow(ctx, updateFlagQuery, p.Status, p.ResolutionNotes, flagID, userID).
+		Scan(flagFields(&updatedFlag)...)
 	if err != nil {
 		return models.FlaggedListing{}, fmt.Errorf("failed to update flag: %w", err)
 	}
 
+	if reason == "" && p.ResolutionNotes != nil {
+		reason = *p.ResolutionNotes
+	}
+	err = recordAudit(ctx, tx, auditEvent{
+		ActorUserID: userID, ActorRole: userRole, Action: auditFlagUpdate,
+		TargetType: "flag", TargetID: flagID, Reason: reason, Before: flag, After: updatedFlag,
+	})
+	if err != nil {
+		return models.FlaggedListing{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.FlaggedListing{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return updatedFlag, nil
 }
 
+// flagColumns is the column list selected for a flag; scan it with flagFields.
+const flagColumns = "id, listing_id, reporter_user_id, reason, details, status, reviewer_user_id, resolution_notes, created_at, updated_at, resolved_at"
+
+// flagFields returns the scan destinations matching flagColumns.
+func flagFields(fl *models.FlaggedListing) []any {
+	return []any{&fl.FlagID, &fl.ListingID, &fl.ReporterUserID, &fl.Reason, &fl.Details, &fl.Status, &fl.ReviewerUserID, &fl.ResolutionNotes, &fl.FlagCreatedAt, &fl.FlagUpdatedAt, &fl.FlagResolvedAt}
+}
+
 // FlagListing creates a new flag for a listing
 func (s *Store) FlagListing(ctx context.Context, listingID int64, reporterUserID string, p models.CreateFlagParams) (models.FlaggedListing, error) {
 	// First verify the listing exists
 	var listing models.Listing
-	err := s.P.QueryRow(ctx, `SELECT id,title,description,price,category,user_id,status,created_at FROM listings WHERE id=$1`, listingID).
-		Scan(&listing.ID, &listing.Title, &listing.Description, &listing.Price, &listing.Category, &listing.UserID, &listing.Status, &listing.CreatedAt)
+	err := s.P.QueryRow(ctx, `SELECT `+listingColumns+` FROM listings WHERE id=$1 AND deleted_at IS NULL`, listingID).
+		Scan(listingFields(&listing)...)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return models.FlaggedListing{}, fmt.Errorf("listing not found")
@@ -537,6 +1017,9 @@ func (s *Store) FlagListing(ctx context.Context, listingID int64, reporterUserID
 	if err != nil {
 		return models.FlaggedListing{}, fmt.Errorf("invalid reporter user ID: %w", err)
 	}
+	if listing.Status == models.StDraft && listing.UserID != reporterUUID {
+		return models.FlaggedListing{}, fmt.Errorf("listing not found")
+	}
 
 	// Check if user has already flagged this listing
 	var existingFlagID int64
@@ -578,10 +1061,17 @@ func (s *Store) FlagListing(ctx context.Context, listingID int64, reporterUserID
 		return models.FlaggedListing{}, fmt.Errorf("failed to create flag: %w", err)
 	}
 
-	// Update listing status to REPORTED when flagged
-	// Always update status to REPORTED regardless of current status
-	_, err = s.P.Exec(ctx, `UPDATE listings SET status='REPORTED' WHERE id=$1`, listingID)
-	if err != nil {
+	// Take a listing on the market off it as REPORTED until a moderator
+	// releases it, remembering its status; sold, archived and draft listings
+	// keep theirs while the flag is reviewed
+	err = s.P.QueryRow(ctx, `
+		UPDATE listings SET status='REPORTED', reported_from=status, version=version+1, updated_at=NOW()
+		WHERE id=$1 AND status IN ('AVAILABLE', 'PENDING')
+		RETURNING version, updated_at`, listingID).
+		Scan(&listing.Version, &listing.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		log.Printf("Listing %d is %s; leaving its status as is", listingID, listing.Status)
+	} else if err != nil {
 		log.Printf("Warning: Failed to update listing status to REPORTED: %v", err)
 		// Don't fail the flag creation if status update fails
 	} else {
@@ -596,25 +1086,44 @@ func (s *Store) FlagListing(ctx context.Context, listingID int64, reporterUserID
 	return fl, nil
 }
 
-// DeleteFlagListing deletes a flagged listing (admin only)
-func (s *Store) DeleteFlagListing(ctx context.Context, flagID int64) error {
-	// First verify the flag exists
-	var exists bool
-	err := s.P.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM flagged_listings WHERE id=$1)`, flagID).Scan(&exists)
+// DeleteFlagListing deletes a flagged listing (admin only). A reason is
+// required and recorded in the audit log with the deleted flag.
+func (s *Store) DeleteFlagListing(ctx context.Context, flagID int64, userID string, userRole string, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+
+	tx, err := s.P.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to verify flag: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if !exists {
+	defer tx.Rollback(ctx)
+
+	// First verify the flag exists
+	var flag models.FlaggedListing
+	err = tx.QueryRow(ctx, `SELECT `+flagColumns+` FROM flagged_listings WHERE id=$1 FOR UPDATE`, flagID).
+		Scan(flagFields(&flag)...)
+	if err == pgx.ErrNoRows {
 		return fmt.Errorf("flag not found")
 	}
+	if err != nil {
+		return fmt.Errorf("failed to verify flag: %w", err)
+	}
 
 	// Delete the flag
-	_, err = s.P.Exec(ctx, `DELETE FROM flagged_listings WHERE id=$1`, flagID)
+	_, err = tx.Exec(ctx, `DELETE FROM flagged_listings WHERE id=$1`, flagID)
 	if err != nil {
 		return fmt.Errorf("failed to delete flag: %w", err)
 	}
 
-	return nil
+	err = recordAudit(ctx, tx, auditEvent{
+		ActorUserID: userID, ActorRole: userRole, Action: auditFlagDelete,
+		TargetType: "flag", TargetID: flagID, Reason: reason, Before: flag,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // HasUserFlaggedListing checks if a user has already flagged a specific listing
@@ -635,11 +1144,20 @@ func (s *Store) HasUserFlaggedListing(ctx context.Context, listingID int64, user
 	return true, nil
 }
 
-// GetMediaUrls retrieves all media URLs for a listing
-func (s *Store) GetMediaUrls(ctx context.Context, listingID int64) ([]models.ListingMedia, error) {
-	const q = `SELECT id, listing_id, media_url, created_at FROM listing_media WHERE listing_id = $1 ORDER BY created_at ASC`
-	
-	rows, err := s.P.Query(ctx, q, listingID)
+// GetMediaUrls retrieves all media of a listing in gallery order. The media
+// of a draft are only returned to its owner, viewerID.
+func (s *Store) GetMediaUrls(ctx context.Context, listingID int64, viewerID string) ([]models.ListingMedia, error) {
+	const q = `
+		SELECT m.id, m.listing_id, m.media_url, m.position, m.is_cover, m.alt_text, m.caption,
+			m.mime_type, m.width, m.height, m.size_bytes, m.created_at
+		FROM listing_media m
+		JOIN listings l ON l.id = m.listing_id AND l.deleted_at IS NULL
+			AND (l.status <> 'DRAFT' OR l.user_id = NULLIF($2, '')::uuid)
+		WHERE m.listing_id = $1
+		ORDER BY m.position, m.id
+	`
+
+	rows, err := s.P.Query(ctx, q, listingID, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query media URLs: %w", err)
 	}
@@ -648,7 +1166,9 @@ func (s *Store) GetMediaUrls(ctx context.Context, listingID int64) ([]models.Lis
 	var media []models.ListingMedia
 	for rows.Next() {
 		var m models.ListingMedia
-		if err := rows.Scan(&m.ID, &m.ListingID, &m.MediaURL, &m.CreatedAt); err != nil {
+		err := rows.Scan(&m.ID, &m.ListingID, &m.MediaURL, &m.Position, &m.IsCover, &m.AltText, &m.Caption,
+			&m.MimeType, &m.Width, &m.Height, &m.SizeBytes, &m.CreatedAt)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan media URL: %w", err)
 		}
 		media = append(media, m)
@@ -661,82 +1181,124 @@ func (s *Store) GetMediaUrls(ctx context.Context, listingID int64) ([]models.Lis
 	return media, nil
 }
 
-// UpdateMediaUrl updates a media URL by ID
-func (s *Store) UpdateMediaUrl(ctx context.Context, mediaID int64, listingID int64, userID string, userRole string, newURL string) error {
-	// First verify the listing exists and belongs to the user (or user is admin)
-	var ownerID string
-	err := s.P.QueryRow(ctx, `SELECT user_id::text FROM listings WHERE id=$1`, listingID).Scan(&ownerID)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return fmt.Errorf("listing not found")
+// UpdateMediaUrl replaces the file of a media item by ID, clearing the file
+// details recorded for the old one, and sets its alt text and caption.
+// Admins editing someone else's media must give a reason, which is recorded
+// in the audit log.
+func (s *Store) UpdateMediaUrl(ctx context.Context, mediaID int64, listingID int64, userID string, userRole string, ifMatch *int64, reason string, p models.UpdateMediaParams) (int64, error) {
+	if fe := validateMedia("", models.MediaInput{AltText: p.AltText, Caption: p.Caption}); fe != nil {
+		return 0, &fieldError{*fe}
+	}
+
+	// The listing must belong to the user unless they are an admin
+	return s.withVersionBump(ctx, listingID, userID, userRole, ifMatch, func(tx pgx.Tx, ownerID string) error {
+		// Admins editing media of someone else's listing must say why
+		override := userRole == string(httplib.ADMIN) && ownerID != userID
+		if override && reason == "" {
+			return fmt.Errorf("reason is required")
 		}
-		return fmt.Errorf("failed to verify listing: %w", err)
-	}
 
-	// Check ownership unless admin
-	if userRole != string(httplib.ADMIN) && ownerID != userID {
-		return fmt.Errorf("listing does not belong to user")
-	}
-
-	// Verify the media belongs to this listing
-	var mediaListingID int64
-	err = s.P.QueryRow(ctx, `SELECT listing_id FROM listing_media WHERE id=$1`, mediaID).Scan(&mediaListingID)
-	if err != nil {
+		var mediaListingID int64
+		var before models.ListingMedia
+		err := tx.QueryRow(ctx, `SELECT listing_id, media_url, alt_text, caption FROM listing_media WHERE id=$1 FOR UPDATE`, mediaID).
+			Scan(&mediaListingID, &before.MediaURL, &before.AltText, &before.Caption)
 		if err == pgx.ErrNoRows {
 			return fmt.Errorf("media not found")
 		}
-		return fmt.Errorf("failed to verify media: %w", err)
-	}
+		if err != nil {
+			return fmt.Errorf("failed to verify media: %w", err)
+		}
+		if mediaListingID != listingID {
+			return fmt.Errorf("media does not belong to this listing")
+		}
 
-	if mediaListingID != listingID {
-		return fmt.Errorf("media does not belong to this listing")
-	}
+		var after models.ListingMedia
+		err = tx.QueryRow(ctx, `
+			UPDATE listing_media SET
+				media_url = COALESCE(NULLIF($1, ''), media_url),
+				alt_text = CASE WHEN $2::text IS NULL THEN alt_text ELSE NULLIF(TRIM($2), '') END,
+				caption = CASE WHEN $3::text IS NULL THEN caption ELSE NULLIF(TRIM($3), '') END,
+				mime_type = CASE WHEN $1 IN ('', media_url) THEN mime_type END,
+				width = CASE WHEN $1 IN ('', media_url) THEN width END,
+				height = CASE WHEN $1 IN ('', media_url) THEN height END,
+				size_bytes = CASE WHEN $1 IN ('', media_url) THEN size_bytes END
+			WHERE id=$4 AND listing_id=$5
+			RETURNING media_url, alt_text, caption
+		`, p.NewURL, p.AltText, p.Caption, mediaID, listingID).Scan(&after.MediaURL, &after.AltText, &after.Caption)
+		if err != nil {
+			return fmt.Errorf("failed to update media URL: %w", err)
+		}
 
-	// Update the media URL
-	_, err = s.P.Exec(ctx, `UPDATE listing_media SET media_url=$1 WHERE id=$2 AND listing_id=$3`, newURL, mediaID, listingID)
-	if err != nil {
-		return fmt.Errorf("failed to update media URL: %w", err)
-	}
+		if override {
+			return recordAudit(ctx, tx, auditEvent{
+				ActorUserID: userID, ActorRole: userRole, Action: auditMediaUpdate,
+				TargetType: "listing_media", TargetID: mediaID, Reason: reason,
+				Before: map[string]any{"listing_id": listingID, "media_url": before.MediaURL, "alt_text": before.AltText, "caption": before.Caption},
+				After:  map[string]any{"listing_id": listingID, "media_url": after.MediaURL, "alt_text": after.AltText, "caption": after.Caption},
+			})
+		}
+		return nil
+	})
+}
 
-	return nil
+// DeleteMediaUrl deletes every media item of a listing with the given URL
+func (s *Store) DeleteMediaUrl(ctx context.Context, listingID int64, userID string, userRole string, ifMatch *int64, reason string, mediaURL string) (int64, error) {
+	return s.deleteMedia(ctx, listingID, userID, userRole, ifMatch, reason, "media_url", mediaURL)
 }
 
-// DeleteMediaUrl deletes a media URL by URL string
-func (s *Store) DeleteMediaUrl(ctx context.Context, listingID int64, userID string, userRole string, mediaURL string) error {
-	// First verify the listing exists and belongs to the user (or user is admin)
-	var ownerID string
-	err := s.P.QueryRow(ctx, `SELECT user_id::text FROM listings WHERE id=$1`, listingID).Scan(&ownerID)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return fmt.Errorf("listing not found")
-		}
-		return fmt.Errorf("failed to verify listing: %w", err)
-	}
+// DeleteMedia deletes one media item of a listing by ID
+func (s *Store) DeleteMedia(ctx context.Context, listingID int64, mediaID int64, userID string, userRole string, ifMatch *int64, reason string) (int64, error) {
+	return s.deleteMedia(ctx, listingID, userID, userRole, ifMatch, reason, "id", mediaID)
+}
 
-	// Check ownership unless admin
-	if userRole != string(httplib.ADMIN) && ownerID != userID {
-		return fmt.Errorf("listing does not belong to user")
-	}
+// deleteMedia deletes the media of a listing whose column matches value,
+// closing the gap in the gallery order and picking a new cover if needed.
+func (s *Store) deleteMedia(ctx context.Context, listingID int64, userID string, userRole string, ifMatch *int64, reason string, column string, value any) (int64, error) {
+	// The listing must belong to the user unless they are an admin
+	return s.withVersionBump(ctx, listingID, userID, userRole, ifMatch, func(tx pgx.Tx, ownerID string) error {
+		// Admins removing media from someone else's listing must say why
+		override := userRole == string(httplib.ADMIN) && ownerID != userID
+		if override && reason == "" {
+			return fmt.Errorf("reason is required")
+		}
 
-	// Delete the media URL
-	result, err := s.P.Exec(ctx, `DELETE FROM listing_media WHERE listing_id=$1 AND media_url=$2`, listingID, mediaURL)
-	if err != nil {
-		return fmt.Errorf("failed to delete media URL: %w", err)
-	}
+		rows, err := tx.Query(ctx, `DELETE FROM listing_media WHERE listing_id=$1 AND `+column+`=$2 RETURNING media_url`, listingID, value)
+		if err != nil {
+			return fmt.Errorf("failed to delete media URL: %w", err)
+		}
+		deleted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("failed to delete media URL: %w", err)
+		}
 
-	// Check if any rows were deleted
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("media URL not found")
-	}
+		// Check if any rows were deleted
+		if len(deleted) == 0 {
+			if column == "id" {
+				return fmt.Errorf("media not found")
+			}
+			return fmt.Errorf("media URL not found")
+		}
+		if err := compactMedia(ctx, tx, listingID); err != nil {
+			return err
+		}
 
-	return nil
+		if override {
+			return recordAudit(ctx, tx, auditEvent{
+				ActorUserID: userID, ActorRole: userRole, Action: auditMediaDelete,
+				TargetType: "listing", TargetID: listingID, Reason: reason,
+				Before: map[string]any{"listing_id": listingID, "media_url": deleted[0]},
+			})
+		}
+		return nil
+	})
 }
 
 // SaveListing saves a listing for a user
 func (s *Store) SaveListing(ctx context.Context, userID string, listingID int64) error {
-	// Check if listing exists
+	// Check if listing exists; other users' drafts do not
 	var exists bool
-	err := s.P.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM listings WHERE id=$1)`, listingID).Scan(&exists)
+	err := s.P.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM listings WHERE id=$1 AND deleted_at IS NULL AND (status <> 'DRAFT' OR user_id=$2::uuid))`,
+		listingID, userID).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("failed to check listing existence: %w", err)
 	}
@@ -797,31 +1359,43 @@ func (s *Store) IsListingSaved(ctx context.Context, userID string, listingID int
 	return exists, nil
 }
 
-// GetSavedListings retrieves all saved listings for a user with listing details
-func (s *Store) GetSavedListings(ctx context.Context, userID string) ([]models.SavedListing, error) {
+// GetSavedListings returns a page of the listings userID has saved, most
+// recently saved first, along with the cursor of the next page.
+func (s *Store) GetSavedListings(ctx context.Context, userID string, p models.PageParams) ([]models.SavedListing, string, error) {
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != nil && cursor.CreatedAt == nil {
+		return nil, "", fmt.Errorf("invalid cursor")
+	}
+	limit := pageLimit(p.Limit, maxPageSize)
+
 	query := `
 		SELECT 
 			sl.id,
 			sl.user_id,
 			sl.listing_id,
 			sl.created_at,
-			l.id,
-			l.title,
-			l.description,
-			l.price,
-			l.category,
-			l.user_id,
-			l.status,
-			l.created_at
+			` + listingColumnsAs("l") + `
 		FROM saved_listings sl
-		JOIN listings l ON sl.listing_id = l.id
+		JOIN listings l ON sl.listing_id = l.id AND l.deleted_at IS NULL
 		WHERE sl.user_id = $1::uuid
-		ORDER BY sl.created_at DESC
 	`
+	args := []any{userID}
+	if cursor != nil {
+		cond, cargs := keysetCondition([]keysetColumn{
+			{expr: "sl.created_at", desc: true, value: *cursor.CreatedAt},
+			{expr: "sl.id", desc: true, value: cursor.ID},
+		}, len(args)+1)
+		query += " AND " + cond
+		args = append(args, cargs...)
+	}
+	query += fmt.Sprintf(" ORDER BY sl.created_at DESC, sl.id DESC LIMIT %d", limit+1)
 
-	rows, err := s.P.Query(ctx, query, userID)
+	rows, err := s.P.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query saved listings: %w", err)
+		return nil, "", fmt.Errorf("failed to query saved listings: %w", err)
 	}
 	defer rows.Close()
 
@@ -830,27 +1404,35 @@ func (s *Store) GetSavedListings(ctx context.Context, userID string) ([]models.S
 		var sl models.SavedListing
 		var listing models.Listing
 
-		err := rows.Scan(
+		err := rows.Scan(append([]any{
 			&sl.ID,
 			&sl.UserID,
 			&sl.ListingID,
 			&sl.CreatedAt,
-			&listing.ID,
-			&listing.Title,
-			&listing.Description,
-			&listing.Price,
-			&listing.Category,
-			&listing.UserID,
-			&listing.Status,
-			&listing.CreatedAt,
-		)
+		}, listingFields(&listing)...)...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan saved listing: %w", err)
+			return nil, "", fmt.Errorf("failed to scan saved listing: %w", err)
 		}
 
 		sl.Listing = listing
 		savedListings = append(savedListings, sl)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
 
-	return savedListings, rows.Err()
+	next := ""
+	if len(savedListings) > limit {
+		savedListings = savedListings[:limit]
+		last := savedListings[limit-1]
+		next = pageCursor{CreatedAt: &last.CreatedAt, ID: last.ID}.encode()
+	}
+	refs := make([]*models.Listing, len(savedListings))
+	for i := range savedListings {
+		refs[i] = &savedListings[i].Listing
+	}
+	if err := s.attachCovers(ctx, refs); err != nil {
+		return nil, "", err
+	}
+	return savedListings, next, nil
 }
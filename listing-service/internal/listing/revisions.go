@@ -0,0 +1,103 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// listingChanges returns the fields that differ between two versions of a
+// listing, keyed by their JSON name.
+func listingChanges(before, after models.Listing) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+	add := func(field string, changed bool, old, new any) {
+		if changed {
+			changes[field] = models.FieldChange{Old: old, New: new}
+		}
+	}
+	add("title", before.Title != after.Title, before.Title, after.Title)
+	add("description", !equalPtr(before.Description, after.Description), before.Description, after.Description)
+	add("price", before.Price != after.Price, before.Price, after.Price)
+	add("category", before.Category != after.Category, before.Category, after.Category)
+	add("status", before.Status != after.Status, before.Status, after.Status)
+	add("condition", !equalPtr(before.Condition, after.Condition), before.Condition, after.Condition)
+	add("pickup_location", !equalPtr(before.PickupLocation, after.PickupLocation), before.PickupLocation, after.PickupLocation)
+	add("tags", !slices.Equal(before.Tags, after.Tags), before.Tags, after.Tags)
+	add("attributes", !maps.Equal(before.Attributes, after.Attributes), before.Attributes, after.Attributes)
+	publishChanged := (before.PublishAt == nil) != (after.PublishAt == nil) ||
+		before.PublishAt != nil && !before.PublishAt.Equal(*after.PublishAt)
+	add("publish_at", publishChanged, before.PublishAt, after.PublishAt)
+	return changes
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// recordRevision writes the changes between before and after to the edit
+// history inside tx. Updates that changed nothing leave no revision.
+func recordRevision(ctx context.Context, tx pgx.Tx, before, after models.Listing, userID string, userRole string) error {
+	changes := listingChanges(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO listing_revisions (listing_id, version, changes, changed_by, changed_by_role)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, ''))
+	`, after.ID, after.Version, changes, userID, userRole)
+	if err != nil {
+		return fmt.Errorf("failed to record listing revision: %w", err)
+	}
+	return nil
+}
+
+// GetHistory returns the edit history of a listing, newest first. Only its
+// owner and admins may read it; admins also see the history of deleted
+// listings, which is kept as moderation evidence until they are purged.
+func (s *Store) GetHistory(ctx context.Context, id int64, userID string, userRole string) ([]models.ListingRevision, error) {
+	var (
+		ownerID   string
+		deletedAt *time.Time
+	)
+	err := s.P.QueryRow(ctx, `SELECT user_id::text, deleted_at FROM listings WHERE id=$1`, id).Scan(&ownerID, &deletedAt)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("listing not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load listing: %w", err)
+	}
+	admin := userRole == string(httplib.ADMIN)
+	if !admin && (ownerID != userID || deletedAt != nil) {
+		return nil, fmt.Errorf("listing not found")
+	}
+
+	rows, err := s.P.Query(ctx, `
+		SELECT id, listing_id, version, changes, changed_by, changed_by_role, changed_at
+		FROM listing_revisions
+		WHERE listing_id = $1
+		ORDER BY changed_at DESC, id DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listing history: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.ListingRevision{}
+	for rows.Next() {
+		var r models.ListingRevision
+		if err := rows.Scan(&r.ID, &r.ListingID, &r.Version, &r.Changes, &r.ChangedBy, &r.ChangedByRole, &r.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan listing revision: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
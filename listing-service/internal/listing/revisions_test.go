@@ -0,0 +1,94 @@
+package listing
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+func TestListingChanges(t *testing.T) {
+	desc := "Lightly used"
+	otherDesc := "Like new"
+	good := models.CondGood
+	library := "MLK Library"
+	publishAt := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	base := models.Listing{
+		ID:          1,
+		Title:       "Calculus",
+		Description: &desc,
+		Price:       2500,
+		Category:    models.CatTextbook,
+		Status:      models.StAvailable,
+		Version:     3,
+		Condition:   &good,
+		Tags:        []string{"math"},
+		Attributes:  models.Attributes{"isbn": "9780134685991"},
+		PublishAt:   &publishAt,
+	}
+
+	tests := []struct {
+		name   string
+		edit   func(l *models.Listing)
+		fields []string
+	}{
+		{"unchanged", func(l *models.Listing) {}, nil},
+		{"bookkeeping only", func(l *models.Listing) {
+			l.Version++
+			l.UpdatedAt = time.Now()
+			l.ExpiresAt = time.Now()
+		}, nil},
+		{"equal pointers to different values", func(l *models.Listing) {
+			d := desc
+			l.Description = &d
+			p := publishAt.In(time.FixedZone("PDT", -7*60*60))
+			l.PublishAt = &p
+		}, nil},
+		{"title and price", func(l *models.Listing) {
+			l.Title = "Calculus 2"
+			l.Price = 2000
+		}, []string{"price", "title"}},
+		{"description changed", func(l *models.Listing) { l.Description = &otherDesc }, []string{"description"}},
+		{"description cleared", func(l *models.Listing) { l.Description = nil }, []string{"description"}},
+		{"pickup set", func(l *models.Listing) { l.PickupLocation = &library }, []string{"pickup_location"}},
+		{"condition cleared", func(l *models.Listing) { l.Condition = nil }, []string{"condition"}},
+		{"tags", func(l *models.Listing) { l.Tags = []string{"math", "calculus"} }, []string{"tags"}},
+		{"attributes", func(l *models.Listing) { l.Attributes = models.Attributes{"isbn": "9780262033848"} }, []string{"attributes"}},
+		{"status and category", func(l *models.Listing) {
+			l.Status = models.StArchived
+			l.Category = models.CatOther
+		}, []string{"category", "status"}},
+		{"published", func(l *models.Listing) { l.PublishAt = nil }, []string{"publish_at"}},
+		{"rescheduled", func(l *models.Listing) {
+			p := publishAt.Add(time.Hour)
+			l.PublishAt = &p
+		}, []string{"publish_at"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			after.Tags = slices.Clone(base.Tags)
+			tt.edit(&after)
+			changes := listingChanges(base, after)
+
+			var fields []string
+			for field := range changes {
+				fields = append(fields, field)
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("Expected changes to %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+
+	t.Run("RecordsOldAndNewValues", func(t *testing.T) {
+		after := base
+		after.Price = 1800
+		change := listingChanges(base, after)["price"]
+		if change.Old != int64(2500) || change.New != int64(1800) {
+			t.Errorf("Expected 2500 -> 1800, got %v -> %v", change.Old, change.New)
+		}
+	})
+}
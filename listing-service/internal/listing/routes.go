@@ -12,6 +12,7 @@ func Routes(h *Handlers, orchReqId string) *chi.Mux {
 
 	reqIDMiddleware := httplib.EnforceXRequestID(orchReqId)
 
+	r.Use(httplib.CorrelationIDMiddleware)
 	r.Use(reqIDMiddleware)
 
 	var (
@@ -32,7 +33,11 @@ func Routes(h *Handlers, orchReqId string) *chi.Mux {
 		r.Use(routeProtected)
 		r.Post("/chatsearch", h.ChatSearchHandler)
 		r.Get("/", h.ListHandler)
+		r.Get("/trending", h.TrendingHandler)
+		r.Get("/courses/{code}", h.GetCourseHandler)
+		r.Get("/courses/{code}/listings", h.CourseListingsHandler)
 		r.Get("/{id}", h.GetHandler)
+		r.Get("/{id}/similar", h.SimilarHandler)
 		r.Get("/{id}/media", h.GetMediaUrlsHandler) // Public endpoint for fetching media
 	})
 
@@ -45,14 +50,33 @@ func Routes(h *Handlers, orchReqId string) *chi.Mux {
 		r.Get("/by-user-id", h.GetListingsByUserIDHandler)
 		r.Get("/user-lists/", h.GetUserListsHandler)
 		r.Post("/create", h.CreateHandler)
+		r.Post("/import", h.ImportListingsHandler)
+		r.Get("/export", h.ExportListingsHandler)
+		r.Get("/books/{isbn}", h.BookLookupHandler)
 		r.Post("/upload", h.UploadUserMedia)
 		r.Get("/flag/{id}/check", h.HasUserFlaggedListingHandler)
 		r.Post("/flag/{id}", h.FlagListingHandler)
 		r.Patch("/update/{id}", h.UpdateHandler)
+		r.Post("/renew/{id}", h.RenewHandler)
 		r.Delete("/delete/{id}", h.DeleteHandler)
+		r.Post("/restore/{id}", h.RestoreHandler)
+		r.Get("/deleted", h.DeletedListingsHandler)
+		r.Get("/{id}/history", h.HistoryHandler)
 		r.Post("/add-media-url/{id}", h.AddMediaURLHandler)
 		r.Patch("/{id}/media/{media_id}", h.UpdateMediaUrlHandler)
 		r.Delete("/{id}/media", h.DeleteMediaUrlHandler)
+		r.Put("/{id}/media/order", h.ReorderMediaHandler)
+		r.Delete("/{id}/media/{media_id}", h.DeleteMediaHandler)
+		r.Post("/{id}/media/{media_id}/cover", h.SetCoverHandler)
+		// Offer routes
+		r.Get("/offers", h.GetOffersHandler)
+		r.Post("/offers/{id}", h.CreateOfferHandler)
+		r.Post("/offers/respond/{offer_id}", h.RespondOfferHandler)
+		// Sale routes
+		r.Post("/sell/{id}", h.RecordSaleHandler)
+		r.Get("/transactions", h.GetTransactionsHandler)
+		r.Post("/transactions/confirm/{id}", h.ConfirmReceiptHandler)
+		r.Post("/courses/import", h.ImportCoursesHandler)
 		// Saved listings routes
 		r.Get("/saved", h.GetSavedListingsHandler)
 		r.Get("/save/{id}/check", h.IsListingSavedHandler)
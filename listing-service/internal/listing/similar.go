@@ -0,0 +1,99 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+// Similar returns the available listings most like listing id, leaving out
+// those of viewerID. A draft only has recommendations for its owner.
+// Candidates are gathered from the same category, title keywords, shared
+// tags, exact attributes and users who saved both listings, each capped at
+// models.SimilarCandidates, and only those are scored.
+func (s *Store) Similar(ctx context.Context, id int64, viewerID string, limit int) ([]models.SimilarListing, error) {
+	q := fmt.Sprintf(`
+		WITH base AS (
+			SELECT id, category, price, tags, attributes,
+				NULLIF(replace(plainto_tsquery('english', title)::text, '&', '|'), '')::tsquery AS terms
+			FROM listings
+			WHERE id = $1 AND deleted_at IS NULL AND (status <> 'DRAFT' OR user_id = NULLIF($2, '')::uuid)
+		), cosaved AS (
+			SELECT s2.listing_id, COUNT(*) AS n
+			FROM saved_listings s1
+			JOIN saved_listings s2 ON s2.user_id = s1.user_id AND s2.listing_id <> s1.listing_id
+			WHERE s1.listing_id = $1
+			GROUP BY s2.listing_id
+			ORDER BY n DESC
+			LIMIT %[1]d
+		), candidates AS (
+			(SELECT l.id FROM listings l, base b
+				WHERE l.category = b.category AND l.status = 'AVAILABLE' AND l.deleted_at IS NULL
+				ORDER BY l.created_at DESC LIMIT %[1]d)
+			UNION
+			(SELECT l.id FROM listings l, base b
+				WHERE b.terms IS NOT NULL AND l.search_vector @@ b.terms AND l.status = 'AVAILABLE' AND l.deleted_at IS NULL
+				ORDER BY ts_rank(l.search_vector, b.terms) DESC LIMIT %[1]d)
+			UNION
+			(SELECT l.id FROM listings l, base b
+				WHERE cardinality(b.tags) > 0 AND l.tags && b.tags AND l.status = 'AVAILABLE' AND l.deleted_at IS NULL
+				LIMIT %[1]d)
+			UNION
+			(SELECT l.id FROM listings l, base b
+				WHERE ((b.attributes ? 'isbn' AND l.attributes @> jsonb_build_object('isbn', b.attributes->'isbn'))
+					OR (b.attributes ? 'course_code' AND l.attributes @> jsonb_build_object('course_code', b.attributes->'course_code')))
+				AND l.status = 'AVAILABLE' AND l.deleted_at IS NULL
+				LIMIT %[1]d)
+			UNION
+			SELECT listing_id FROM cosaved
+		)
+		SELECT `+listingColumnsAs("l")+`, score
+		FROM (
+			SELECT l.id,
+				CASE WHEN l.category = b.category THEN $3::float8 ELSE 0 END
+				+ CASE WHEN b.terms IS NULL THEN 0 ELSE ts_rank(l.search_vector, b.terms) * $4::float8 END
+				+ cardinality(ARRAY(SELECT unnest(l.tags) INTERSECT SELECT unnest(b.tags)))::float8
+					/ GREATEST(cardinality(b.tags), 1) * $5::float8
+				+ (SELECT COUNT(*) FROM jsonb_each_text(b.attributes) a WHERE l.attributes->>a.key = a.value)::float8
+					/ GREATEST((SELECT COUNT(*) FROM jsonb_object_keys(b.attributes)), 1) * $6::float8
+				+ (1 - LEAST(ABS(l.price - b.price)::float8 / GREATEST(b.price, 1), 1)) * $7::float8
+				+ COALESCE(LN(1 + cs.n), 0) * $8::float8 AS score
+			FROM candidates c
+			JOIN listings l ON l.id = c.id
+			CROSS JOIN base b
+			LEFT JOIN cosaved cs ON cs.listing_id = l.id
+			WHERE l.id <> b.id
+			AND l.status = 'AVAILABLE' AND l.deleted_at IS NULL
+			AND l.user_id IS DISTINCT FROM NULLIF($2, '')::uuid
+		) scored
+		JOIN listings l ON l.id = scored.id
+		ORDER BY score DESC, l.id DESC
+		LIMIT %[2]d
+	`, models.SimilarCandidates, pageLimit(limit, 8))
+
+	rows, err := s.P.Query(ctx, q, id, viewerID,
+		models.SimilarCategoryWeight, models.SimilarKeywordWeight, models.SimilarTagWeight,
+		models.SimilarAttributeWeight, models.SimilarPriceWeight, models.SimilarCoSaveWeight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query similar listings: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.SimilarListing{}
+	for rows.Next() {
+		var sl models.SimilarListing
+		if err := rows.Scan(append(listingFields(&sl.Listing), &sl.Score)...); err != nil {
+			return nil, fmt.Errorf("failed to scan similar listing: %w", err)
+		}
+		out = append(out, sl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	refs := make([]*models.Listing, len(out))
+	for i := range out {
+		refs[i] = &out[i].Listing
+	}
+	return out, s.attachCovers(ctx, refs)
+}
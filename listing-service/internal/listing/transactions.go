@@ -0,0 +1,205 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kunal768/cmpe202/listing-service/internal/models"
+)
+
+var (
+	errTransactionNotFound = errors.New("transaction not found")
+	errAlreadyReceived     = errors.New("receipt was already confirmed")
+	errBuyerNotFound       = errors.New("buyer not found")
+	errOwnPurchase         = errors.New("you cannot sell a listing to yourself")
+	errReservedForOther    = errors.New("listing is reserved for another buyer")
+	errAlreadySold         = errors.New("listing was already sold")
+)
+
+// transactionColumns is the column list selected for a transaction; scan it with transactionFields.
+const transactionColumns = "id, listing_id, buyer_id, seller_id, offer_id, listing_title, category, price, completed_at, received_at"
+
+// transactionFields returns the scan destinations matching transactionColumns.
+func transactionFields(t *models.Transaction) []any {
+	return []any{&t.ID, &t.ListingID, &t.BuyerID, &t.SellerID, &t.OfferID, &t.ListingTitle, &t.Category, &t.Price, &t.CompletedAt, &t.ReceivedAt}
+}
+
+// RecordSale marks an AVAILABLE or PENDING listing SOLD to buyer and records
+// the transaction, declining every offer still open on it. Only the owner
+// can sell; a reserved listing can only be sold to the buyer it is reserved
+// for.
+func (s *Store) RecordSale(ctx context.Context, listingID int64, sellerID string, p models.RecordSaleParams) (models.SaleResult, error) {
+	tx, err := s.P.Begin(ctx)
+	if err != nil {
+		return models.SaleResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// No role, so admins cannot sell on someone else's behalf
+	l, err := lockListing(ctx, tx, listingID, sellerID, "", nil)
+	if err != nil {
+		return models.SaleResult{}, err
+	}
+	if l.Status != models.StAvailable && l.Status != models.StPending {
+		return models.SaleResult{}, transitionError(l.Status, models.StSold)
+	}
+	if p.BuyerID == l.UserID {
+		return models.SaleResult{}, errOwnPurchase
+	}
+	if l.ReservedFor != nil && *l.ReservedFor != p.BuyerID {
+		return models.SaleResult{}, errReservedForOther
+	}
+
+	// The buyer's accepted offer sets the price unless the seller overrides it
+	var offerID *int64
+	price := l.Price
+	var offerAmount int64
+	var id int64
+	err = tx.QueryRow(ctx, `
+		SELECT id, amount FROM offers
+		WHERE listing_id=$1 AND buyer_id=$2 AND status='ACCEPTED'
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, listingID, p.BuyerID).Scan(&id, &offerAmount)
+	switch {
+	case err == nil:
+		offerID, price = &id, offerAmount
+	case err != pgx.ErrNoRows:
+		return models.SaleResult{}, fmt.Errorf("failed to load accepted offer: %w", err)
+	}
+	if p.Price != nil {
+		price = *p.Price
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE listings
+		SET status='SOLD', reserved_for=$2, version=version+1, updated_at=NOW()
+		WHERE id=$1
+	`, listingID, p.BuyerID)
+	if err != nil {
+		return models.SaleResult{}, fmt.Errorf("failed to mark listing sold: %w", err)
+	}
+
+	var t models.Transaction
+	err = tx.QueryRow(ctx, `
+		INSERT INTO transactions (listing_id, buyer_id, seller_id, offer_id, listing_title, category, price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+transactionColumns,
+		listingID, p.BuyerID, l.UserID, offerID, l.Title, l.Category, price).Scan(transactionFields(&t)...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return models.SaleResult{}, errBuyerNotFound
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.SaleResult{}, errAlreadySold
+		}
+		return models.SaleResult{}, fmt.Errorf("failed to record transaction: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE offers SET status='REJECTED', updated_at=NOW()
+		WHERE listing_id=$1 AND status='PENDING'
+		RETURNING `+offerColumns, listingID)
+	if err != nil {
+		return models.SaleResult{}, fmt.Errorf("failed to decline other offers: %w", err)
+	}
+	declined, err := scanOffers(rows)
+	if err != nil {
+		return models.SaleResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.SaleResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return models.SaleResult{Transaction: t, Declined: declined}, nil
+}
+
+// ConfirmReceipt records that the buyer received the item.
+func (s *Store) ConfirmReceipt(ctx context.Context, transactionID int64, buyerID string) (models.Transaction, error) {
+	var t models.Transaction
+	err := s.P.QueryRow(ctx, `
+		UPDATE transactions SET received_at=NOW()
+		WHERE id=$1 AND buyer_id=$2::uuid AND received_at IS NULL
+		RETURNING `+transactionColumns, transactionID, buyerID).Scan(transactionFields(&t)...)
+	if err == nil {
+		return t, nil
+	}
+	if err != pgx.ErrNoRows {
+		return models.Transaction{}, fmt.Errorf("failed to confirm receipt: %w", err)
+	}
+
+	// Tell an already confirmed receipt apart from someone else's transaction
+	var exists bool
+	err = s.P.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM transactions WHERE id=$1 AND buyer_id=$2::uuid)`, transactionID, buyerID).Scan(&exists)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if exists {
+		return models.Transaction{}, errAlreadyReceived
+	}
+	return models.Transaction{}, errTransactionNotFound
+}
+
+// GetTransactions returns the purchases ("buyer") or sales ("seller") of
+// userID, or both when role is empty, newest first.
+func (s *Store) GetTransactions(ctx context.Context, userID string, role string, p models.PageParams) ([]models.Transaction, string, error) {
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != nil && cursor.CreatedAt == nil {
+		return nil, "", fmt.Errorf("invalid cursor")
+	}
+	limit := pageLimit(p.Limit, maxPageSize)
+
+	q := `SELECT ` + transactionColumns + ` FROM transactions WHERE `
+	switch role {
+	case "buyer":
+		q += `buyer_id=$1::uuid`
+	case "seller":
+		q += `seller_id=$1::uuid`
+	default:
+		q += `(buyer_id=$1::uuid OR seller_id=$1::uuid)`
+	}
+	args := []any{userID}
+	if cursor != nil {
+		cond, cargs := keysetCondition([]keysetColumn{
+			{expr: "completed_at", desc: true, value: *cursor.CreatedAt},
+			{expr: "id", desc: true, value: cursor.ID},
+		}, len(args)+1)
+		q += " AND " + cond
+		args = append(args, cargs...)
+	}
+	q += fmt.Sprintf(" ORDER BY completed_at DESC, id DESC LIMIT %d", limit+1)
+
+	rows, err := s.P.Query(ctx, q, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var out []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(transactionFields(&t)...); err != nil {
+			return nil, "", fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(out) > limit {
+		out = out[:limit]
+		last := out[limit-1]
+		next = pageCursor{CreatedAt: &last.CompletedAt, ID: last.ID}.encode()
+	}
+	return out, next, nil
+}
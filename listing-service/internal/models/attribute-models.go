@@ -0,0 +1,62 @@
+package models
+
+// Condition is the state of the item being sold.
+type Condition string
+
+const (
+	CondNew     Condition = "NEW"
+	CondLikeNew Condition = "LIKE_NEW"
+	CondGood    Condition = "GOOD"
+	CondFair    Condition = "FAIR"
+	CondPoor    Condition = "POOR"
+)
+
+var AllConditions = []Condition{
+	CondNew,
+	CondLikeNew,
+	CondGood,
+	CondFair,
+	CondPoor,
+}
+
+const (
+	MaxTags              = 10
+	MaxTagLength         = 30
+	MaxPickupLength      = 100
+	MaxAttributeValueLen = 100
+)
+
+// Attributes are the category-specific details of a listing, stored as a flat
+// JSONB object of strings.
+type Attributes map[string]string
+
+// AttributeSpec describes one attribute a category accepts.
+type AttributeSpec struct {
+	Key         string `json:"key"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+const (
+	AttrISBN       = "isbn"
+	AttrEdition    = "edition"
+	AttrCourseCode = "course_code"
+	AttrBrand      = "brand"
+	AttrModel      = "model"
+	AttrStorage    = "storage"
+)
+
+// CategoryAttributes lists the attributes each category accepts; categories
+// missing here take none.
+var CategoryAttributes = map[Category][]AttributeSpec{
+	CatTextbook: {
+		{Key: AttrISBN, Label: "ISBN", Description: "ISBN-10 or ISBN-13, digits only"},
+		{Key: AttrEdition, Label: "Edition", Description: "edition, e.g. \"3rd\""},
+		{Key: AttrCourseCode, Label: "Course code", Description: "course code without spaces, e.g. \"CMPE202\""},
+	},
+	CatGadget: {
+		{Key: AttrBrand, Label: "Brand", Description: "manufacturer, e.g. \"Apple\""},
+		{Key: AttrModel, Label: "Model", Description: "model name, e.g. \"iPad Air\""},
+		{Key: AttrStorage, Label: "Storage", Description: "storage size, e.g. \"256GB\""},
+	},
+}
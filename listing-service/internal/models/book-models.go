@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// BookMetadata describes one edition of a book, looked up by ISBN.
+type BookMetadata struct {
+	ISBN string `json:"isbn"` // ISBN-13
+	// WorkID groups the editions of the same book, e.g. an Open Library work key
+	WorkID      string    `json:"work_id,omitempty"`
+	Title       string    `json:"title"`
+	Authors     []string  `json:"authors"`
+	Edition     string    `json:"edition,omitempty"`
+	Publisher   string    `json:"publisher,omitempty"`
+	PublishDate string    `json:"publish_date,omitempty"`
+	CoverURL    string    `json:"cover_url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// BookPrefill is the create-listing form filled in from an ISBN. CoverURL
+// can be attached with add-media-url once the listing exists.
+type BookPrefill struct {
+	Book     BookMetadata `json:"book"`
+	Listing  CreateParams `json:"listing"`
+	CoverURL string       `json:"cover_url,omitempty"`
+}
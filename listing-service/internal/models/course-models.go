@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Course is a catalog entry with the textbooks it uses.
+type Course struct {
+	Code      string       `json:"code"` // e.g. "CMPE202"
+	Title     string       `json:"title"`
+	Books     []CourseBook `json:"books"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CourseBook is a textbook of a course. Book is set when its metadata was
+// looked up before.
+type CourseBook struct {
+	ISBN     string        `json:"isbn"`
+	Required bool          `json:"required"`
+	Book     *BookMetadata `json:"book,omitempty"`
+}
+
+// CourseImportResult summarises a catalog import.
+type CourseImportResult struct {
+	Courses int `json:"courses"`
+	Books   int `json:"books"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Weights and decay of the trending score. Every view, save and chat started
+// within TrendingWindow adds its weight, halved for every TrendingHalfLife
+// since it happened.
+const (
+	TrendingViewWeight = 1.0
+	TrendingSaveWeight = 5.0
+	TrendingChatWeight = 8.0
+	TrendingHalfLife   = 48 * time.Hour
+	TrendingWindow     = 14 * 24 * time.Hour
+)
+
+// TrendingListing is a listing of the trending feed with its score.
+type TrendingListing struct {
+	Listing
+	Score float64 `json:"trending_score"`
+}
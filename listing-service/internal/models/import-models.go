@@ -0,0 +1,35 @@
+package models
+
+// MaxImportRows caps the listings one bulk import can create.
+const MaxImportRows = 200
+
+// Bulk import and export formats.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// ImportColumns are the CSV columns of a bulk import or export, in export
+// order. price is in cents, tags are separated by semicolons and attributes
+// are key=value pairs separated by semicolons, e.g. "isbn=9780262046305;edition=4th".
+// An import may leave out any column but title, price and category.
+var ImportColumns = []string{"title", "description", "price", "category", "condition", "pickup_location", "tags", "attributes"}
+
+// ImportRowError is a validation failure of one imported row. Rows are
+// numbered from 1, not counting the CSV header.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the outcome of a bulk import. Listings are the created
+// listings; a dry run validates every row without creating any.
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Valid    int              `json:"valid"`
+	Errors   []ImportRowError `json:"errors"`
+	Listings []Listing        `json:"listings"`
+}
@@ -26,6 +26,7 @@ const (
 	StSold      Status = "SOLD"
 	StArchived  Status = "ARCHIVED"
 	StReported  Status = "REPORTED"
+	StDraft     Status = "DRAFT" // only visible to the owner until published
 )
 
 var AllCategories = []Category{
@@ -44,45 +45,172 @@ var AllStatuses = []Status{
 	StReported,
 }
 
+// DefaultDeleteRetention is how long a deleted listing can be restored
+// before it is purged with its media.
+const DefaultDeleteRetention = 30 * 24 * time.Hour
+
 type Listing struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description,omitempty"`
-	Price       int64     `json:"price"`
-	Category    Category  `json:"category"`
-	UserID      uuid.UUID `json:"user_id"`
-	Status      Status    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             int64      `json:"id"`
+	Title          string     `json:"title"`
+	Description    *string    `json:"description,omitempty"`
+	Price          int64      `json:"price"`
+	Category       Category   `json:"category"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Status         Status     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Version        int64      `json:"version"`
+	ExpiresAt      time.Time  `json:"expires_at"`             // archived then unless renewed, while AVAILABLE or PENDING
+	ReservedFor    *uuid.UUID `json:"reserved_for,omitempty"` // buyer whose accepted offer put the listing in PENDING
+	Condition      *Condition `json:"condition,omitempty"`
+	PickupLocation *string    `json:"pickup_location,omitempty"` // campus spot to meet, e.g. "MLK Library"
+	Tags           []string   `json:"tags"`
+	Attributes     Attributes `json:"attributes"`           // keys depend on Category, see CategoryAttributes
+	PublishAt      *time.Time `json:"publish_at,omitempty"` // when a DRAFT is published automatically
+	Snippet        *string    `json:"snippet,omitempty"`    // ts_headline excerpt, only set by keyword searches
+	Views          *int64     `json:"views,omitempty"`      // views flushed so far, only shown to the owner
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"` // only set when listing the owner's deleted listings
+	Cover          *Cover     `json:"cover,omitempty"`      // cover image, only set in list responses
+	// PriceHistory is only loaded for the listing detail, newest change first
+	PriceHistory []PriceChange `json:"price_history,omitempty"`
+}
+
+// PriceChange is a row of listing_price_history.
+type PriceChange struct {
+	OldPrice  int64     `json:"old_price"`
+	NewPrice  int64     `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at"`
 }
 
 type CreateParams struct {
-	Title       string   `json:"title"`
-	Description *string  `json:"description,omitempty"`
-	Price       int64    `json:"price"`
-	Category    Category `json:"category"`
+	Title          string     `json:"title"`
+	Description    *string    `json:"description,omitempty"`
+	Price          int64      `json:"price"`
+	Category       Category   `json:"category"`
+	Condition      *Condition `json:"condition,omitempty"`
+	PickupLocation *string    `json:"pickup_location,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	Attributes     Attributes `json:"attributes,omitempty"`
+	// Draft creates the listing as a DRAFT; a future PublishAt also does and
+	// schedules its publication
+	Draft     bool       `json:"draft,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
 }
 
 type UpdateParams struct {
-	Title       *string   `json:"title,omitempty"`
-	Description *string   `json:"description,omitempty"`
-	Price       *int64    `json:"price,omitempty"`
-	Category    *Category `json:"category,omitempty"`
-	Status      *Status   `json:"status,omitempty"`
+	Title          *string    `json:"title,omitempty"`
+	Description    *string    `json:"description,omitempty"`
+	Price          *int64     `json:"price,omitempty"`
+	Category       *Category  `json:"category,omitempty"`
+	Status         *Status    `json:"status,omitempty"`
+	Condition      *Condition `json:"condition,omitempty"`
+	PickupLocation *string    `json:"pickup_location,omitempty"`
+	// Tags and Attributes replace the stored values when non-nil. Changing
+	// Category without sending Attributes clears them.
+	Tags       *[]string   `json:"tags,omitempty"`
+	Attributes *Attributes `json:"attributes,omitempty"`
+	// PublishAt reschedules a DRAFT and must be in the future
+	PublishAt *time.Time `json:"publish_at,omitempty"`
 }
 
+// AddMediaParams appends media to a listing's gallery. MediaUrls is the
+// older form without file details; both may be sent.
 type AddMediaParams struct {
-	MediaUrls []string `json:"media_urls"`
+	MediaUrls []string     `json:"media_urls,omitempty"`
+	Media     []MediaInput `json:"media,omitempty"`
+}
+
+// MediaInput is one uploaded file added to a listing.
+type MediaInput struct {
+	URL       string  `json:"url"`
+	AltText   *string `json:"alt_text,omitempty"`
+	Caption   *string `json:"caption,omitempty"`
+	MimeType  *string `json:"mime_type,omitempty"`
+	Width     *int    `json:"width,omitempty"`
+	Height    *int    `json:"height,omitempty"`
+	SizeBytes *int64  `json:"size_bytes,omitempty"`
+}
+
+// ReorderMediaParams lists every media ID of a listing in its new order.
+type ReorderMediaParams struct {
+	MediaIDs []int64 `json:"media_ids"`
 }
 
 type ListFilters struct {
+	Query    string    `json:"-"` // web-search syntax, e.g. `"linear algebra" -solutions`
 	Keywords []string  `json:"keywords,omitempty"`
 	Category *Category `json:"category,omitempty"`
 	Status   *Status   `json:"status,omitempty"`
 	MinPrice *int64    `json:"min_price,omitempty"`
 	MaxPrice *int64    `json:"max_price,omitempty"`
-	Limit    int
-	Offset   int
-	Sort     string // "created_at_desc", "price_asc", "price_desc"
+	// Condition, Pickup, Tags (all must match) and Attributes (all must
+	// match) narrow the results further
+	Condition  *Condition `json:"condition,omitempty"`
+	Pickup     *string    `json:"pickup_location,omitempty"` // case-insensitive substring
+	Tags       []string   `json:"tags,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Course     *string    `json:"course,omitempty"` // a catalog course code; matches the listings of its books
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"-"` // ignored when Cursor is set
+	Cursor     string     `json:"-"`
+	Sort       string     `json:"sort,omitempty"` // "created_at_desc", "price_asc", "price_desc"
+	// WithCount also computes the total number of matches, which costs a
+	// second scan of the filtered rows.
+	WithCount bool `json:"-"`
+	// Facets names the facets (see AllFacets) to count alongside the page;
+	// PriceBuckets are the ascending lower bounds, in cents, of the price facet.
+	Facets       []string `json:"-"`
+	PriceBuckets []int64  `json:"-"`
+}
+
+const (
+	FacetCategory = "category"
+	FacetStatus   = "status"
+	FacetPrice    = "price"
+)
+
+var AllFacets = []string{FacetCategory, FacetStatus, FacetPrice}
+
+// DefaultPriceBuckets splits prices at $25, $50, $100 and $250.
+var DefaultPriceBuckets = []int64{0, 2500, 5000, 10000, 25000}
+
+// FacetCount is the number of matching listings with a given facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceBucket counts the matching listings priced in [Min, Max); the last
+// bucket has no upper bound.
+type PriceBucket struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets holds the counts of the requested facets. Each facet is counted over
+// the current filters except its own, so picking a category still shows how
+// many results the other categories hold.
+type Facets struct {
+	Category []FacetCount  `json:"category,omitempty"`
+	Status   []FacetCount  `json:"status,omitempty"`
+	Price    []PriceBucket `json:"price,omitempty"`
+}
+
+// ListingPage is one keyset page of listings. NextCursor is empty on the last
+// page and Count is only set when the caller asked for it.
+type ListingPage struct {
+	Items      []Listing `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Count      *int      `json:"count,omitempty"`
+	Facets     *Facets   `json:"facets,omitempty"`
+}
+
+// PageParams selects a keyset page of an unfiltered collection. Cursor is the
+// next cursor returned with the previous page.
+type PageParams struct {
+	Limit  int
+	Cursor string
 }
 
 type FileMetadata struct {
@@ -145,12 +273,32 @@ type ListingMedia struct {
 	ID        int64     `json:"id"`
 	ListingID int64     `json:"listing_id"`
 	MediaURL  string    `json:"media_url"`
+	Position  int       `json:"position"` // order in the gallery, from 0
+	IsCover   bool      `json:"is_cover"`
+	AltText   *string   `json:"alt_text,omitempty"`
+	Caption   *string   `json:"caption,omitempty"`
+	MimeType  *string   `json:"mime_type,omitempty"`
+	Width     *int      `json:"width,omitempty"`
+	Height    *int      `json:"height,omitempty"`
+	SizeBytes *int64    `json:"size_bytes,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// UpdateMediaParams represents parameters for updating a media URL
+// Cover is the cover image of a listing as shown in list responses.
+type Cover struct {
+	MediaID  int64   `json:"media_id"`
+	MediaURL string  `json:"media_url"`
+	AltText  *string `json:"alt_text,omitempty"`
+	Width    *int    `json:"width,omitempty"`
+	Height   *int    `json:"height,omitempty"`
+}
+
+// UpdateMediaParams represents parameters for updating a media URL and its
+// description; an empty alt_text or caption clears it
 type UpdateMediaParams struct {
-	NewURL string `json:"new_url"`
+	NewURL  string  `json:"new_url,omitempty"`
+	AltText *string `json:"alt_text,omitempty"`
+	Caption *string `json:"caption,omitempty"`
 }
 
 // SavedListing represents a saved listing entry
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OfferStatus string
+
+const (
+	OfferPending   OfferStatus = "PENDING"
+	OfferAccepted  OfferStatus = "ACCEPTED"
+	OfferRejected  OfferStatus = "REJECTED"
+	OfferCountered OfferStatus = "COUNTERED"
+	OfferWithdrawn OfferStatus = "WITHDRAWN"
+	OfferExpired   OfferStatus = "EXPIRED"
+)
+
+// Offer responses
+const (
+	OfferActionAccept   = "accept"
+	OfferActionReject   = "reject"
+	OfferActionCounter  = "counter"
+	OfferActionWithdraw = "withdraw"
+)
+
+const DefaultOfferTTL = 48 * time.Hour
+
+type Offer struct {
+	ID            int64       `json:"id"`
+	ListingID     int64       `json:"listing_id"`
+	BuyerID       uuid.UUID   `json:"buyer_id"`
+	SellerID      uuid.UUID   `json:"seller_id"`
+	FromUserID    uuid.UUID   `json:"from_user_id"` // the buyer, or the seller for a counter
+	ParentOfferID *int64      `json:"parent_offer_id,omitempty"`
+	Amount        int64       `json:"amount"`
+	Message       *string     `json:"message,omitempty"`
+	Status        OfferStatus `json:"status"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type CreateOfferParams struct {
+	Amount  int64   `json:"amount"`
+	Message *string `json:"message,omitempty"`
+}
+
+type RespondOfferParams struct {
+	Action  string  `json:"action"`           // accept, reject, counter or withdraw
+	Amount  int64   `json:"amount,omitempty"` // counter only
+	Message *string `json:"message,omitempty"`
+}
+
+// OfferResult is the outcome of a response. Offer is the answered offer, or
+// the new counter-offer; Declined lists the other open offers closed because
+// the listing was reserved by an acceptance.
+type OfferResult struct {
+	Offer    Offer   `json:"offer"`
+	Declined []Offer `json:"declined,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListingRevision is a row of listing_revisions: one update of a listing and
+// the fields it changed, keyed by their JSON name.
+type ListingRevision struct {
+	ID            int64                  `json:"id"`
+	ListingID     int64                  `json:"listing_id"`
+	Version       int64                  `json:"version"` // listing version the update produced
+	Changes       map[string]FieldChange `json:"changes"`
+	ChangedBy     *uuid.UUID             `json:"changed_by,omitempty"`
+	ChangedByRole *string                `json:"changed_by_role,omitempty"`
+	ChangedAt     time.Time              `json:"changed_at"`
+}
+
+// FieldChange is the value of a listing field before and after an update.
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
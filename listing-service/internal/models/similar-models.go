@@ -0,0 +1,22 @@
+package models
+
+// Weights of the similarity score of a listing to the one being viewed. Each
+// signal is scaled to roughly 0..1 before weighting, except co-saves which
+// grow with the log of the number of users who saved both listings.
+const (
+	SimilarCategoryWeight  = 2.0
+	SimilarKeywordWeight   = 4.0
+	SimilarTagWeight       = 1.0
+	SimilarAttributeWeight = 1.5
+	SimilarPriceWeight     = 1.5
+	SimilarCoSaveWeight    = 3.0
+	// SimilarCandidates caps how many listings each signal contributes
+	// before scoring, keeping the query cheap in large categories
+	SimilarCandidates = 200
+)
+
+// SimilarListing is a listing recommended from the one being viewed.
+type SimilarListing struct {
+	Listing
+	Score float64 `json:"similarity_score"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction is a sale the seller confirmed to a specific buyer.
+type Transaction struct {
+	ID           int64      `json:"id"`
+	ListingID    *int64     `json:"listing_id,omitempty"` // nil once the listing was deleted
+	BuyerID      *uuid.UUID `json:"buyer_id,omitempty"`
+	SellerID     *uuid.UUID `json:"seller_id,omitempty"`
+	OfferID      *int64     `json:"offer_id,omitempty"`
+	ListingTitle string     `json:"listing_title"`
+	Category     Category   `json:"category"`
+	Price        int64      `json:"price"`
+	CompletedAt  time.Time  `json:"completed_at"`
+	ReceivedAt   *time.Time `json:"received_at,omitempty"` // set when the buyer confirms receipt
+}
+
+// SaleResult is a recorded sale and the other open offers declined because
+// the listing was sold.
+type SaleResult struct {
+	Transaction
+	Declined []Offer `json:"declined,omitempty"`
+}
+
+type RecordSaleParams struct {
+	BuyerID uuid.UUID `json:"buyer_id"`
+	// Price defaults to the buyer's accepted offer, else the listing price
+	Price *int64 `json:"price,omitempty"`
+}
@@ -3,6 +3,8 @@ package platform
 import (
 	"encoding/json"
 	"net/http"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
 )
 
 func JSON(w http.ResponseWriter, status int, v any) {
@@ -11,6 +13,17 @@ func JSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// Error writes an RFC 7807 problem with the default code for status.
 func Error(w http.ResponseWriter, status int, msg string) {
-	JSON(w, status, map[string]string{"error": msg})
+	httplib.WriteProblem(w, httplib.NewProblem(status, msg))
+}
+
+// CodedError writes a problem with an explicit machine-readable code.
+func CodedError(w http.ResponseWriter, status int, code, msg string) {
+	httplib.WriteProblem(w, httplib.NewCodedProblem(status, code, msg))
+}
+
+// ValidationError writes a 400 problem listing the invalid fields.
+func ValidationError(w http.ResponseWriter, msg string, fields ...httplib.FieldError) {
+	httplib.WriteProblem(w, httplib.ValidationProblem(msg, fields...))
 }
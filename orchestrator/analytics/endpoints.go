@@ -3,6 +3,7 @@ package analytics
 import (
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	httplib "github.com/kunal768/cmpe202/http-lib"
 )
@@ -17,12 +18,6 @@ func NewEndpoints(service Service) *Endpoints {
 	}
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
 // checkAdminRole checks if the current user is an admin
 func checkAdminRole(r *http.Request) (bool, string) {
 	userRole, ok := r.Context().Value(httplib.ContextKey("userRole")).(string)
@@ -37,20 +32,31 @@ func (e *Endpoints) GetAnalyticsHandler(w http.ResponseWriter, r *http.Request)
 	// Check if user is admin
 	isAdmin, _ := checkAdminRole(r)
 	if !isAdmin {
-		httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-			Error:   "Forbidden",
-			Message: "Admin access required",
-		})
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 		return
 	}
 
 	// Call service
 	response, err := e.service.GetAnalytics(r.Context())
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch analytics",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch analytics", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetSellerStatsHandler returns a seller's sales record (any signed-in user)
+func (e *Endpoints) GetSellerStatsHandler(w http.ResponseWriter, r *http.Request) {
+	sellerID := r.PathValue("id")
+	if _, err := uuid.Parse(sellerID); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid user ID format")
+		return
+	}
+
+	response, err := e.service.GetSellerStats(r.Context(), sellerID)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch seller stats", err)
 		return
 	}
 
@@ -59,7 +65,7 @@ func (e *Endpoints) GetAnalyticsHandler(w http.ResponseWriter, r *http.Request)
 
 // RegisterRoutes registers all analytics routes with proper middleware
 func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
-	// Admin-only route: requires auth + role injection
+	// Routes require auth + role injection; GetAnalyticsHandler checks for admin itself
 	protected := func(h http.Handler) http.Handler {
 		return httplib.AuthMiddleWare(
 			httplib.RoleInjectionMiddleWare(dbPool)(
@@ -69,4 +75,5 @@ func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
 	}
 
 	mux.Handle("GET /api/analytics", protected(http.HandlerFunc(e.GetAnalyticsHandler)))
+	mux.Handle("GET /api/users/{id}/stats", protected(http.HandlerFunc(e.GetSellerStatsHandler)))
 }
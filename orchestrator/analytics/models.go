@@ -1,5 +1,7 @@
 package analytics
 
+import "time"
+
 // OverviewStats represents the main dashboard overview statistics
 type OverviewStats struct {
 	TotalUsers    int `json:"total_users"`
@@ -32,6 +34,20 @@ type FlagsByReason struct {
 	Count  int    `json:"count"`
 }
 
+// SalesStats summarises completed transactions; amounts are in cents
+type SalesStats struct {
+	TotalSales     int   `json:"total_sales"`
+	ConfirmedSales int   `json:"confirmed_sales"`
+	GrossValue     int64 `json:"gross_value"`
+}
+
+// SalesByCategory represents completed transactions grouped by category
+type SalesByCategory struct {
+	Category   string `json:"category"`
+	Count      int    `json:"count"`
+	GrossValue int64  `json:"gross_value"`
+}
+
 // AnalyticsResponse contains all analytics data
 type AnalyticsResponse struct {
 	Overview           OverviewStats        `json:"overview"`
@@ -39,4 +55,16 @@ type AnalyticsResponse struct {
 	ListingsByCategory []ListingsByCategory `json:"listings_by_category"`
 	FlagsByStatus      []FlagsByStatus      `json:"flags_by_status"`
 	FlagsByReason      []FlagsByReason      `json:"flags_by_reason"`
+	Sales              SalesStats           `json:"sales"`
+	SalesByCategory    []SalesByCategory    `json:"sales_by_category"`
+}
+
+// SellerStats is the public track record of a seller, built from their
+// recorded sales
+type SellerStats struct {
+	SellerID       string     `json:"seller_id"`
+	SalesCount     int        `json:"sales_count"`
+	ConfirmedSales int        `json:"confirmed_sales"`
+	ActiveListings int        `json:"active_listings"`
+	LastSaleAt     *time.Time `json:"last_sale_at,omitempty"`
 }
@@ -15,6 +15,9 @@ type Repository interface {
 	GetListingsByCategory(ctx context.Context) ([]ListingsByCategory, error)
 	GetFlagsByStatus(ctx context.Context) ([]FlagsByStatus, error)
 	GetFlagsByReason(ctx context.Context) ([]FlagsByReason, error)
+	GetSalesStats(ctx context.Context) (SalesStats, error)
+	GetSalesByCategory(ctx context.Context) ([]SalesByCategory, error)
+	GetSellerStats(ctx context.Context, sellerID string) (SellerStats, error)
 }
 
 type repo struct {
@@ -35,7 +38,7 @@ func (r *repo) GetTotalUsers(ctx context.Context) (int, error) {
 
 func (r *repo) GetTotalListings(ctx context.Context) (int, error) {
 	var count int
-	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM listings").Scan(&count)
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM listings WHERE status <> 'DRAFT' AND deleted_at IS NULL").Scan(&count)
 	return count, err
 }
 
@@ -55,6 +58,7 @@ func (r *repo) GetListingsByStatus(ctx context.Context) ([]ListingsByStatus, err
 	rows, err := r.db.Query(ctx, `
 		SELECT status, COUNT(*) as count
 		FROM listings
+		WHERE status <> 'DRAFT' AND deleted_at IS NULL
 		GROUP BY status
 		ORDER BY count DESC
 	`)
@@ -78,6 +82,7 @@ func (r *repo) GetListingsByCategory(ctx context.Context) ([]ListingsByCategory,
 	rows, err := r.db.Query(ctx, `
 		SELECT category, COUNT(*) as count
 		FROM listings
+		WHERE status <> 'DRAFT' AND deleted_at IS NULL
 		GROUP BY category
 		ORDER BY count DESC
 	`)
@@ -142,3 +147,47 @@ func (r *repo) GetFlagsByReason(ctx context.Context) ([]FlagsByReason, error) {
 	}
 	return results, rows.Err()
 }
+
+func (r *repo) GetSalesStats(ctx context.Context) (SalesStats, error) {
+	var stats SalesStats
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(received_at), COALESCE(SUM(price), 0)
+		FROM transactions
+	`).Scan(&stats.TotalSales, &stats.ConfirmedSales, &stats.GrossValue)
+	return stats, err
+}
+
+func (r *repo) GetSalesByCategory(ctx context.Context) ([]SalesByCategory, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT category, COUNT(*) as count, COALESCE(SUM(price), 0) as gross_value
+		FROM transactions
+		GROUP BY category
+		ORDER BY count DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SalesByCategory
+	for rows.Next() {
+		var item SalesByCategory
+		if err := rows.Scan(&item.Category, &item.Count, &item.GrossValue); err != nil {
+			return nil, err
+		}
+		results = append(results, item)
+	}
+	return results, rows.Err()
+}
+
+func (r *repo) GetSellerStats(ctx context.Context, sellerID string) (SellerStats, error) {
+	stats := SellerStats{SellerID: sellerID}
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM transactions WHERE seller_id = $1::uuid),
+			(SELECT COUNT(*) FROM transactions WHERE seller_id = $1::uuid AND received_at IS NOT NULL),
+			(SELECT COUNT(*) FROM listings WHERE user_id = $1::uuid AND status IN ('AVAILABLE', 'PENDING') AND deleted_at IS NULL),
+			(SELECT MAX(completed_at) FROM transactions WHERE seller_id = $1::uuid)
+	`, sellerID).Scan(&stats.SalesCount, &stats.ConfirmedSales, &stats.ActiveListings, &stats.LastSaleAt)
+	return stats, err
+}
@@ -4,6 +4,7 @@ import "context"
 
 type Service interface {
 	GetAnalytics(ctx context.Context) (*AnalyticsResponse, error)
+	GetSellerStats(ctx context.Context, sellerID string) (*SellerStats, error)
 }
 
 type service struct {
@@ -58,6 +59,16 @@ func (s *service) GetAnalytics(ctx context.Context) (*AnalyticsResponse, error)
 		return nil, err
 	}
 
+	sales, err := s.repo.GetSalesStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	salesByCategory, err := s.repo.GetSalesByCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure all slices are non-nil (empty slices instead of nil)
 	if listingsByStatus == nil {
 		listingsByStatus = []ListingsByStatus{}
@@ -71,6 +82,9 @@ func (s *service) GetAnalytics(ctx context.Context) (*AnalyticsResponse, error)
 	if flagsByReason == nil {
 		flagsByReason = []FlagsByReason{}
 	}
+	if salesByCategory == nil {
+		salesByCategory = []SalesByCategory{}
+	}
 
 	return &AnalyticsResponse{
 		Overview: OverviewStats{
@@ -83,5 +97,15 @@ func (s *service) GetAnalytics(ctx context.Context) (*AnalyticsResponse, error)
 		ListingsByCategory: listingsByCategory,
 		FlagsByStatus:      flagsByStatus,
 		FlagsByReason:      flagsByReason,
+		Sales:              sales,
+		SalesByCategory:    salesByCategory,
 	}, nil
 }
+
+func (s *service) GetSellerStats(ctx context.Context, sellerID string) (*SellerStats, error) {
+	stats, err := s.repo.GetSellerStats(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+)
+
+type Endpoints struct {
+	service Service
+}
+
+func NewEndpoints(service Service) *Endpoints {
+	return &Endpoints{
+		service: service,
+	}
+}
+
+// checkAdminRole checks if the current user is an admin
+func checkAdminRole(r *http.Request) (bool, string) {
+	userRole, ok := r.Context().Value(httplib.ContextKey("userRole")).(string)
+	if !ok || userRole != "0" {
+		return false, userRole
+	}
+	return true, userRole
+}
+
+// ListAuditEventsHandler handles listing audit events (admin only).
+// Filters: actor_id, action, target_type, target_id, since, until (RFC 3339),
+// limit and offset.
+func (e *Endpoints) ListAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	// Check if user is admin
+	isAdmin, _ := checkAdminRole(r)
+	if !isAdmin {
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
+		return
+	}
+
+	q := r.URL.Query()
+	f := ListFilter{
+		ActorUserID: q.Get("actor_id"),
+		Action:      q.Get("action"),
+		TargetType:  q.Get("target_type"),
+		TargetID:    q.Get("target_id"),
+	}
+
+	var fields []httplib.FieldError
+	for _, p := range []struct {
+		name   string
+		target **time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				fields = append(fields, httplib.FieldError{Field: p.name, Code: "invalid", Message: "must be an RFC 3339 timestamp"})
+				continue
+			}
+			*p.target = &t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			fields = append(fields, httplib.FieldError{Field: "limit", Code: "invalid", Message: "must be a positive integer"})
+		}
+		f.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fields = append(fields, httplib.FieldError{Field: "offset", Code: "invalid", Message: "must be a non-negative integer"})
+		}
+		f.Offset = n
+	}
+	if len(fields) > 0 {
+		httplib.WriteProblem(w, httplib.ValidationProblem("Invalid audit filters", fields...))
+		return
+	}
+
+	// Call service
+	response, err := e.service.List(r.Context(), f)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch audit events", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// RegisterRoutes registers all audit routes with proper middleware
+func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
+	// Admin-only route: requires auth + role injection
+	protected := func(h http.Handler) http.Handler {
+		return httplib.AuthMiddleWare(
+			httplib.RoleInjectionMiddleWare(dbPool)(
+				httplib.JSONRequestDecoder(h),
+			),
+		)
+	}
+
+	mux.Handle("GET /api/admin/audit", protected(http.HandlerFunc(e.ListAuditEventsHandler)))
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded by the orchestrator. listing-service records the listing,
+// media and flag actions itself.
+const (
+	ActionUserDelete = "user.delete"
+)
+
+// Event is a row of the append-only audit_events table.
+type Event struct {
+	ID            int64           `json:"id"`
+	ActorUserID   *string         `json:"actor_user_id,omitempty"`
+	ActorRole     string          `json:"actor_role"`
+	Action        string          `json:"action"`
+	TargetType    string          `json:"target_type"`
+	TargetID      string          `json:"target_id"`
+	Reason        *string         `json:"reason,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	CorrelationID *string         `json:"correlation_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// ListFilter narrows GET /api/admin/audit. Empty fields are ignored.
+type ListFilter struct {
+	ActorUserID string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Since       *time.Time
+	Until       *time.Time
+	Limit       int
+	Offset      int
+}
+
+// ListResponse is a page of audit events, newest first
+type ListResponse struct {
+	Events []Event `json:"events"`
+	Count  int     `json:"count"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
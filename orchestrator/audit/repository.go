@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+)
+
+// Execer is satisfied by *pgxpool.Pool and pgx.Tx, so events can be written
+// in the same transaction as the change they describe.
+type Execer = httplib.Execer
+
+type Repository interface {
+	List(ctx context.Context, f ListFilter) ([]Event, int, error)
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+// Record writes a privileged action performed by the user in ctx. before and
+// after are JSON-encoded snapshots of the target and may be nil.
+func Record(ctx context.Context, db Execer, action, targetType, targetID, reason string, before, after any) error {
+	actorID, _ := ctx.Value(httplib.ContextKey("userId")).(string)
+	actorRole, _ := ctx.Value(httplib.ContextKey("userRole")).(string)
+	return httplib.RecordAudit(ctx, db, httplib.AuditEvent{
+		ActorUserID: actorID,
+		ActorRole:   actorRole,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      reason,
+		Before:      before,
+		After:       after,
+	})
+}
+
+func (r *repo) List(ctx context.Context, f ListFilter) ([]Event, int, error) {
+	var where []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.ActorUserID != "" {
+		add("actor_user_id = $%d::uuid", f.ActorUserID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.Since != nil {
+		add("created_at >= $%d", *f.Since)
+	}
+	if f.Until != nil {
+		add("created_at < $%d", *f.Until)
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_user_id::text, actor_role, action, target_type, target_id, reason, before, after, correlation_id, created_at
+		FROM audit_events%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT %d OFFSET %d
+	`, whereClause, f.Limit, f.Offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.ActorUserID, &e.ActorRole, &e.Action, &e.TargetType, &e.TargetID,
+			&e.Reason, &e.Before, &e.After, &e.CorrelationID, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
@@ -0,0 +1,44 @@
+package audit
+
+import "context"
+
+type Service interface {
+	List(ctx context.Context, f ListFilter) (*ListResponse, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+func (s *service) List(ctx context.Context, f ListFilter) (*ListResponse, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	if f.Limit > 200 {
+		f.Limit = 200
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	events, total, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []Event{}
+	}
+
+	return &ListResponse{
+		Events: events,
+		Count:  total,
+		Limit:  f.Limit,
+		Offset: f.Offset,
+	}, nil
+}
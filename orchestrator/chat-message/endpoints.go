@@ -27,10 +27,7 @@ func (e *Endpoints) GetUndeliveredMessagesHandler(w http.ResponseWriter, r *http
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -45,10 +42,7 @@ func (e *Endpoints) GetUndeliveredMessagesHandler(w http.ResponseWriter, r *http
 			})
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch undelivered messages",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch undelivered messages", err)
 		return
 	}
 
@@ -67,10 +61,7 @@ func (e *Endpoints) FetchAndRepublishUndeliveredMessagesHandler(w http.ResponseW
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -89,10 +80,7 @@ func (e *Endpoints) FetchAndRepublishUndeliveredMessagesHandler(w http.ResponseW
 			})
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch undelivered messages",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch undelivered messages", err)
 		return
 	}
 
@@ -112,10 +100,7 @@ func (e *Endpoints) GetConversationsHandler(w http.ResponseWriter, r *http.Reque
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -129,10 +114,7 @@ func (e *Endpoints) GetConversationsHandler(w http.ResponseWriter, r *http.Reque
 			})
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch conversations",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch conversations", err)
 		return
 	}
 
@@ -149,10 +131,7 @@ func (e *Endpoints) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -161,19 +140,13 @@ func (e *Endpoints) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	parts := strings.Split(path, "/")
 	if len(parts) < 5 {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Other user ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Other user ID is required")
 		return
 	}
 	otherUserID := parts[len(parts)-1]
 
 	if otherUserID == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Other user ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Other user ID is required")
 		return
 	}
 
@@ -187,10 +160,7 @@ func (e *Endpoints) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch messages",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch messages", err)
 		return
 	}
 
@@ -207,10 +177,7 @@ func (e *Endpoints) GetConversationsWithUndeliveredCountHandler(w http.ResponseW
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -223,10 +190,7 @@ func (e *Endpoints) GetConversationsWithUndeliveredCountHandler(w http.ResponseW
 			})
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch conversations with undelivered count",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch conversations with undelivered count", err)
 		return
 	}
 
@@ -254,4 +218,3 @@ func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
 	// Get conversations with undelivered count endpoint (requires auth but not role injection)
 	mux.Handle("GET /api/chat/conversations-with-undelivered-count", httplib.AuthMiddleWare(http.HandlerFunc(e.GetConversationsWithUndeliveredCountHandler)))
 }
-
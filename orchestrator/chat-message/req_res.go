@@ -3,7 +3,7 @@ package chatmessage
 // Get Undelivered Messages Response
 type GetUndeliveredMessagesResponse struct {
 	Messages []map[string]interface{} `json:"messages"`
-	Count    int                       `json:"count"`
+	Count    int                      `json:"count"`
 }
 
 // Fetch and Republish Undelivered Messages Response
@@ -21,17 +21,10 @@ type GetConversationsResponse struct {
 // Get Messages Response
 type GetMessagesResponse struct {
 	Messages []ChatMessage `json:"messages"`
-	Count    int            `json:"count"`
+	Count    int           `json:"count"`
 }
 
 // Get Conversations With Undelivered Count Response
 type GetConversationsWithUndeliveredCountResponse struct {
 	Count int `json:"count"`
 }
-
-// Error Response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/kunal768/cmpe202/orchestrator/internal/queue"
+	"github.com/kunal768/cmpe202/orchestrator/offers"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -168,7 +169,7 @@ func (s *svc) GetConversations(ctx context.Context, userID string) ([]Conversati
 		if _, exists := conversationsMap[otherUserID]; !exists {
 			conversationsMap[otherUserID] = &Conversation{
 				OtherUserID:   otherUserID,
-				LastMessage:   msg.Content,
+				LastMessage:   previewText(msg),
 				LastTimestamp: msg.Timestamp,
 				UnreadCount:   0, // Will be calculated below
 				IsLastFromMe:  isLastFromMe,
@@ -210,6 +211,19 @@ func (s *svc) GetConversations(ctx context.Context, userID string) ([]Conversati
 	return conversations, nil
 }
 
+// previewText returns the conversation preview of msg. Offer events carry
+// JSON content, so their plain summary is shown instead.
+func previewText(msg ChatMessage) string {
+	if msg.Type != offers.MessageTypeOffer {
+		return msg.Content
+	}
+	var ev offers.Event
+	if err := json.Unmarshal([]byte(msg.Content), &ev); err != nil || ev.Text == "" {
+		return msg.Content
+	}
+	return ev.Text
+}
+
 // GetMessages returns all messages between two users, sorted chronologically
 func (s *svc) GetMessages(ctx context.Context, userID, otherUserID string) ([]ChatMessage, error) {
 	if s.mongoClient == nil {
@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type redisSvc struct {
+	addr     string
+	password string
+	db       int
+}
+
+type RedisService interface {
+	Connect() (*goredis.Client, error)
+}
+
+func NewRedisService(addr, password string, db int) RedisService {
+	return &redisSvc{addr: addr, password: password, db: db}
+}
+
+func (s *redisSvc) Connect() (*goredis.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     s.addr,
+		Password: s.password,
+		DB:       s.db,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
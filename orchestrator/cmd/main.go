@@ -6,20 +6,46 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	httplib "github.com/kunal768/cmpe202/http-lib"
 	"github.com/kunal768/cmpe202/orchestrator/analytics"
+	"github.com/kunal768/cmpe202/orchestrator/audit"
 	chatmessage "github.com/kunal768/cmpe202/orchestrator/chat-message"
 	dbclient "github.com/kunal768/cmpe202/orchestrator/clients/db"
 	mongoclient "github.com/kunal768/cmpe202/orchestrator/clients/mongo"
+	redisclient "github.com/kunal768/cmpe202/orchestrator/clients/redis"
+	"github.com/kunal768/cmpe202/orchestrator/courses"
+	"github.com/kunal768/cmpe202/orchestrator/engagement"
+	"github.com/kunal768/cmpe202/orchestrator/internal/mail"
+	"github.com/kunal768/cmpe202/orchestrator/internal/notify"
 	"github.com/kunal768/cmpe202/orchestrator/internal/queue"
 	"github.com/kunal768/cmpe202/orchestrator/listings"
+	"github.com/kunal768/cmpe202/orchestrator/offers"
+	"github.com/kunal768/cmpe202/orchestrator/pricedrops"
+	"github.com/kunal768/cmpe202/orchestrator/reminders"
+	"github.com/kunal768/cmpe202/orchestrator/revisions"
+	"github.com/kunal768/cmpe202/orchestrator/scheduled"
+	"github.com/kunal768/cmpe202/orchestrator/searches"
 	"github.com/kunal768/cmpe202/orchestrator/users"
+	goredis "github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
+	// Deferred first so it runs last: a failed serve or drain exits non-zero
+	// once the other deferred closes have released their connections
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	// Load environment variables from .env if present (current dir, then parent)
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found; continuing with environment variables")
@@ -69,6 +95,36 @@ func main() {
 		defer pub.Close()
 	}
 
+	// Connect to Redis for distributed rate limiting and idempotency keys (optional)
+	var rateLimiter *httplib.RateLimiter
+	var idempotency *httplib.Idempotency
+	var rc *goredis.Client
+	var notifier notify.Publisher
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr != "" {
+		redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+		rc, err = redisclient.NewRedisService(redisAddr, os.Getenv("REDIS_PASSWORD"), redisDB).Connect()
+		if err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		defer rc.Close()
+		// Only these proxies may report the client address in X-Forwarded-For
+		trustedProxies, err := httplib.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+		if err != nil {
+			log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+		}
+		rateLimiter = httplib.NewRateLimiter(rc, trustedProxies)
+		idempotency = httplib.NewIdempotency(httplib.NewRedisIdempotencyStore(rc))
+		notifier = notify.NewRedisPublisher(rc)
+		log.Println("Connected to REDIS_ADDR; rate limiting, idempotency keys and notifications enabled")
+	}
+
+	// SMTP relay for email digests (optional)
+	var mailer mail.Mailer
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mailer = mail.NewSMTPMailer(smtpAddr, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+	}
+
 	// Create user service and endpoints. Publisher is no longer needed for users service.
 	userService := users.NewService(userRepo, publisher)
 	userEndpoints := users.NewEndpoints(userService)
@@ -77,17 +133,47 @@ func main() {
 	chatService := chatmessage.NewChatService(mc, publisher)
 	chatEndpoints := chatmessage.NewEndpoints(chatService)
 
-	// Create listing service and endpoints
+	// Create saved search service and endpoints
+	searchRepo := searches.NewRepository(dbPool)
+	searchService := searches.NewService(searchRepo, notifier, mailer, os.Getenv("PUBLIC_APP_URL"))
+	searchEndpoints := searches.NewEndpoints(searchService)
+
+	// Price drops are announced to users who saved the listing
+	priceDropService := pricedrops.NewService(pricedrops.NewRepository(dbPool), notifier)
+
+	// Buyers talking to a seller are told when the listing is edited (optional)
+	listingWatchers := listings.Watchers{searchService, priceDropService}
+	if os.Getenv("LISTING_CHANGE_NOTICES") == "true" {
+		listingWatchers = append(listingWatchers, revisions.NewService(revisions.NewRepository(dbPool), notifier))
+	}
+
+	// Create listing service and endpoints; saved searches, price drop
+	// alerts and change notices watch listing writes
 	baseUrl := os.Getenv("LISTING_SERVICE_URL")
 	sharedSecret := os.Getenv("LISTING_SERVICE_SHARED_SECRET")
 	listingService := listings.NewListingService(baseUrl, sharedSecret)
-	listingEndpoints := listings.NewEndpoints(listingService)
+	// Views and chats started rank the trending feed; views are counted in
+	// Redis and flushed to Postgres in batches
+	engagementService := engagement.NewService(engagement.NewRepository(dbPool), rc)
+	listingEndpoints := listings.NewEndpoints(listingService, rateLimiter, idempotency, listingWatchers, engagementService)
+
+	// Create offer service and endpoints; offer events are posted to chat
+	offerService := offers.NewService(listingService, publisher)
+	offerEndpoints := offers.NewEndpoints(offerService, idempotency)
+
+	// Course catalog endpoints proxy listing-service
+	courseEndpoints := courses.NewEndpoints(listingService)
 
 	// Create analytics service and endpoints
 	analyticsRepo := analytics.NewRepository(dbPool)
 	analyticsService := analytics.NewService(analyticsRepo)
 	analyticsEndpoints := analytics.NewEndpoints(analyticsService)
 
+	// Create audit log service and endpoints
+	auditRepo := audit.NewRepository(dbPool)
+	auditService := audit.NewService(auditRepo)
+	auditEndpoints := audit.NewEndpoints(auditService)
+
 	// Setup HTTP server
 	mux := http.NewServeMux()
 
@@ -103,14 +189,34 @@ func main() {
 	// Register analytics routes with middleware
 	analyticsEndpoints.RegisterRoutes(mux, dbPool)
 
-	// Health check endpoint
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Register admin audit log routes with middleware
+	auditEndpoints.RegisterRoutes(mux, dbPool)
+
+	// Register saved search routes with middleware
+	searchEndpoints.RegisterRoutes(mux, dbPool)
 
-	// Wrap the mux with CORS middleware
-	handler := httplib.CORSMiddleware(mux)
+	// Register offer routes with middleware
+	offerEndpoints.RegisterRoutes(mux, dbPool)
+
+	// Register course catalog routes with middleware
+	courseEndpoints.RegisterRoutes(mux, dbPool)
+
+	// Liveness and dependency-aware readiness probes
+	health := httplib.NewHealth(2 * time.Second)
+	health.Register("postgres", dbPool.Ping)
+	if mc != nil {
+		health.Register("mongo", func(ctx context.Context) error { return mc.Ping(ctx, nil) })
+	}
+	if pub, ok := publisher.(*queue.RabbitMQPublisher); ok {
+		health.Register("rabbitmq", pub.Ping)
+	}
+	if rc != nil {
+		health.Register("redis", func(ctx context.Context) error { return rc.Ping(ctx).Err() })
+	}
+	health.RegisterHealthRoutes(mux)
+
+	// Wrap the mux with CORS and correlation id middleware
+	handler := httplib.CORSMiddleware(httplib.CorrelationIDMiddleware(mux))
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -118,6 +224,51 @@ func main() {
 		port = "8080"
 	}
 
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: handler,
+	}
+
+	// Stop accepting traffic on SIGINT/SIGTERM and drain in-flight requests
+	// before the deferred closes release Postgres, Mongo, RabbitMQ and Redis.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Email saved search matches in the background until shutdown
+	digestInterval, err := time.ParseDuration(os.Getenv("SAVED_SEARCH_DIGEST_INTERVAL"))
+	if err != nil || digestInterval <= 0 {
+		digestInterval = 24 * time.Hour
+	}
+	go searchService.RunDigests(ctx, digestInterval)
+
+	// Remind owners to renew listings before they are archived
+	reminderInterval, err := time.ParseDuration(os.Getenv("LISTING_EXPIRY_REMINDER_INTERVAL"))
+	if err != nil || reminderInterval <= 0 {
+		reminderInterval = time.Hour
+	}
+	reminderLead, _ := time.ParseDuration(os.Getenv("LISTING_EXPIRY_REMINDER_LEAD"))
+	reminderService := reminders.NewService(reminders.NewRepository(dbPool), notifier, mailer, reminderLead, os.Getenv("PUBLIC_APP_URL"))
+	go reminderService.Run(ctx, reminderInterval)
+
+	// Flush counted listing views to Postgres
+	viewFlushInterval, err := time.ParseDuration(os.Getenv("LISTING_VIEW_FLUSH_INTERVAL"))
+	if err != nil || viewFlushInterval <= 0 {
+		viewFlushInterval = time.Minute
+	}
+	go engagementService.Run(ctx, viewFlushInterval)
+
+	// Run the listing watchers for drafts listing-service published on schedule
+	publishNoticeInterval, err := time.ParseDuration(os.Getenv("LISTING_PUBLISH_NOTICE_INTERVAL"))
+	if err != nil || publishNoticeInterval <= 0 {
+		publishNoticeInterval = time.Minute
+	}
+	go scheduled.NewService(scheduled.NewRepository(dbPool), listingWatchers).Run(ctx, publishNoticeInterval)
+
 	fmt.Printf("Server starting on port %s\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+	if err := httplib.ListenAndServeGracefully(ctx, srv, health, httplib.PreStopDelayFromEnv(), 20*time.Second); err != nil {
+		log.Printf("Server did not shut down cleanly: %v", err)
+		exitCode = 1
+		return
+	}
+	log.Println("Server stopped")
 }
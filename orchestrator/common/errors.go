@@ -3,6 +3,8 @@ package common
 import (
 	"errors"
 	"fmt"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
 )
 
 // Predefined sentinel errors for common flows
@@ -50,6 +52,12 @@ func (e *AppError) Error() string {
 
 func (e *AppError) Unwrap() error { return e.Err }
 
+// Problem describes the error as RFC 7807 problem details so it can be
+// written with httplib.WriteServiceError.
+func (e *AppError) Problem() *httplib.Problem {
+	return httplib.NewCodedProblem(e.HTTPStatus, e.Code, e.Message)
+}
+
 // NewAppError creates a new AppError
 func NewAppError(code string, httpStatus int, msg string, err error) *AppError {
 	return &AppError{Code: code, Message: msg, HTTPStatus: httpStatus, Err: err}
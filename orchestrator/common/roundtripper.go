@@ -2,6 +2,8 @@ package common
 
 import (
 	"net/http"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
 )
 
 // defaultHeaderTransport implements the http.RoundTripper interface
@@ -20,6 +22,10 @@ func (t *DefaultHeaderTransport) RoundTrip(req *http.Request) (*http.Response, e
 			req.Header.Add(key, value)
 		}
 	}
+	// Propagate the caller's correlation id so errors can be traced across services
+	if id := httplib.CorrelationIDFromContext(req.Context()); id != "" && req.Header.Get(httplib.HeaderCorrelationID) == "" {
+		req.Header.Set(httplib.HeaderCorrelationID, id)
+	}
 	// Use the underlying transport to execute the request
 	return t.Transport.RoundTrip(req)
 }
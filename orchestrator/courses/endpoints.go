@@ -0,0 +1,91 @@
+package courses
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+// maxImportSize caps the CSV body of a catalog import
+const maxImportSize = 1 << 20
+
+// Endpoints serve the course catalog, which listing-service owns
+type Endpoints struct {
+	listings listings.Service
+}
+
+func NewEndpoints(listingService listings.Service) *Endpoints {
+	return &Endpoints{
+		listings: listingService,
+	}
+}
+
+// GetCourseHandler returns a course with its required and optional books
+func (e *Endpoints) GetCourseHandler(w http.ResponseWriter, r *http.Request) {
+	course, err := e.listings.FetchCourse(r.Context(), r.PathValue("code"))
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch course", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, course)
+}
+
+// GetCourseListingsHandler returns the available listings of a course's books
+func (e *Endpoints) GetCourseListingsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := listings.FetchCourseListingsRequest{Code: r.PathValue("code")}
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		req.Cursor = &cursor
+	}
+	if sort := q.Get("sort"); sort != "" {
+		req.Sort = &sort
+	}
+
+	response, err := e.listings.FetchCourseListings(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch course listings", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// ImportCoursesHandler adds or replaces catalog courses from a CSV body with
+// the columns code,title,required_isbns,optional_isbns (admin only)
+func (e *Endpoints) ImportCoursesHandler(w http.ResponseWriter, r *http.Request) {
+	role, ok := r.Context().Value(httplib.ContextKey("userRole")).(string)
+	if !ok || role != string(httplib.ADMIN) {
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
+		return
+	}
+
+	response, err := e.listings.ImportCourses(r.Context(), http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to import courses", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// RegisterRoutes registers all course routes with proper middleware
+func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
+	// Protected routes: require auth + role injection
+	protected := func(h http.Handler) http.Handler {
+		return httplib.AuthMiddleWare(
+			httplib.RoleInjectionMiddleWare(dbPool)(
+				httplib.JSONRequestDecoder(h),
+			),
+		)
+	}
+
+	mux.Handle("GET /api/courses/{code}", protected(http.HandlerFunc(e.GetCourseHandler)))
+	mux.Handle("GET /api/courses/{code}/listings", protected(http.HandlerFunc(e.GetCourseListingsHandler)))
+	mux.Handle("POST /api/courses/import", protected(http.HandlerFunc(e.ImportCoursesHandler)))
+}
@@ -0,0 +1,21 @@
+package engagement
+
+import "time"
+
+// ViewSessionTTL is how long a viewer's visits to a listing count as one view
+const ViewSessionTTL = 30 * time.Minute
+
+// staleFlushAge is how long a flushing hash may exist before it is taken for
+// abandoned, left behind by a flush that crashed or lost Redis before it
+// finished, and its counts are put back into the pending hash.
+const staleFlushAge = 10 * time.Minute
+
+// Redis keys of the view counter. Seen keys de-duplicate views per listing
+// and viewer; the pending hash counts views per listing until they are
+// flushed to Postgres. A flush renames the pending hash to
+// "<flushingKeyBase>:<unix seconds>:<uuid>" while it writes the counts.
+const (
+	seenKeyPrefix   = "listing-views:seen"
+	pendingKey      = "listing-views:pending"
+	flushingKeyBase = "listing-views:flushing"
+)
@@ -0,0 +1,58 @@
+package engagement
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// AddViews adds view counts per listing to the current hour. Listings
+	// deleted since they were viewed are skipped.
+	AddViews(ctx context.Context, views map[int64]int64) error
+	// RecordChat records that a user contacted the seller of a listing;
+	// only the first contact counts
+	RecordChat(ctx context.Context, listingID int64, userID string) error
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+func (r *repo) AddViews(ctx context.Context, views map[int64]int64) error {
+	ids := make([]int64, 0, len(views))
+	counts := make([]int64, 0, len(views))
+	for id, n := range views {
+		ids = append(ids, id)
+		counts = append(counts, n)
+	}
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO listing_views (listing_id, bucket, views)
+		SELECT v.id, date_trunc('hour', NOW()), v.n
+		FROM unnest($1::bigint[], $2::bigint[]) AS v(id, n)
+		WHERE v.n > 0 AND EXISTS (SELECT 1 FROM listings l WHERE l.id = v.id AND l.deleted_at IS NULL)
+		ON CONFLICT (listing_id, bucket) DO UPDATE SET views = listing_views.views + EXCLUDED.views
+	`, ids, counts)
+	if err != nil {
+		return fmt.Errorf("failed to save listing views: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) RecordChat(ctx context.Context, listingID int64, userID string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO listing_chats (listing_id, user_id) VALUES ($1, $2::uuid)
+		ON CONFLICT (listing_id, user_id) DO NOTHING
+	`, listingID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record listing chat: %w", err)
+	}
+	return nil
+}
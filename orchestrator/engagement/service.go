@@ -0,0 +1,184 @@
+package engagement
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type Service interface {
+	// RecordView counts a view of a listing, at most once per viewer every
+	// ViewSessionTTL. Failures are logged rather than returned so a view
+	// never fails the page.
+	RecordView(ctx context.Context, listingID int64, viewerID string)
+	// RecordChat records that a user contacted the seller of a listing
+	RecordChat(ctx context.Context, listingID int64, userID string) error
+	// Run flushes counted views to Postgres every interval until ctx is done
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type service struct {
+	repo Repository
+	rc   *goredis.Client
+}
+
+// NewService creates the engagement service. Views are only counted when rc
+// is set; chats started are recorded either way.
+func NewService(repo Repository, rc *goredis.Client) Service {
+	return &service{
+		repo: repo,
+		rc:   rc,
+	}
+}
+
+func (s *service) RecordView(ctx context.Context, listingID int64, viewerID string) {
+	if s.rc == nil {
+		return
+	}
+	seen := fmt.Sprintf("%s:%d:%s", seenKeyPrefix, listingID, viewerID)
+	first, err := s.rc.SetNX(ctx, seen, 1, ViewSessionTTL).Result()
+	if err != nil {
+		log.Printf("Failed to de-duplicate view of listing %d: %v", listingID, err)
+		return
+	}
+	if !first {
+		return
+	}
+	if err := s.rc.HIncrBy(ctx, pendingKey, strconv.FormatInt(listingID, 10), 1).Err(); err != nil {
+		log.Printf("Failed to count view of listing %d: %v", listingID, err)
+	}
+}
+
+func (s *service) RecordChat(ctx context.Context, listingID int64, userID string) error {
+	return s.repo.RecordChat(ctx, listingID, userID)
+}
+
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	if s.rc == nil {
+		log.Println("No Redis configured; listing view counting disabled")
+		return
+	}
+	s.sweep(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	sweeper := time.NewTicker(staleFlushAge)
+	defer sweeper.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.flush(ctx); err != nil {
+				log.Printf("Failed to flush listing views: %v", err)
+			}
+		case <-sweeper.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// restoreScript adds the counts of a flushing hash (KEYS[1]) back to the
+// pending hash (KEYS[2]) and deletes it in one step, returning how many
+// listings it restored.
+var restoreScript = goredis.NewScript(`
+local fields = redis.call('HGETALL', KEYS[1])
+for i = 1, #fields, 2 do
+  redis.call('HINCRBY', KEYS[2], fields[i], fields[i + 1])
+end
+redis.call('DEL', KEYS[1])
+return #fields / 2
+`)
+
+// restore puts the counts of the flushing hash back into the pending hash so
+// the next flush picks them up.
+func (s *service) restore(ctx context.Context, flushing string) {
+	if err := restoreScript.Run(ctx, s.rc, []string{flushing, pendingKey}).Err(); err != nil {
+		log.Printf("Failed to restore pending listing views from %s: %v", flushing, err)
+	}
+}
+
+// flush moves the pending view counts to Postgres in one batch. The hash is
+// renamed to a key of this flush first, so views counted meanwhile start a
+// new hash and several orchestrators can flush without counting twice. When
+// reading the counts or Postgres fails they are added back to the pending
+// hash; if even that fails, sweep restores them later.
+func (s *service) flush(ctx context.Context) error {
+	flushing := fmt.Sprintf("%s:%d:%s", flushingKeyBase, time.Now().Unix(), uuid.NewString())
+	if err := s.rc.Rename(ctx, pendingKey, flushing).Err(); err != nil {
+		// Nothing was viewed since the last flush
+		if goredis.HasErrorPrefix(err, "no such key") {
+			return nil
+		}
+		return fmt.Errorf("failed to claim pending views: %w", err)
+	}
+
+	fields, err := s.rc.HGetAll(ctx, flushing).Result()
+	if err != nil {
+		s.restore(ctx, flushing)
+		return fmt.Errorf("failed to read pending views: %w", err)
+	}
+	views := make(map[int64]int64, len(fields))
+	for field, value := range fields {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		views[id] = n
+	}
+
+	if err := s.repo.AddViews(ctx, views); err != nil {
+		s.restore(ctx, flushing)
+		return err
+	}
+	return s.rc.Del(ctx, flushing).Err()
+}
+
+// sweep restores the flushing hashes of flushes that never finished, such as
+// those of an orchestrator that crashed mid-flush. Hashes younger than
+// staleFlushAge may belong to a flush still running elsewhere and are left
+// alone.
+func (s *service) sweep(ctx context.Context) {
+	iter := s.rc.Scan(ctx, 0, flushingKeyBase+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if started, ok := flushStartedAt(key); ok && time.Since(started) < staleFlushAge {
+			continue
+		}
+		n, err := restoreScript.Run(ctx, s.rc, []string{key, pendingKey}).Int()
+		if err != nil {
+			log.Printf("Failed to restore abandoned listing views from %s: %v", key, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Restored view counts of %d listings from abandoned flush %s", n, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("Failed to scan for abandoned listing view flushes: %v", err)
+	}
+}
+
+// flushStartedAt reads the start time out of a flushing key. Keys without
+// one predate the timestamp and are always old enough to sweep.
+func flushStartedAt(key string) (time.Time, bool) {
+	rest, _ := strings.CutPrefix(key, flushingKeyBase+":")
+	ts, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Notification sub types understood by the frontend
+const (
+	SubTypeSavedSearch = "saved_search"
+	SubTypePriceDrop   = "price_drop"
+	// SubTypeListingExpiring reminds an owner to renew a listing
+	SubTypeListingExpiring = "listing_expiring"
+	// SubTypeListingChanged tells buyers a listing they are discussing was edited
+	SubTypeListingChanged = "listing_changed"
+)
+
+// Notification is pushed to a user's events-server channel and delivered over
+// their WebSocket as {"type": "notification", "subType", "count", "data"}.
+type Notification struct {
+	SubType string
+	Count   int
+	Data    any
+}
+
+// Publisher defines a minimal interface for notifying online users
+type Publisher interface {
+	Notify(ctx context.Context, userID string, n Notification) error
+}
+
+// RedisPublisher publishes notifications on the per-user Redis channel that
+// events-server subscribes to for every connected client.
+type RedisPublisher struct {
+	client *redis.Client
+}
+
+// NewRedisPublisher creates a publisher on an existing Redis client
+func NewRedisPublisher(client *redis.Client) *RedisPublisher {
+	return &RedisPublisher{client: client}
+}
+
+// Notify publishes n to userID. Offline users simply miss it.
+func (p *RedisPublisher) Notify(ctx context.Context, userID string, n Notification) error {
+	msg, err := json.Marshal(map[string]any{
+		"type":        "notification",
+		"subType":     n.SubType,
+		"count":       n.Count,
+		"recipientId": userID,
+		"data":        n.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	channel := fmt.Sprintf("user:%s:messages", userID)
+	if err := p.client.Publish(ctx, channel, msg).Err(); err != nil {
+		return fmt.Errorf("failed to publish notification to user %s: %w", userID, err)
+	}
+	return nil
+}
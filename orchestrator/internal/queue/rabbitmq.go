@@ -135,6 +135,20 @@ func (p *RabbitMQPublisher) Publish(ctx context.Context, message []byte) error {
 	return nil
 }
 
+// Ping reports whether the publisher currently holds an open connection and channel
+func (p *RabbitMQPublisher) Ping(ctx context.Context) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return fmt.Errorf("publisher is closed")
+	}
+	if p.conn == nil || p.conn.IsClosed() || p.channel == nil || p.channel.IsClosed() {
+		return fmt.Errorf("not connected to RabbitMQ")
+	}
+	return nil
+}
+
 // Close gracefully closes the publisher
 func (p *RabbitMQPublisher) Close() error {
 	p.mu.Lock()
@@ -1,32 +1,94 @@
 package listings
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	httplib "github.com/kunal768/cmpe202/http-lib"
 )
 
+// ListingWatcher is told about every listing created or updated through the
+// orchestrator, e.g. to alert saved searches it now matches.
+type ListingWatcher interface {
+	ListingChanged(ctx context.Context, listing Listing)
+}
+
+// Watchers fans a listing change out to several watchers
+type Watchers []ListingWatcher
+
+func (ws Watchers) ListingChanged(ctx context.Context, listing Listing) {
+	for _, w := range ws {
+		w.ListingChanged(ctx, listing)
+	}
+}
+
+// EngagementTracker records the views and chats started that rank the
+// trending feed.
+type EngagementTracker interface {
+	RecordView(ctx context.Context, listingID int64, viewerID string)
+	RecordChat(ctx context.Context, listingID int64, userID string) error
+}
+
 type Endpoints struct {
-	service Service
+	service     Service
+	limiter     *httplib.RateLimiter
+	idempotency *httplib.Idempotency
+	watcher     ListingWatcher
+	engagement  EngagementTracker
 }
 
-func NewEndpoints(service Service) *Endpoints {
+// NewEndpoints creates listing endpoints. limiter, idempotency, watcher and
+// engagement may be nil to disable rate limiting, Idempotency-Key replay,
+// listing change hooks and view tracking respectively.
+func NewEndpoints(service Service, limiter *httplib.RateLimiter, idempotency *httplib.Idempotency, watcher ListingWatcher, engagement EngagementTracker) *Endpoints {
 	return &Endpoints{
-		service: service,
+		service:     service,
+		limiter:     limiter,
+		idempotency: idempotency,
+		watcher:     watcher,
+		engagement:  engagement,
 	}
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+// listingChanged notifies the watcher, if any
+func (e *Endpoints) listingChanged(ctx context.Context, listing *Listing) {
+	if e.watcher != nil && listing != nil {
+		e.watcher.ListingChanged(ctx, *listing)
+	}
 }
 
+// Default rate limits for expensive or abuse-prone routes. Each can be
+// overridden via RATE_LIMIT_<ROUTE>_USER / RATE_LIMIT_<ROUTE>_IP, e.g.
+// RATE_LIMIT_LISTINGS_CHATSEARCH_USER="20/1m".
+var (
+	chatSearchRateLimit = httplib.RateLimitRule{
+		Route: "listings-chatsearch",
+		User:  &httplib.RateLimit{Burst: 10, Per: time.Minute},
+		IP:    &httplib.RateLimit{Burst: 30, Per: time.Minute},
+	}
+	createListingRateLimit = httplib.RateLimitRule{
+		Route: "listings-create",
+		User:  &httplib.RateLimit{Burst: 20, Per: time.Hour},
+		IP:    &httplib.RateLimit{Burst: 60, Per: time.Hour},
+	}
+	importListingsRateLimit = httplib.RateLimitRule{
+		Route: "listings-import",
+		User:  &httplib.RateLimit{Burst: 5, Per: time.Hour},
+		IP:    &httplib.RateLimit{Burst: 15, Per: time.Hour},
+	}
+	flagListingRateLimit = httplib.RateLimitRule{
+		Route: "listings-flag",
+		User:  &httplib.RateLimit{Burst: 10, Per: time.Hour},
+		IP:    &httplib.RateLimit{Burst: 30, Per: time.Hour},
+	}
+)
+
 // GetAllListingsHandler handles getting all listings with optional filters
 func (e *Endpoints) GetAllListingsHandler(w http.ResponseWriter, r *http.Request) {
 	req := FetchAllListingsRequest{}
@@ -44,6 +106,16 @@ func (e *Endpoints) GetAllListingsHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		req.Cursor = &cursor
+	}
+
+	if includeCountStr := r.URL.Query().Get("include_count"); includeCountStr != "" {
+		if includeCount, err := strconv.ParseBool(includeCountStr); err == nil {
+			req.IncludeCount = &includeCount
+		}
+	}
+
 	if sort := r.URL.Query().Get("sort"); sort != "" {
 		req.Sort = &sort
 	}
@@ -74,13 +146,46 @@ func (e *Endpoints) GetAllListingsHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	if condition := r.URL.Query().Get("condition"); condition != "" {
+		c := Condition(condition)
+		req.Condition = &c
+	}
+
+	if pickup := r.URL.Query().Get("pickup_location"); pickup != "" {
+		req.Pickup = &pickup
+	}
+
+	if tags := r.URL.Query().Get("tags"); tags != "" {
+		req.Tags = &tags
+	}
+
+	if course := r.URL.Query().Get("course"); course != "" {
+		req.Course = &course
+	}
+
+	// Attribute filters (attr.<key>=<value>) are validated by listing-service
+	for key, values := range r.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "attr."); ok && len(values) > 0 {
+			if req.Attributes == nil {
+				req.Attributes = Attributes{}
+			}
+			req.Attributes[name] = values[0]
+		}
+	}
+
+	// Facet names and price buckets are validated by listing-service
+	if facets := r.URL.Query().Get("facets"); facets != "" {
+		req.Facets = &facets
+	}
+
+	if priceBuckets := r.URL.Query().Get("price_buckets"); priceBuckets != "" {
+		req.PriceBuckets = &priceBuckets
+	}
+
 	// Call service
 	response, err := e.service.FetchAllListings(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch listings",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch listings", err)
 		return
 	}
 
@@ -92,19 +197,13 @@ func (e *Endpoints) GetListingByIDHandler(w http.ResponseWriter, r *http.Request
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -113,108 +212,275 @@ func (e *Endpoints) GetListingByIDHandler(w http.ResponseWriter, r *http.Request
 	// Call service
 	response, err := e.service.FetchListing(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-			Error:   "Listing not found",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusNotFound, "Listing not found", err)
 		return
 	}
 
+	// Owners viewing their own listing are not counted
+	userID, _ := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if e.engagement != nil && userID != "" && userID != response.UserID.String() {
+		e.engagement.RecordView(r.Context(), listingID, userID)
+	}
+
+	etag := httplib.VersionETag(response.Version)
+	// The owner's copy carries a view count that changes without a version bump
+	if response.Views == nil && httplib.NotModified(r, etag) {
+		httplib.WriteNotModified(w, etag)
+		return
+	}
+	w.Header().Set("ETag", etag)
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
+// GetTrendingListingsHandler returns the listings with the most recent
+// views, saves and chats started
+func (e *Endpoints) GetTrendingListingsHandler(w http.ResponseWriter, r *http.Request) {
+	req := FetchTrendingRequest{}
+	if category := r.URL.Query().Get("category"); category != "" {
+		cat := Category(category)
+		req.Category = &cat
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
+	}
+
+	response, err := e.service.FetchTrending(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch trending listings", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetSimilarListingsHandler recommends available listings like the one in
+// the URL, leaving out the caller's own
+func (e *Endpoints) GetSimilarListingsHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	req := FetchSimilarRequest{ID: listingID}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
+	}
+
+	response, err := e.service.FetchSimilar(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch similar listings", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetListingHistoryHandler returns the edit history of a listing to its
+// owner or an admin
+func (e *Endpoints) GetListingHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	response, err := e.service.FetchHistory(r.Context(), listingID)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch listing history", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// ContactSellerHandler records that the caller started a chat with the
+// seller of a listing and returns the seller to open the conversation with
+func (e *Endpoints) ContactSellerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if !ok {
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	response, err := e.service.FetchListing(r.Context(), FetchListingRequest{ID: listingID})
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusNotFound, "Listing not found", err)
+		return
+	}
+	if response.UserID.String() == userID {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "You cannot contact yourself about your own listing")
+		return
+	}
+
+	if e.engagement != nil {
+		if err := e.engagement.RecordChat(r.Context(), listingID, userID); err != nil {
+			httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to contact seller", err)
+			return
+		}
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, ContactSellerResponse{SellerID: response.UserID})
+}
+
 // CreateListingHandler handles creating a new listing (requires authentication)
 func (e *Endpoints) CreateListingHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateListingRequest
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if err := validateCreateListingRequest(req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
 	// Call service (context should have userID and role from middleware)
 	response, err := e.service.CreateListing(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to create listing", err)
 		return
 	}
+	e.listingChanged(r.Context(), response.Listing)
 
 	httplib.WriteJSON(w, http.StatusCreated, response)
 }
 
+// ImportListingsHandler creates many listings from a JSON array or a CSV body
+// (Content-Type text/csv); dry_run=true only validates the rows
+func (e *Endpoints) ImportListingsHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	report, err := e.service.ImportListings(r.Context(), r.Body, r.Header.Get("Content-Type"), dryRun)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to import listings", err)
+		return
+	}
+	for _, listing := range report.Listings {
+		e.listingChanged(r.Context(), &listing)
+	}
+
+	status := http.StatusCreated
+	if report.DryRun {
+		status = http.StatusOK
+	}
+	httplib.WriteJSON(w, status, report)
+}
+
+// ExportListingsHandler downloads the caller's listings as JSON or, with
+// format=csv, as CSV
+func (e *Endpoints) ExportListingsHandler(w http.ResponseWriter, r *http.Request) {
+	export, err := e.service.ExportListings(r.Context(), r.URL.Query().Get("format"))
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to export listings", err)
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentType)
+	if export.ContentDisposition != "" {
+		w.Header().Set("Content-Disposition", export.ContentDisposition)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(export.Data)
+}
+
 // UpdateListingHandler handles updating a listing (requires authentication)
 func (e *Endpoints) UpdateListingHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	var updateReq struct {
-		Title       *string   `json:"title,omitempty"`
-		Description *string   `json:"description,omitempty"`
-		Price       *int64    `json:"price,omitempty"`
-		Category    *Category `json:"category,omitempty"`
-		Status      *Status   `json:"status,omitempty"`
+		Title          *string     `json:"title,omitempty"`
+		Description    *string     `json:"description,omitempty"`
+		Price          *int64      `json:"price,omitempty"`
+		Category       *Category   `json:"category,omitempty"`
+		Status         *Status     `json:"status,omitempty"`
+		Condition      *Condition  `json:"condition,omitempty"`
+		PickupLocation *string     `json:"pickup_location,omitempty"`
+		Tags           *[]string   `json:"tags,omitempty"`
+		Attributes     *Attributes `json:"attributes,omitempty"`
+		PublishAt      *time.Time  `json:"publish_at,omitempty"`
 	}
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	req := UpdateListingRequest{
-		ID:          listingID,
-		Title:       updateReq.Title,
-		Description: updateReq.Description,
-		Price:       updateReq.Price,
-		Category:    updateReq.Category,
-		Status:      updateReq.Status,
+		ID:             listingID,
+		Title:          updateReq.Title,
+		Description:    updateReq.Description,
+		Price:          updateReq.Price,
+		Category:       updateReq.Category,
+		Status:         updateReq.Status,
+		Condition:      updateReq.Condition,
+		PickupLocation: updateReq.PickupLocation,
+		Tags:           updateReq.Tags,
+		Attributes:     updateReq.Attributes,
+		PublishAt:      updateReq.PublishAt,
+		IfMatch:        r.Header.Get("If-Match"),
+		Reason:         r.URL.Query().Get("reason"),
 	}
 
 	// Call service
 	response, err := e.service.UpdateListing(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to update listing", err)
 		return
 	}
+	e.listingChanged(r.Context(), response.Listing)
 
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// RenewListingHandler handles renewing a listing before or after it expired
+func (e *Endpoints) RenewListingHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	req := RenewListingRequest{
+		ID:      listingID,
+		IfMatch: r.Header.Get("If-Match"),
+	}
+
+	response, err := e.service.RenewListing(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to renew listing", err)
+		return
+	}
+	// A relisted listing may now match saved searches
+	e.listingChanged(r.Context(), response.Listing)
+
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -223,19 +489,13 @@ func (e *Endpoints) DeleteListingHandler(w http.ResponseWriter, r *http.Request)
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -252,35 +512,90 @@ func (e *Endpoints) DeleteListingHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	req := DeleteListingRequest{
-		ID:   listingID,
-		Hard: hard,
+		ID:     listingID,
+		Hard:   hard,
+		Reason: r.URL.Query().Get("reason"),
 	}
 
 	// Call service
 	response, err := e.service.DeleteListing(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to delete listing", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// RestoreListingHandler brings back a deleted listing within the retention window
+func (e *Endpoints) RestoreListingHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	req := RestoreListingRequest{
+		ID:     listingID,
+		Reason: r.URL.Query().Get("reason"),
+	}
+
+	response, err := e.service.RestoreListing(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to restore listing", err)
 		return
 	}
+	// A restored listing may now match saved searches
+	e.listingChanged(r.Context(), response)
 
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
+// GetDeletedListingsHandler returns the caller's deleted listings that can still be restored
+func (e *Endpoints) GetDeletedListingsHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := e.service.FetchDeletedListings(r.Context())
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch deleted listings", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// parsePageRequest reads the limit and cursor query parameters of a paged
+// collection endpoint.
+func parsePageRequest(r *http.Request) PageRequest {
+	var req PageRequest
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		req.Cursor = &cursor
+	}
+	return req
+}
+
+// setNextCursor exposes the next page cursor of an array response; the body
+// stays a plain array so existing clients keep working.
+func setNextCursor(w http.ResponseWriter, next string) {
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+}
+
 // GetUserListingsHandler handles getting listings for the authenticated user
 func (e *Endpoints) GetUserListingsHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service (context should have userID and role from middleware)
-	response, err := e.service.FetchUserListings(r.Context())
+	response, err := e.service.FetchUserListings(r.Context(), parsePageRequest(r))
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch user listings",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch user listings", err)
 		return
 	}
 
+	setNextCursor(w, response.NextCursor)
 	httplib.WriteJSON(w, http.StatusOK, response.Listings)
 }
 
@@ -289,46 +604,39 @@ func (e *Endpoints) GetListingsByUserIDHandler(w http.ResponseWriter, r *http.Re
 	// Extract user_id from query parameter
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "user_id query parameter is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "user_id query parameter is required")
 		return
 	}
 
 	// Parse user_id as UUID
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid user_id format. Expected UUID",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid user_id format. Expected UUID")
 		return
 	}
 
-	req := FetchListingsByUserIDRequest{UserID: userID}
+	req := FetchListingsByUserIDRequest{UserID: userID, PageRequest: parsePageRequest(r)}
 
 	// Call service (service will validate admin role)
 	response, err := e.service.FetchListingsByUserID(r.Context(), req)
 	if err != nil {
 		// Check if error is due to admin access requirement
 		if err.Error() == "admin access required" {
-			httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
+			httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch listings by user ID",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch listings by user ID", err)
 		return
 	}
 
+	setNextCursor(w, response.NextCursor)
 	httplib.WriteJSON(w, http.StatusOK, response.Listings)
 }
 
+// maxUploadSize matches the upload limit of listing-service: five files of
+// 20 MB plus form overhead
+const maxUploadSize = 5*(20<<20) + (1 << 20)
+
 // UploadMediaHandler handles uploading media files (requires authentication)
 func (e *Endpoints) UploadMediaHandler(w http.ResponseWriter, r *http.Request) {
 	// Get optional listing ID from query parameter
@@ -342,10 +650,7 @@ func (e *Endpoints) UploadMediaHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service - forward the request body directly
 	response, err := e.service.UploadMedia(r.Context(), r, listingID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to upload media",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to upload media", err)
 		return
 	}
 
@@ -357,58 +662,47 @@ func (e *Endpoints) AddMediaURLHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	var mediaReq struct {
-		MediaUrls []string `json:"media_urls"`
+		MediaUrls []string     `json:"media_urls"`
+		Media     []MediaInput `json:"media"`
 	}
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&mediaReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
-	if len(mediaReq.MediaUrls) == 0 {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "At least one media URL is required",
-		})
+	if len(mediaReq.MediaUrls) == 0 && len(mediaReq.Media) == 0 {
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "At least one media URL is required")
 		return
 	}
 
 	req := AddMediaURLRequest{
 		ID:        listingID,
 		MediaUrls: mediaReq.MediaUrls,
+		Media:     mediaReq.Media,
+		IfMatch:   r.Header.Get("If-Match"),
 	}
 
 	// Call service
 	response, err := e.service.AddMediaURL(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to add media URL",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to add media URL", err)
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -418,19 +712,13 @@ func (e *Endpoints) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if req.Query == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "Query is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "Query is required")
 		return
 	}
 
@@ -442,10 +730,7 @@ func (e *Endpoints) ChatSearchHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	response, err := e.service.ChatSearch(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to perform chat search",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to perform chat search", err)
 		return
 	}
 
@@ -467,16 +752,10 @@ func (e *Endpoints) GetFlaggedListingsHandler(w http.ResponseWriter, r *http.Req
 	if err != nil {
 		// Check if error is due to admin access requirement
 		if err.Error() == "admin access required" {
-			httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
+			httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 			return
 		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch flagged listings",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch flagged listings", err)
 		return
 	}
 
@@ -488,19 +767,13 @@ func (e *Endpoints) FlagListingHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -511,19 +784,13 @@ func (e *Endpoints) FlagListingHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&flagReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if flagReq.Reason == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "Reason is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "Reason is required")
 		return
 	}
 
@@ -537,17 +804,7 @@ func (e *Endpoints) FlagListingHandler(w http.ResponseWriter, r *http.Request) {
 	response, err := e.service.FlagListing(r.Context(), req)
 	if err != nil {
 		// Check if error is due to duplicate flag
-		if err.Error() == "user has already flagged this listing" || err.Error() == "you have already flagged this listing" {
-			httplib.WriteJSON(w, http.StatusConflict, ErrorResponse{
-				Error:   "Already flagged",
-				Message: "You have already flagged this listing",
-			})
-			return
-		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to flag listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to flag listing", err)
 		return
 	}
 
@@ -559,29 +816,20 @@ func (e *Endpoints) HasUserFlaggedListingHandler(w http.ResponseWriter, r *http.
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	// Call service
 	hasFlagged, err := e.service.HasUserFlaggedListing(r.Context(), listingID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to check flag status",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to check flag status", err)
 		return
 	}
 
@@ -593,29 +841,20 @@ func (e *Endpoints) GetMediaURLsHandler(w http.ResponseWriter, r *http.Request)
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	// Call service
 	response, err := e.service.FetchMediaURLs(r.Context(), listingID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch media URLs",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch media URLs", err)
 		return
 	}
 
@@ -627,58 +866,42 @@ func (e *Endpoints) UpdateMediaURLHandler(w http.ResponseWriter, r *http.Request
 	// Extract IDs from URL path
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	mediaIDStr := r.PathValue("media_id")
 	if mediaIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Media ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Media ID is required")
 		return
 	}
 
 	mediaID, err := strconv.ParseInt(mediaIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid media ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid media ID format")
 		return
 	}
 
 	var updateReq struct {
-		NewURL string `json:"new_url"`
+		NewURL  string  `json:"new_url"`
+		AltText *string `json:"alt_text"`
+		Caption *string `json:"caption"`
 	}
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
-	if updateReq.NewURL == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "new_url is required",
-		})
+	if updateReq.NewURL == "" && updateReq.AltText == nil && updateReq.Caption == nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "new_url, alt_text or caption is required")
 		return
 	}
 
@@ -686,18 +909,20 @@ func (e *Endpoints) UpdateMediaURLHandler(w http.ResponseWriter, r *http.Request
 		ListingID: listingID,
 		MediaID:   mediaID,
 		NewURL:    updateReq.NewURL,
+		AltText:   updateReq.AltText,
+		Caption:   updateReq.Caption,
+		IfMatch:   r.Header.Get("If-Match"),
+		Reason:    r.URL.Query().Get("reason"),
 	}
 
 	// Call service
 	response, err := e.service.UpdateMediaURL(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update media URL",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to update media URL", err)
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -706,19 +931,13 @@ func (e *Endpoints) DeleteMediaURLHandler(w http.ResponseWriter, r *http.Request
 	// Extract ID from URL path
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -728,36 +947,118 @@ func (e *Endpoints) DeleteMediaURLHandler(w http.ResponseWriter, r *http.Request
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&deleteReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	if deleteReq.MediaURL == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "media_url is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "media_url is required")
 		return
 	}
 
 	req := DeleteMediaURLRequest{
 		ListingID: listingID,
 		MediaURL:  deleteReq.MediaURL,
+		IfMatch:   r.Header.Get("If-Match"),
+		Reason:    r.URL.Query().Get("reason"),
 	}
 
 	// Call service
 	response, err := e.service.DeleteMediaURL(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete media URL",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to delete media URL", err)
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// mediaPathIDs parses the listing and media IDs of a media item route
+func mediaPathIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return 0, 0, false
+	}
+	mediaID, err := strconv.ParseInt(r.PathValue("media_id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid media ID format")
+		return 0, 0, false
+	}
+	return listingID, mediaID, true
+}
+
+// DeleteMediaHandler handles deleting a single media item by ID
+func (e *Endpoints) DeleteMediaHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, mediaID, ok := mediaPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := e.service.DeleteMedia(r.Context(), DeleteMediaRequest{
+		ListingID: listingID,
+		MediaID:   mediaID,
+		IfMatch:   r.Header.Get("If-Match"),
+		Reason:    r.URL.Query().Get("reason"),
+	})
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to delete media", err)
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// ReorderMediaHandler handles setting the gallery order of a listing
+func (e *Endpoints) ReorderMediaHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	var req ReorderMediaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+	if len(req.MediaIDs) == 0 {
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "media_ids is required")
+		return
+	}
+	req.ListingID = listingID
+	req.IfMatch = r.Header.Get("If-Match")
+
+	response, err := e.service.ReorderMedia(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to reorder media", err)
 		return
 	}
 
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// SetCoverHandler handles choosing the cover image of a listing
+func (e *Endpoints) SetCoverHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, mediaID, ok := mediaPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := e.service.SetCover(r.Context(), SetCoverRequest{
+		ListingID: listingID,
+		MediaID:   mediaID,
+		IfMatch:   r.Header.Get("If-Match"),
+	})
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to set cover image", err)
+		return
+	}
+
+	w.Header().Set("ETag", httplib.VersionETag(response.Version))
 	httplib.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -765,19 +1066,13 @@ func (e *Endpoints) UpdateFlagListingHandler(w http.ResponseWriter, r *http.Requ
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	flagIDStr := r.PathValue("flag_id")
 	if flagIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Flag ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Flag ID is required")
 		return
 	}
 
 	flagID, err := strconv.ParseInt(flagIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid flag ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid flag ID format")
 		return
 	}
 
@@ -788,10 +1083,7 @@ func (e *Endpoints) UpdateFlagListingHandler(w http.ResponseWriter, r *http.Requ
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
@@ -799,15 +1091,13 @@ func (e *Endpoints) UpdateFlagListingHandler(w http.ResponseWriter, r *http.Requ
 		FlagID:          flagID,
 		Status:          updateReq.Status,
 		ResolutionNotes: updateReq.ResolutionNotes,
+		Reason:          r.URL.Query().Get("reason"),
 	}
 
 	// Call service
 	response, err := e.service.UpdateFlagListing(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update flag listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to update flag listing", err)
 		return
 	}
 
@@ -819,47 +1109,35 @@ func (e *Endpoints) DeleteFlagListingHandler(w http.ResponseWriter, r *http.Requ
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	flagIDStr := r.PathValue("flag_id")
 	if flagIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Flag ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Flag ID is required")
 		return
 	}
 
 	flagID, err := strconv.ParseInt(flagIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid flag ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid flag ID format")
 		return
 	}
 
-	req := DeleteFlagListingRequest{FlagID: flagID}
+	// Destructive admin actions must say why; the reason goes to the audit log
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	if reason == "" {
+		httplib.WriteProblem(w, httplib.ValidationProblem("A reason is required to delete a flag",
+			httplib.FieldError{Field: "reason", Code: "required", Message: "reason is required"}))
+		return
+	}
+
+	req := DeleteFlagListingRequest{FlagID: flagID, Reason: reason}
 
 	// Call service
 	response, err := e.service.DeleteFlagListing(r.Context(), req)
 	if err != nil {
 		// Check if error is due to admin access requirement
 		if err.Error() == "admin access required" {
-			httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
+			httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 			return
 		}
-		// Check if flag not found
-		if err.Error() == "flag not found" {
-			httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-				Error:   "Not found",
-				Message: "Flag not found",
-			})
-			return
-		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete flagged listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to delete flagged listing", err)
 		return
 	}
 
@@ -871,19 +1149,13 @@ func (e *Endpoints) SaveListingHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -892,17 +1164,7 @@ func (e *Endpoints) SaveListingHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	response, err := e.service.SaveListing(r.Context(), req)
 	if err != nil {
-		if err.Error() == "listing not found" {
-			httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-				Error:   "Listing not found",
-				Message: err.Error(),
-			})
-			return
-		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to save listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to save listing", err)
 		return
 	}
 
@@ -914,19 +1176,13 @@ func (e *Endpoints) UnsaveListingHandler(w http.ResponseWriter, r *http.Request)
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
@@ -935,17 +1191,7 @@ func (e *Endpoints) UnsaveListingHandler(w http.ResponseWriter, r *http.Request)
 	// Call service
 	response, err := e.service.UnsaveListing(r.Context(), req)
 	if err != nil {
-		if err.Error() == "saved listing not found" {
-			httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-				Error:   "Saved listing not found",
-				Message: err.Error(),
-			})
-			return
-		}
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to unsave listing",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to unsave listing", err)
 		return
 	}
 
@@ -957,29 +1203,20 @@ func (e *Endpoints) IsListingSavedHandler(w http.ResponseWriter, r *http.Request
 	// Extract ID from URL path using PathValue (Go 1.22+)
 	listingIDStr := r.PathValue("id")
 	if listingIDStr == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Listing ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Listing ID is required")
 		return
 	}
 
 	listingID, err := strconv.ParseInt(listingIDStr, 10, 64)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Invalid listing ID format",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
 		return
 	}
 
 	// Call service
 	isSaved, err := e.service.IsListingSaved(r.Context(), listingID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to check if listing is saved",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to check if listing is saved", err)
 		return
 	}
 
@@ -989,37 +1226,73 @@ func (e *Endpoints) IsListingSavedHandler(w http.ResponseWriter, r *http.Request
 // GetSavedListingsHandler handles getting all saved listings for the authenticated user
 func (e *Endpoints) GetSavedListingsHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service
-	response, err := e.service.FetchSavedListings(r.Context())
+	response, err := e.service.FetchSavedListings(r.Context(), parsePageRequest(r))
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to fetch saved listings",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch saved listings", err)
 		return
 	}
 
+	setNextCursor(w, response.NextCursor)
 	httplib.WriteJSON(w, http.StatusOK, response.SavedListings)
 }
 
+// ConfirmReceiptHandler lets the buyer confirm they received a purchase
+func (e *Endpoints) ConfirmReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid transaction ID format")
+		return
+	}
+
+	response, err := e.service.ConfirmReceipt(r.Context(), transactionID)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to confirm receipt", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetTransactionsHandler lists the caller's purchases and sales; ?role=buyer
+// or ?role=seller narrows it to one side
+func (e *Endpoints) GetTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	req := FetchTransactionsRequest{PageRequest: parsePageRequest(r)}
+	if role := r.URL.Query().Get("role"); role != "" {
+		req.Role = &role
+	}
+
+	response, err := e.service.FetchTransactions(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch transactions", err)
+		return
+	}
+
+	setNextCursor(w, response.NextCursor)
+	httplib.WriteJSON(w, http.StatusOK, response.Transactions)
+}
+
+// LookupBookHandler fills in a textbook listing from an ISBN
+func (e *Endpoints) LookupBookHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := e.service.LookupBook(r.Context(), r.PathValue("isbn"))
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to look up book", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
 // adminOnlyMiddleware checks if the user has admin role
 func adminOnlyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		roleVal := ctx.Value(httplib.ContextKey("userRole"))
 		if roleVal == nil {
-			httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
+			httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 			return
 		}
 
 		role, ok := roleVal.(string)
 		if !ok || role != string(httplib.ADMIN) {
-			httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
+			httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 			return
 		}
 
@@ -1049,33 +1322,62 @@ func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
 		)
 	}
 
+	// Rate-limited chain: Auth -> Role -> Rate limit -> JSON
+	limited := func(rule httplib.RateLimitRule, h http.Handler) http.Handler {
+		return protected(e.limiter.Limit(httplib.RateLimitRuleFromEnv(rule))(h))
+	}
+
+	// Idempotent chain: replays the stored response for a repeated Idempotency-Key
+	idempotent := func(h http.Handler) http.Handler {
+		return e.idempotency.Middleware(h)
+	}
+
 	// Protected routes (require auth + role injection)
 	// Specific routes must come before parameterized routes to avoid conflicts
 	// Routes with specific path segments (like /delete/, /update/, /flag/, etc.) must come first
 	mux.Handle("GET /api/listings/", protected(http.HandlerFunc(e.GetAllListingsHandler)))
-	mux.Handle("POST /api/listings/chatsearch", protected(http.HandlerFunc(e.ChatSearchHandler)))
-	mux.Handle("POST /api/listings/create", protected(http.HandlerFunc(e.CreateListingHandler)))
+	mux.Handle("POST /api/listings/chatsearch", limited(chatSearchRateLimit, http.HandlerFunc(e.ChatSearchHandler)))
+	mux.Handle("POST /api/listings/create", limited(createListingRateLimit, idempotent(http.HandlerFunc(e.CreateListingHandler))))
+	mux.Handle("POST /api/listings/import", limited(importListingsRateLimit, idempotent(http.HandlerFunc(e.ImportListingsHandler))))
+	mux.Handle("GET /api/listings/export", protected(http.HandlerFunc(e.ExportListingsHandler)))
+	mux.Handle("GET /api/listings/books/{isbn}", protected(http.HandlerFunc(e.LookupBookHandler)))
 	mux.Handle("GET /api/listings/user-lists", protected(http.HandlerFunc(e.GetUserListingsHandler)))
+	mux.Handle("GET /api/listings/trending", protected(http.HandlerFunc(e.GetTrendingListingsHandler)))
+	// Uses /similar/{id} rather than /{id}/similar, which would conflict with /media/{id}
+	mux.Handle("GET /api/listings/similar/{id}", protected(http.HandlerFunc(e.GetSimilarListingsHandler)))
+	// Uses /history/{id} for the same reason
+	mux.Handle("GET /api/listings/history/{id}", protected(http.HandlerFunc(e.GetListingHistoryHandler)))
+	mux.Handle("POST /api/listings/contact/{id}", protected(http.HandlerFunc(e.ContactSellerHandler)))
 	mux.Handle("POST /api/listings/upload", httplib.AuthMiddleWare(
-		httplib.RoleInjectionMiddleWare(dbPool)(http.HandlerFunc(e.UploadMediaHandler)),
+		httplib.RoleInjectionMiddleWare(dbPool)(
+			e.idempotency.WithMaxBody(maxUploadSize).Middleware(http.HandlerFunc(e.UploadMediaHandler)),
+		),
 	))
-	mux.Handle("POST /api/listings/add-media-url/{id}", protected(http.HandlerFunc(e.AddMediaURLHandler)))
+	mux.Handle("POST /api/listings/add-media-url/{id}", protected(idempotent(http.HandlerFunc(e.AddMediaURLHandler))))
 	mux.Handle("GET /api/listings/flag/{id}/check", protected(http.HandlerFunc(e.HasUserFlaggedListingHandler)))
-	mux.Handle("POST /api/listings/flag/{id}", protected(http.HandlerFunc(e.FlagListingHandler)))
+	mux.Handle("POST /api/listings/flag/{id}", limited(flagListingRateLimit, idempotent(http.HandlerFunc(e.FlagListingHandler))))
 	mux.Handle("GET /api/listings/saved", protected(http.HandlerFunc(e.GetSavedListingsHandler)))
 	mux.Handle("GET /api/listings/save/{id}/check", protected(http.HandlerFunc(e.IsListingSavedHandler)))
 	mux.Handle("POST /api/listings/save/{id}", protected(http.HandlerFunc(e.SaveListingHandler)))
 	mux.Handle("DELETE /api/listings/save/{id}", protected(http.HandlerFunc(e.UnsaveListingHandler)))
 	mux.Handle("PATCH /api/listings/update/{id}", protected(http.HandlerFunc(e.UpdateListingHandler)))
+	mux.Handle("POST /api/listings/renew/{id}", protected(idempotent(http.HandlerFunc(e.RenewListingHandler))))
+	mux.Handle("GET /api/listings/transactions", protected(http.HandlerFunc(e.GetTransactionsHandler)))
+	mux.Handle("POST /api/listings/transactions/{id}/confirm", protected(idempotent(http.HandlerFunc(e.ConfirmReceiptHandler))))
 	mux.Handle("DELETE /api/listings/delete/{id}", httplib.AuthMiddleWare(
 		httplib.RoleInjectionMiddleWare(dbPool)(http.HandlerFunc(e.DeleteListingHandler)),
 	))
+	mux.Handle("POST /api/listings/restore/{id}", protected(idempotent(http.HandlerFunc(e.RestoreListingHandler))))
+	mux.Handle("GET /api/listings/deleted", protected(http.HandlerFunc(e.GetDeletedListingsHandler)))
 	// Parameterized routes - must come after all specific routes with path segments
 	mux.Handle("GET /api/listings/{id}", protected(http.HandlerFunc(e.GetListingByIDHandler)))
 	// Media routes use /media/{id} pattern to avoid conflicts with /delete/{id}, /update/{id}, etc.
 	mux.Handle("GET /api/listings/media/{id}", protected(http.HandlerFunc(e.GetMediaURLsHandler)))
-	mux.Handle("PATCH /api/listings/media/{id}/{media_id}", protected(http.HandlerFunc(e.UpdateMediaURLHandler)))
-	mux.Handle("DELETE /api/listings/media/{id}", protected(http.HandlerFunc(e.DeleteMediaURLHandler)))
+	mux.Handle("PATCH /api/listings/media/{id}/{media_id}", protected(idempotent(http.HandlerFunc(e.UpdateMediaURLHandler))))
+	mux.Handle("DELETE /api/listings/media/{id}", protected(idempotent(http.HandlerFunc(e.DeleteMediaURLHandler))))
+	mux.Handle("DELETE /api/listings/media/{id}/{media_id}", protected(idempotent(http.HandlerFunc(e.DeleteMediaHandler))))
+	mux.Handle("PUT /api/listings/media/{id}/order", protected(idempotent(http.HandlerFunc(e.ReorderMediaHandler))))
+	mux.Handle("POST /api/listings/media/{id}/{media_id}/cover", protected(idempotent(http.HandlerFunc(e.SetCoverHandler))))
 
 	// Admin-only routes
 	mux.Handle("GET /api/listings/flagged", adminProtected(http.HandlerFunc(e.GetFlaggedListingsHandler)))
@@ -1086,29 +1388,34 @@ func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
 
 // validateCreateListingRequest validates create listing request
 func validateCreateListingRequest(req CreateListingRequest) error {
+	var fields []httplib.FieldError
 	if req.Title == "" {
-		return fmt.Errorf("title is required")
+		fields = append(fields, httplib.FieldError{Field: "title", Code: "required", Message: "title is required"})
 	}
 	if len(req.Title) > 200 {
-		return fmt.Errorf("title must be less than 200 characters")
+		fields = append(fields, httplib.FieldError{Field: "title", Code: "too_long", Message: "title must be less than 200 characters"})
 	}
 	if req.Price < 0 {
-		return fmt.Errorf("price must be non-negative")
+		fields = append(fields, httplib.FieldError{Field: "price", Code: "out_of_range", Message: "price must be non-negative"})
 	}
 	if req.Category == "" {
-		return fmt.Errorf("category is required")
-	}
-	// Validate category
-	validCategories := []Category{CatTextbook, CatGadget, CatEssential, CatNonEssential, CatOther, CatTest}
-	valid := false
-	for _, cat := range validCategories {
-		if req.Category == cat {
-			valid = true
-			break
+		fields = append(fields, httplib.FieldError{Field: "category", Code: "required", Message: "category is required"})
+	} else {
+		// Validate category
+		validCategories := []Category{CatTextbook, CatGadget, CatEssential, CatNonEssential, CatOther, CatTest}
+		valid := false
+		for _, cat := range validCategories {
+			if req.Category == cat {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			fields = append(fields, httplib.FieldError{Field: "category", Code: "invalid", Message: "invalid category"})
 		}
 	}
-	if !valid {
-		return fmt.Errorf("invalid category")
+	if len(fields) > 0 {
+		return httplib.ValidationProblem(fields[0].Message, fields...)
 	}
 	return nil
 }
@@ -10,6 +10,13 @@ import (
 type Category string
 type Status string
 
+// Condition is the state of the item being sold
+type Condition string
+
+// Attributes are the category-specific details of a listing, e.g. isbn and
+// course_code for TEXTBOOK or brand and storage for GADGET
+type Attributes map[string]string
+
 const (
 	CatTextbook     Category = "TEXTBOOK"
 	CatGadget       Category = "GADGET"
@@ -23,6 +30,13 @@ const (
 	StSold      Status = "SOLD"
 	StArchived  Status = "ARCHIVED"
 	StReported  Status = "REPORTED"
+	StDraft     Status = "DRAFT"
+
+	CondNew     Condition = "NEW"
+	CondLikeNew Condition = "LIKE_NEW"
+	CondGood    Condition = "GOOD"
+	CondFair    Condition = "FAIR"
+	CondPoor    Condition = "POOR"
 )
 
 // Listing represents a listing item
@@ -35,14 +49,49 @@ type Listing struct {
 	UserID      uuid.UUID `json:"user_id"`
 	Status      Status    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int64     `json:"version"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// ReservedFor is the buyer whose accepted offer moved the listing to PENDING
+	ReservedFor    *uuid.UUID `json:"reserved_for,omitempty"`
+	Condition      *Condition `json:"condition,omitempty"`
+	PickupLocation *string    `json:"pickup_location,omitempty"`
+	Tags           []string   `json:"tags"`
+	Attributes     Attributes `json:"attributes"`
+	// PublishAt is when a DRAFT is published automatically
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	Snippet   *string    `json:"snippet,omitempty"`
+	// Views is how often the listing was viewed, only shown to its owner
+	Views *int64 `json:"views,omitempty"`
+	// DeletedAt is only set when listing the caller's deleted listings
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Cover is the listing's cover image, only set in list responses
+	Cover *ListingCover `json:"cover,omitempty"`
+	// PriceHistory is only returned by the listing detail
+	PriceHistory []PriceChange `json:"price_history,omitempty"`
+}
+
+// PriceChange is one recorded change of a listing's price
+type PriceChange struct {
+	OldPrice  int64     `json:"old_price"`
+	NewPrice  int64     `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at"`
 }
 
 // CreateListingRequest for creating a new listing
 type CreateListingRequest struct {
-	Title       string   `json:"title"`
-	Description *string  `json:"description,omitempty"`
-	Price       int64    `json:"price"`
-	Category    Category `json:"category"`
+	Title          string     `json:"title"`
+	Description    *string    `json:"description,omitempty"`
+	Price          int64      `json:"price"`
+	Category       Category   `json:"category"`
+	Condition      *Condition `json:"condition,omitempty"`
+	PickupLocation *string    `json:"pickup_location,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	Attributes     Attributes `json:"attributes,omitempty"`
+	// Draft creates a listing only the seller sees; a future PublishAt also
+	// does and publishes it at that time
+	Draft     bool       `json:"draft,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
 }
 
 // CreateListingResponse returns the created listing
@@ -52,20 +101,62 @@ type CreateListingResponse struct {
 
 // FetchAllListingsRequest for filtering listings
 type FetchAllListingsRequest struct {
-	Limit    *int      `json:"limit,omitempty"`
-	Offset   *int      `json:"offset,omitempty"`
-	Sort     *string   `json:"sort,omitempty"`
-	Keywords *string   `json:"keywords,omitempty"`
-	Category *Category `json:"category,omitempty"`
-	Status   *Status   `json:"status,omitempty"`
-	MinPrice *int64    `json:"min_price,omitempty"`
-	MaxPrice *int64    `json:"max_price,omitempty"`
+	Limit        *int       `json:"limit,omitempty"`
+	Offset       *int       `json:"offset,omitempty"`
+	Cursor       *string    `json:"cursor,omitempty"`
+	IncludeCount *bool      `json:"include_count,omitempty"`
+	Sort         *string    `json:"sort,omitempty"`
+	Keywords     *string    `json:"keywords,omitempty"`
+	Category     *Category  `json:"category,omitempty"`
+	Status       *Status    `json:"status,omitempty"`
+	MinPrice     *int64     `json:"min_price,omitempty"`
+	MaxPrice     *int64     `json:"max_price,omitempty"`
+	Condition    *Condition `json:"condition,omitempty"`
+	Pickup       *string    `json:"pickup_location,omitempty"`
+	// Tags is a comma separated list the listing must all carry; Attributes
+	// are forwarded as attr.<key>=<value>
+	Tags       *string    `json:"tags,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Course     *string    `json:"course,omitempty"` // a catalog course code
+	// Facets is a comma separated list of category, status and price;
+	// PriceBuckets the comma separated lower bounds of the price facet in cents
+	Facets       *string `json:"facets,omitempty"`
+	PriceBuckets *string `json:"price_buckets,omitempty"`
+}
+
+// FacetCount is the number of matching listings with a given facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceBucket counts the matching listings priced in [Min, Max)
+type PriceBucket struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets holds the requested facet counts, each computed without its own filter
+type Facets struct {
+	Category []FacetCount  `json:"category,omitempty"`
+	Status   []FacetCount  `json:"status,omitempty"`
+	Price    []PriceBucket `json:"price,omitempty"`
+}
+
+// FetchAllListingsResponse returns one page of listings. NextCursor is empty
+// on the last page; Count is only present when it was computed.
+type FetchAllListingsResponse struct {
+	Items      []Listing `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Count      *int      `json:"count,omitempty"`
+	Facets     *Facets   `json:"facets,omitempty"`
 }
 
-// FetchAllListingsResponse returns a list of listings with count
-type FetchAllListingsResponse struct {
-	Items []Listing `json:"items"`
-	Count int       `json:"count"`
+// PageRequest selects a keyset page of an unfiltered collection
+type PageRequest struct {
+	Limit  *int    `json:"limit,omitempty"`
+	Cursor *string `json:"cursor,omitempty"`
 }
 
 // FetchListingRequest for getting a single listing
@@ -80,27 +171,43 @@ type FetchListingResponse struct {
 
 // FetchUserListingsResponse returns user's listings
 type FetchUserListingsResponse struct {
-	Listings []Listing `json:"listings"`
+	Listings   []Listing `json:"listings"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 // FetchListingsByUserIDRequest for getting listings by user ID (admin only)
 type FetchListingsByUserIDRequest struct {
 	UserID uuid.UUID `json:"user_id"`
+	PageRequest
 }
 
 // FetchListingsByUserIDResponse returns listings for a specific user
 type FetchListingsByUserIDResponse struct {
-	Listings []Listing `json:"listings"`
+	Listings   []Listing `json:"listings"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 // UpdateListingRequest for updating a listing
 type UpdateListingRequest struct {
-	ID          int64     `json:"id"`
-	Title       *string   `json:"title,omitempty"`
-	Description *string   `json:"description,omitempty"`
-	Price       *int64    `json:"price,omitempty"`
-	Category    *Category `json:"category,omitempty"`
-	Status      *Status   `json:"status,omitempty"`
+	ID          int64      `json:"id"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Price       *int64     `json:"price,omitempty"`
+	Category    *Category  `json:"category,omitempty"`
+	Status      *Status    `json:"status,omitempty"`
+	Condition   *Condition `json:"condition,omitempty"`
+	// PickupLocation is cleared by an empty string
+	PickupLocation *string `json:"pickup_location,omitempty"`
+	// Tags and Attributes replace the stored values when set; changing the
+	// category without new Attributes clears them
+	Tags       *[]string   `json:"tags,omitempty"`
+	Attributes *Attributes `json:"attributes,omitempty"`
+	// PublishAt reschedules a DRAFT; publish one now by setting Status to AVAILABLE
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// IfMatch is forwarded as the If-Match header
+	IfMatch string `json:"-"`
+	// Reason is recorded in the audit log when an admin edits someone else's listing
+	Reason string `json:"-"`
 }
 
 // UpdateListingResponse returns the updated listing
@@ -108,10 +215,23 @@ type UpdateListingResponse struct {
 	*Listing
 }
 
+// RenewListingRequest for extending a listing's expiry
+type RenewListingRequest struct {
+	ID int64 `json:"id"`
+	// IfMatch is forwarded as the If-Match header
+	IfMatch string `json:"-"`
+}
+
+// RenewListingResponse returns the renewed listing
+type RenewListingResponse struct {
+	*Listing
+}
+
 // DeleteListingRequest for deleting a listing
 type DeleteListingRequest struct {
-	ID   int64 `json:"id"`
-	Hard *bool `json:"hard,omitempty"`
+	ID     int64  `json:"id"`
+	Hard   *bool  `json:"hard,omitempty"`
+	Reason string `json:"-"`
 }
 
 // DeleteListingResponse returns deletion status
@@ -119,6 +239,13 @@ type DeleteListingResponse struct {
 	Status string `json:"status"`
 }
 
+// RestoreListingRequest for bringing back a deleted listing
+type RestoreListingRequest struct {
+	ID int64 `json:"id"`
+	// Reason is recorded in the audit log when an admin restores someone else's listing
+	Reason string `json:"-"`
+}
+
 // UploadSASResponse represents a SAS URL response from blob service
 type UploadSASResponse struct {
 	SASURL             string `json:"sas_url"`
@@ -132,16 +259,31 @@ type UploadMediaResponse struct {
 	Uploads []UploadSASResponse `json:"uploads"`
 }
 
-// AddMediaURLRequest for adding media URLs to a listing
+// AddMediaURLRequest for adding media URLs to a listing, either as plain
+// URLs or with their description and file details
 type AddMediaURLRequest struct {
-	ID        int64    `json:"id"`
-	MediaUrls []string `json:"media_urls"`
+	ID        int64        `json:"id"`
+	MediaUrls []string     `json:"media_urls,omitempty"`
+	Media     []MediaInput `json:"media,omitempty"`
+	IfMatch   string       `json:"-"`
+}
+
+// MediaInput is a media item added to a listing's gallery
+type MediaInput struct {
+	URL       string  `json:"url"`
+	AltText   *string `json:"alt_text,omitempty"`
+	Caption   *string `json:"caption,omitempty"`
+	MimeType  *string `json:"mime_type,omitempty"`
+	Width     *int    `json:"width,omitempty"`
+	Height    *int    `json:"height,omitempty"`
+	SizeBytes *int64  `json:"size_bytes,omitempty"`
 }
 
 // AddMediaURLResponse returns success message
 type AddMediaURLResponse struct {
 	Message string `json:"message"`
 	Count   int    `json:"count"`
+	Version int64  `json:"version"`
 }
 
 // ChatMessage represents a message in the conversation history
@@ -154,11 +296,31 @@ type ChatMessage struct {
 type ChatSearchRequest struct {
 	Query               string        `json:"query"`
 	ConversationHistory []ChatMessage `json:"conversation_history,omitempty"`
+	Facets              []string      `json:"facets,omitempty"`
+	PriceBuckets        []int64       `json:"price_buckets,omitempty"`
+}
+
+// SearchFilters are the filters chat search derived from the query, in the
+// shape saved searches accept
+type SearchFilters struct {
+	Keywords   []string   `json:"keywords,omitempty"`
+	Category   *Category  `json:"category,omitempty"`
+	Status     *Status    `json:"status,omitempty"`
+	MinPrice   *int64     `json:"min_price,omitempty"`
+	MaxPrice   *int64     `json:"max_price,omitempty"`
+	Condition  *Condition `json:"condition,omitempty"`
+	Pickup     *string    `json:"pickup_location,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Course     *string    `json:"course,omitempty"`
+	Sort       string     `json:"sort,omitempty"`
 }
 
 // ChatSearchResponse returns search results
 type ChatSearchResponse struct {
-	Listings []Listing `json:"listings"`
+	Listings []Listing      `json:"listings"`
+	Filters  *SearchFilters `json:"filters,omitempty"`
+	Facets   *Facets        `json:"facets,omitempty"`
 }
 
 // FlagReason represents the reason a listing was flagged
@@ -228,6 +390,7 @@ type UpdateFlagListingRequest struct {
 	FlagID          int64      `json:"flag_id"`
 	Status          FlagStatus `json:"status"`
 	ResolutionNotes *string    `json:"resolution_notes,omitempty"`
+	Reason          string     `json:"-"`
 }
 
 // UpdateFlagListingResponse returns the updated flagged listing
@@ -237,7 +400,8 @@ type UpdateFlagListingResponse struct {
 
 // DeleteFlagListingRequest for deleting a flagged listing (admin only)
 type DeleteFlagListingRequest struct {
-	FlagID int64 `json:"flag_id"`
+	FlagID int64  `json:"flag_id"`
+	Reason string `json:"-"`
 }
 
 // DeleteFlagListingResponse returns deletion status
@@ -251,9 +415,26 @@ type ListingMedia struct {
 	ID        int64     `json:"id"`
 	ListingID int64     `json:"listing_id"`
 	MediaURL  string    `json:"media_url"`
+	Position  int       `json:"position"`
+	IsCover   bool      `json:"is_cover"`
+	AltText   *string   `json:"alt_text,omitempty"`
+	Caption   *string   `json:"caption,omitempty"`
+	MimeType  *string   `json:"mime_type,omitempty"`
+	Width     *int      `json:"width,omitempty"`
+	Height    *int      `json:"height,omitempty"`
+	SizeBytes *int64    `json:"size_bytes,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ListingCover is the cover image of a listing in list responses
+type ListingCover struct {
+	MediaID  int64   `json:"media_id"`
+	MediaURL string  `json:"media_url"`
+	AltText  *string `json:"alt_text,omitempty"`
+	Width    *int    `json:"width,omitempty"`
+	Height   *int    `json:"height,omitempty"`
+}
+
 // FetchMediaURLsRequest for getting media URLs for a listing
 type FetchMediaURLsRequest struct {
 	ListingID int64 `json:"listing_id"`
@@ -264,27 +445,63 @@ type FetchMediaURLsResponse struct {
 	Media []ListingMedia `json:"media"`
 }
 
-// UpdateMediaURLRequest for updating a media URL
+// UpdateMediaURLRequest for updating a media URL, alt text or caption
 type UpdateMediaURLRequest struct {
-	ListingID int64  `json:"listing_id"`
-	MediaID   int64  `json:"media_id"`
-	NewURL    string `json:"new_url"`
+	ListingID int64   `json:"listing_id"`
+	MediaID   int64   `json:"media_id"`
+	NewURL    string  `json:"new_url,omitempty"`
+	AltText   *string `json:"alt_text,omitempty"`
+	Caption   *string `json:"caption,omitempty"`
+	IfMatch   string  `json:"-"`
+	Reason    string  `json:"-"`
 }
 
 // UpdateMediaURLResponse returns success message
 type UpdateMediaURLResponse struct {
 	Message string `json:"message"`
+	Version int64  `json:"version"`
 }
 
 // DeleteMediaURLRequest for deleting a media URL
 type DeleteMediaURLRequest struct {
 	ListingID int64  `json:"listing_id"`
 	MediaURL  string `json:"media_url"`
+	IfMatch   string `json:"-"`
+	Reason    string `json:"-"`
 }
 
 // DeleteMediaURLResponse returns success message
 type DeleteMediaURLResponse struct {
 	Message string `json:"message"`
+	Version int64  `json:"version"`
+}
+
+// DeleteMediaRequest for deleting a single media item by ID
+type DeleteMediaRequest struct {
+	ListingID int64  `json:"listing_id"`
+	MediaID   int64  `json:"media_id"`
+	IfMatch   string `json:"-"`
+	Reason    string `json:"-"`
+}
+
+// ReorderMediaRequest sets the gallery order of a listing's media
+type ReorderMediaRequest struct {
+	ListingID int64   `json:"-"`
+	MediaIDs  []int64 `json:"media_ids"`
+	IfMatch   string  `json:"-"`
+}
+
+// SetCoverRequest makes a media item the cover image of its listing
+type SetCoverRequest struct {
+	ListingID int64  `json:"listing_id"`
+	MediaID   int64  `json:"media_id"`
+	IfMatch   string `json:"-"`
+}
+
+// MediaChangeResponse returns the listing version after a gallery change
+type MediaChangeResponse struct {
+	Message string `json:"message"`
+	Version int64  `json:"version"`
 }
 
 // SavedListing represents a saved listing entry
@@ -308,6 +525,11 @@ type SaveListingResponse struct {
 	Message string `json:"message"`
 }
 
+// ContactSellerResponse names the seller to open a conversation with
+type ContactSellerResponse struct {
+	SellerID uuid.UUID `json:"seller_id"`
+}
+
 // UnsaveListingRequest for unsaving a listing
 type UnsaveListingRequest struct {
 	ListingID int64 `json:"listing_id"`
@@ -326,4 +548,227 @@ type IsListingSavedResponse struct {
 // FetchSavedListingsResponse returns all saved listings for a user
 type FetchSavedListingsResponse struct {
 	SavedListings []SavedListing `json:"saved_listings"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// OfferStatus is the state of an offer
+type OfferStatus string
+
+const (
+	OfferPending   OfferStatus = "PENDING"
+	OfferAccepted  OfferStatus = "ACCEPTED"
+	OfferRejected  OfferStatus = "REJECTED"
+	OfferCountered OfferStatus = "COUNTERED"
+	OfferWithdrawn OfferStatus = "WITHDRAWN"
+	OfferExpired   OfferStatus = "EXPIRED"
+)
+
+// Offer is a buyer's offer, or a counter-offer, on a listing
+type Offer struct {
+	ID            int64       `json:"id"`
+	ListingID     int64       `json:"listing_id"`
+	BuyerID       uuid.UUID   `json:"buyer_id"`
+	SellerID      uuid.UUID   `json:"seller_id"`
+	FromUserID    uuid.UUID   `json:"from_user_id"`
+	ParentOfferID *int64      `json:"parent_offer_id,omitempty"`
+	Amount        int64       `json:"amount"`
+	Message       *string     `json:"message,omitempty"`
+	Status        OfferStatus `json:"status"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// CreateOfferRequest for making an offer on a listing
+type CreateOfferRequest struct {
+	ListingID int64   `json:"listing_id"`
+	Amount    int64   `json:"amount"`
+	Message   *string `json:"message,omitempty"`
+}
+
+// RespondOfferRequest for answering an offer; Amount is only used to counter
+type RespondOfferRequest struct {
+	OfferID int64   `json:"-"`
+	Action  string  `json:"action"` // accept, reject, counter or withdraw
+	Amount  int64   `json:"amount,omitempty"`
+	Message *string `json:"message,omitempty"`
+}
+
+// RespondOfferResponse holds the answered offer, or the new counter-offer,
+// and the other open offers declined when an offer was accepted
+type RespondOfferResponse struct {
+	Offer    Offer   `json:"offer"`
+	Declined []Offer `json:"declined,omitempty"`
+}
+
+// FetchOffersRequest for listing the caller's offers
+type FetchOffersRequest struct {
+	PageRequest
+	Role      *string `json:"role,omitempty"` // "buyer" or "seller"
+	ListingID *int64  `json:"listing_id,omitempty"`
+}
+
+// FetchOffersResponse returns a page of offers
+type FetchOffersResponse struct {
+	Offers     []Offer `json:"offers"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Transaction is a sale the seller confirmed to a specific buyer
+type Transaction struct {
+	ID           int64      `json:"id"`
+	ListingID    *int64     `json:"listing_id,omitempty"`
+	BuyerID      *uuid.UUID `json:"buyer_id,omitempty"`
+	SellerID     *uuid.UUID `json:"seller_id,omitempty"`
+	OfferID      *int64     `json:"offer_id,omitempty"`
+	ListingTitle string     `json:"listing_title"`
+	Category     Category   `json:"category"`
+	Price        int64      `json:"price"`
+	CompletedAt  time.Time  `json:"completed_at"`
+	ReceivedAt   *time.Time `json:"received_at,omitempty"`
+}
+
+// SaleResponse holds the recorded sale and the other open offers declined
+// because the listing was sold
+type SaleResponse struct {
+	Transaction
+	Declined []Offer `json:"declined,omitempty"`
+}
+
+// RecordSaleRequest for marking a listing sold to a buyer; Price defaults to
+// the buyer's accepted offer, else the listing price
+type RecordSaleRequest struct {
+	ListingID int64     `json:"-"`
+	BuyerID   uuid.UUID `json:"buyer_id"`
+	Price     *int64    `json:"price,omitempty"`
+}
+
+// FetchTransactionsRequest for listing the caller's purchases and sales
+type FetchTransactionsRequest struct {
+	PageRequest
+	Role *string `json:"role,omitempty"` // "buyer" or "seller"
+}
+
+// FetchTransactionsResponse returns a page of transactions
+type FetchTransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// BookMetadata describes one edition of a book, looked up by ISBN
+type BookMetadata struct {
+	ISBN        string    `json:"isbn"`
+	WorkID      string    `json:"work_id,omitempty"`
+	Title       string    `json:"title"`
+	Authors     []string  `json:"authors"`
+	Edition     string    `json:"edition,omitempty"`
+	Publisher   string    `json:"publisher,omitempty"`
+	PublishDate string    `json:"publish_date,omitempty"`
+	CoverURL    string    `json:"cover_url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// BookPrefillResponse is a create-listing form filled in from an ISBN;
+// CoverURL can be attached with add-media-url once the listing is created
+type BookPrefillResponse struct {
+	Book     BookMetadata         `json:"book"`
+	Listing  CreateListingRequest `json:"listing"`
+	CoverURL string               `json:"cover_url,omitempty"`
+}
+
+// Course is a course catalog entry with the textbooks it uses
+type Course struct {
+	Code      string       `json:"code"`
+	Title     string       `json:"title"`
+	Books     []CourseBook `json:"books"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CourseBook is a required or optional textbook of a course; Book is set
+// once its metadata has been looked up
+type CourseBook struct {
+	ISBN     string        `json:"isbn"`
+	Required bool          `json:"required"`
+	Book     *BookMetadata `json:"book,omitempty"`
+}
+
+// TrendingListing is a listing of the trending feed with its score
+type TrendingListing struct {
+	Listing
+	Score float64 `json:"trending_score"`
+}
+
+// SimilarListing is a listing recommended from the one being viewed
+type SimilarListing struct {
+	Listing
+	Score float64 `json:"similarity_score"`
+}
+
+// FetchSimilarRequest asks for the listings most like ID
+type FetchSimilarRequest struct {
+	ID    int64 `json:"id"`
+	Limit *int  `json:"limit,omitempty"`
+}
+
+// ListingRevision is one update of a listing and the fields it changed,
+// keyed by their JSON name
+type ListingRevision struct {
+	ID            int64                  `json:"id"`
+	ListingID     int64                  `json:"listing_id"`
+	Version       int64                  `json:"version"`
+	Changes       map[string]FieldChange `json:"changes"`
+	ChangedBy     *uuid.UUID             `json:"changed_by,omitempty"`
+	ChangedByRole *string                `json:"changed_by_role,omitempty"`
+	ChangedAt     time.Time              `json:"changed_at"`
+}
+
+// FieldChange is the value of a listing field before and after an update
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// FetchTrendingRequest limits the trending feed, optionally to one category
+type FetchTrendingRequest struct {
+	Category *Category `json:"category,omitempty"`
+	Limit    *int      `json:"limit,omitempty"`
+}
+
+// FetchCourseListingsRequest pages the available listings of a course's books
+type FetchCourseListingsRequest struct {
+	PageRequest
+	Code string  `json:"code"`
+	Sort *string `json:"sort,omitempty"`
+}
+
+// CourseImportResult counts the courses and books a catalog import saved
+type CourseImportResult struct {
+	Courses int `json:"courses"`
+	Books   int `json:"books"`
+}
+
+// ImportRowError is a validation failure of one imported row, numbered from
+// 1 without the CSV header
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the outcome of a bulk import; a dry run creates no Listings
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Valid    int              `json:"valid"`
+	Errors   []ImportRowError `json:"errors"`
+	Listings []Listing        `json:"listings"`
+}
+
+// ListingExport is a downloaded export file as listing-service produced it
+type ListingExport struct {
+	ContentType        string
+	ContentDisposition string
+	Data               []byte
 }
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	httplib "github.com/kunal768/cmpe202/http-lib"
@@ -28,11 +29,15 @@ type Service interface {
 	CreateListing(ctx context.Context, req CreateListingRequest) (*CreateListingResponse, error)
 	FetchAllListings(ctx context.Context, req FetchAllListingsRequest) (*FetchAllListingsResponse, error)
 	FetchListing(ctx context.Context, req FetchListingRequest) (*FetchListingResponse, error)
-	FetchUserListings(ctx context.Context) (*FetchUserListingsResponse, error)
+	FetchUserListings(ctx context.Context, req PageRequest) (*FetchUserListingsResponse, error)
 	FetchListingsByUserID(ctx context.Context, req FetchListingsByUserIDRequest) (*FetchListingsByUserIDResponse, error)
 	UpdateListing(ctx context.Context, req UpdateListingRequest) (*UpdateListingResponse, error)
+	/* renews a listing's expiry, relisting it if it was archived */
+	RenewListing(ctx context.Context, req RenewListingRequest) (*RenewListingResponse, error)
 	/* user can delete only their own listing, admin can delete all listings */
 	DeleteListing(ctx context.Context, req DeleteListingRequest) (*DeleteListingResponse, error)
+	RestoreListing(ctx context.Context, req RestoreListingRequest) (*Listing, error)
+	FetchDeletedListings(ctx context.Context) ([]Listing, error)
 	UploadMedia(ctx context.Context, r *http.Request, listingID *int64) (*UploadMediaResponse, error)
 	AddMediaURL(ctx context.Context, req AddMediaURLRequest) (*AddMediaURLResponse, error)
 	ChatSearch(ctx context.Context, req ChatSearchRequest) (*ChatSearchResponse, error)
@@ -44,10 +49,28 @@ type Service interface {
 	FetchMediaURLs(ctx context.Context, listingID int64) (*FetchMediaURLsResponse, error)
 	UpdateMediaURL(ctx context.Context, req UpdateMediaURLRequest) (*UpdateMediaURLResponse, error)
 	DeleteMediaURL(ctx context.Context, req DeleteMediaURLRequest) (*DeleteMediaURLResponse, error)
+	DeleteMedia(ctx context.Context, req DeleteMediaRequest) (*MediaChangeResponse, error)
+	ReorderMedia(ctx context.Context, req ReorderMediaRequest) (*MediaChangeResponse, error)
+	SetCover(ctx context.Context, req SetCoverRequest) (*MediaChangeResponse, error)
 	SaveListing(ctx context.Context, req SaveListingRequest) (*SaveListingResponse, error)
 	UnsaveListing(ctx context.Context, req UnsaveListingRequest) (*UnsaveListingResponse, error)
 	IsListingSaved(ctx context.Context, listingID int64) (bool, error)
-	FetchSavedListings(ctx context.Context) (*FetchSavedListingsResponse, error)
+	FetchSavedListings(ctx context.Context, req PageRequest) (*FetchSavedListingsResponse, error)
+	CreateOffer(ctx context.Context, req CreateOfferRequest) (*Offer, error)
+	RespondOffer(ctx context.Context, req RespondOfferRequest) (*RespondOfferResponse, error)
+	FetchOffers(ctx context.Context, req FetchOffersRequest) (*FetchOffersResponse, error)
+	RecordSale(ctx context.Context, req RecordSaleRequest) (*SaleResponse, error)
+	ConfirmReceipt(ctx context.Context, transactionID int64) (*Transaction, error)
+	FetchTransactions(ctx context.Context, req FetchTransactionsRequest) (*FetchTransactionsResponse, error)
+	LookupBook(ctx context.Context, isbn string) (*BookPrefillResponse, error)
+	ImportListings(ctx context.Context, body io.Reader, contentType string, dryRun bool) (*ImportReport, error)
+	ExportListings(ctx context.Context, format string) (*ListingExport, error)
+	FetchTrending(ctx context.Context, req FetchTrendingRequest) ([]TrendingListing, error)
+	FetchSimilar(ctx context.Context, req FetchSimilarRequest) ([]SimilarListing, error)
+	FetchHistory(ctx context.Context, listingID int64) ([]ListingRevision, error)
+	FetchCourse(ctx context.Context, code string) (*Course, error)
+	FetchCourseListings(ctx context.Context, req FetchCourseListingsRequest) (*FetchAllListingsResponse, error)
+	ImportCourses(ctx context.Context, csv io.Reader) (*CourseImportResult, error)
 }
 
 func NewListingService(baseUrl string, sharedSecret string) Service {
@@ -81,6 +104,37 @@ func NewListingService(baseUrl string, sharedSecret string) Service {
 	}
 }
 
+// withReason passes the admin's audit reason on to listing-service.
+func withReason(rawURL string, reason string) string {
+	if reason == "" {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + "reason=" + url.QueryEscape(reason)
+}
+
+// withPage appends the limit and cursor of req to rawURL.
+func withPage(rawURL string, req PageRequest) string {
+	q := url.Values{}
+	if req.Limit != nil {
+		q.Set("limit", strconv.Itoa(*req.Limit))
+	}
+	if req.Cursor != nil {
+		q.Set("cursor", *req.Cursor)
+	}
+	if len(q) == 0 {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + q.Encode()
+}
+
 // extractUserAndRole extracts userID and roleID from context
 func (s *svc) extractUserAndRole(ctx context.Context) (userID string, roleID string, err error) {
 	userIDVal := ctx.Value(httplib.ContextKey("userId"))
@@ -133,8 +187,7 @@ func (s *svc) CreateListing(ctx context.Context, req CreateListingRequest) (*Cre
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var listing Listing
@@ -159,6 +212,12 @@ func (s *svc) FetchAllListings(ctx context.Context, req FetchAllListingsRequest)
 	if req.Offset != nil {
 		q.Set("offset", strconv.Itoa(*req.Offset))
 	}
+	if req.Cursor != nil {
+		q.Set("cursor", *req.Cursor)
+	}
+	if req.IncludeCount != nil {
+		q.Set("include_count", strconv.FormatBool(*req.IncludeCount))
+	}
 	if req.Sort != nil {
 		q.Set("sort", *req.Sort)
 	}
@@ -177,6 +236,27 @@ func (s *svc) FetchAllListings(ctx context.Context, req FetchAllListingsRequest)
 	if req.MaxPrice != nil {
 		q.Set("max_price", strconv.FormatInt(*req.MaxPrice, 10))
 	}
+	if req.Condition != nil {
+		q.Set("condition", string(*req.Condition))
+	}
+	if req.Pickup != nil {
+		q.Set("pickup_location", *req.Pickup)
+	}
+	if req.Tags != nil {
+		q.Set("tags", *req.Tags)
+	}
+	for key, value := range req.Attributes {
+		q.Set("attr."+key, value)
+	}
+	if req.Course != nil {
+		q.Set("course", *req.Course)
+	}
+	if req.Facets != nil {
+		q.Set("facets", *req.Facets)
+	}
+	if req.PriceBuckets != nil {
+		q.Set("price_buckets", *req.PriceBuckets)
+	}
 	u.RawQuery = q.Encode()
 
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
@@ -191,22 +271,15 @@ func (s *svc) FetchAllListings(ctx context.Context, req FetchAllListingsRequest)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
-	var result struct {
-		Items []Listing `json:"items"`
-		Count int       `json:"count"`
-	}
+	var result FetchAllListingsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &FetchAllListingsResponse{
-		Items: result.Items,
-		Count: result.Count,
-	}, nil
+	return &result, nil
 }
 
 func (s *svc) FetchListing(ctx context.Context, req FetchListingRequest) (*FetchListingResponse, error) {
@@ -217,6 +290,12 @@ func (s *svc) FetchListing(ctx context.Context, req FetchListingRequest) (*Fetch
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Owners can see their own drafts
+	if userID, roleID, err := s.extractUserAndRole(ctx); err == nil {
+		httpReq.Header.Set("X-User-ID", userID)
+		httpReq.Header.Set("X-Role-ID", roleID)
+	}
+
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
@@ -224,8 +303,7 @@ func (s *svc) FetchListing(ctx context.Context, req FetchListingRequest) (*Fetch
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var listing Listing
@@ -236,13 +314,13 @@ func (s *svc) FetchListing(ctx context.Context, req FetchListingRequest) (*Fetch
 	return &FetchListingResponse{Listing: &listing}, nil
 }
 
-func (s *svc) FetchUserListings(ctx context.Context) (*FetchUserListingsResponse, error) {
+func (s *svc) FetchUserListings(ctx context.Context, req PageRequest) (*FetchUserListingsResponse, error) {
 	userID, roleID, err := s.extractUserAndRole(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fullURL := s.config.URL + "/listings/user-lists/"
+	fullURL := withPage(s.config.URL+"/listings/user-lists/", req)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
@@ -259,8 +337,7 @@ func (s *svc) FetchUserListings(ctx context.Context) (*FetchUserListingsResponse
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var listings []Listing
@@ -268,7 +345,7 @@ func (s *svc) FetchUserListings(ctx context.Context) (*FetchUserListingsResponse
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &FetchUserListingsResponse{Listings: listings}, nil
+	return &FetchUserListingsResponse{Listings: listings, NextCursor: resp.Header.Get("X-Next-Cursor")}, nil
 }
 
 func (s *svc) FetchListingsByUserID(ctx context.Context, req FetchListingsByUserIDRequest) (*FetchListingsByUserIDResponse, error) {
@@ -284,7 +361,7 @@ func (s *svc) FetchListingsByUserID(ctx context.Context, req FetchListingsByUser
 	}
 
 	// Build URL with user_id query parameter
-	fullURL := s.config.URL + "/listings/by-user-id?user_id=" + req.UserID.String()
+	fullURL := withPage(s.config.URL+"/listings/by-user-id?user_id="+req.UserID.String(), req.PageRequest)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
@@ -302,8 +379,7 @@ func (s *svc) FetchListingsByUserID(ctx context.Context, req FetchListingsByUser
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var listings []Listing
@@ -311,7 +387,7 @@ func (s *svc) FetchListingsByUserID(ctx context.Context, req FetchListingsByUser
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &FetchListingsByUserIDResponse{Listings: listings}, nil
+	return &FetchListingsByUserIDResponse{Listings: listings, NextCursor: resp.Header.Get("X-Next-Cursor")}, nil
 }
 
 func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*UpdateListingResponse, error) {
@@ -321,17 +397,27 @@ func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*Upd
 	}
 
 	updateParams := struct {
-		Title       *string   `json:"title,omitempty"`
-		Description *string   `json:"description,omitempty"`
-		Price       *int64    `json:"price,omitempty"`
-		Category    *Category `json:"category,omitempty"`
-		Status      *Status   `json:"status,omitempty"`
+		Title          *string     `json:"title,omitempty"`
+		Description    *string     `json:"description,omitempty"`
+		Price          *int64      `json:"price,omitempty"`
+		Category       *Category   `json:"category,omitempty"`
+		Status         *Status     `json:"status,omitempty"`
+		Condition      *Condition  `json:"condition,omitempty"`
+		PickupLocation *string     `json:"pickup_location,omitempty"`
+		Tags           *[]string   `json:"tags,omitempty"`
+		Attributes     *Attributes `json:"attributes,omitempty"`
+		PublishAt      *time.Time  `json:"publish_at,omitempty"`
 	}{
-		Title:       req.Title,
-		Description: req.Description,
-		Price:       req.Price,
-		Category:    req.Category,
-		Status:      req.Status,
+		Title:          req.Title,
+		Description:    req.Description,
+		Price:          req.Price,
+		Category:       req.Category,
+		Status:         req.Status,
+		Condition:      req.Condition,
+		PickupLocation: req.PickupLocation,
+		Tags:           req.Tags,
+		Attributes:     req.Attributes,
+		PublishAt:      req.PublishAt,
 	}
 
 	reqBody, err := json.Marshal(updateParams)
@@ -339,7 +425,7 @@ func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*Upd
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	fullURL := fmt.Sprintf("%s/listings/update/%d", s.config.URL, req.ID)
+	fullURL := withReason(fmt.Sprintf("%s/listings/update/%d", s.config.URL, req.ID), req.Reason)
 	httpReq, err := http.NewRequestWithContext(ctx, "PATCH", fullURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -348,6 +434,9 @@ func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*Upd
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-User-ID", userID)
 	httpReq.Header.Set("X-Role-ID", roleID)
+	if req.IfMatch != "" {
+		httpReq.Header.Set("If-Match", req.IfMatch)
+	}
 
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
@@ -356,8 +445,7 @@ func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*Upd
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var listing Listing
@@ -368,6 +456,42 @@ func (s *svc) UpdateListing(ctx context.Context, req UpdateListingRequest) (*Upd
 	return &UpdateListingResponse{Listing: &listing}, nil
 }
 
+func (s *svc) RenewListing(ctx context.Context, req RenewListingRequest) (*RenewListingResponse, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/listings/renew/%d", s.config.URL, req.ID)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+	if req.IfMatch != "" {
+		httpReq.Header.Set("If-Match", req.IfMatch)
+	}
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var listing Listing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &RenewListingResponse{Listing: &listing}, nil
+}
+
 func (s *svc) DeleteListing(ctx context.Context, req DeleteListingRequest) (*DeleteListingResponse, error) {
 	userID, roleID, err := s.extractUserAndRole(ctx)
 	if err != nil {
@@ -378,6 +502,7 @@ func (s *svc) DeleteListing(ctx context.Context, req DeleteListingRequest) (*Del
 	if req.Hard != nil && *req.Hard {
 		fullURL += "?hard=true"
 	}
+	fullURL = withReason(fullURL, req.Reason)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", fullURL, nil)
 	if err != nil {
@@ -394,8 +519,7 @@ func (s *svc) DeleteListing(ctx context.Context, req DeleteListingRequest) (*Del
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -408,6 +532,71 @@ func (s *svc) DeleteListing(ctx context.Context, req DeleteListingRequest) (*Del
 	return &DeleteListingResponse{Status: result.Status}, nil
 }
 
+func (s *svc) RestoreListing(ctx context.Context, req RestoreListingRequest) (*Listing, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := withReason(fmt.Sprintf("%s/listings/restore/%d", s.config.URL, req.ID), req.Reason)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var listing Listing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &listing, nil
+}
+
+func (s *svc) FetchDeletedListings(ctx context.Context) ([]Listing, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", s.config.URL+"/listings/deleted", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var listings []Listing
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listings, nil
+}
+
 func (s *svc) UploadMedia(ctx context.Context, r *http.Request, listingID *int64) (*UploadMediaResponse, error) {
 	userID, roleID, err := s.extractUserAndRole(ctx)
 	if err != nil {
@@ -432,8 +621,7 @@ func (s *svc) UploadMedia(ctx context.Context, r *http.Request, listingID *int64
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -482,9 +670,11 @@ func (s *svc) AddMediaURL(ctx context.Context, req AddMediaURLRequest) (*AddMedi
 	}
 
 	reqBody := struct {
-		MediaUrls []string `json:"media_urls"`
+		MediaUrls []string     `json:"media_urls,omitempty"`
+		Media     []MediaInput `json:"media,omitempty"`
 	}{
 		MediaUrls: req.MediaUrls,
+		Media:     req.Media,
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -501,6 +691,9 @@ func (s *svc) AddMediaURL(ctx context.Context, req AddMediaURLRequest) (*AddMedi
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-User-ID", userID)
 	httpReq.Header.Set("X-Role-ID", roleID)
+	if req.IfMatch != "" {
+		httpReq.Header.Set("If-Match", req.IfMatch)
+	}
 
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
@@ -509,34 +702,19 @@ func (s *svc) AddMediaURL(ctx context.Context, req AddMediaURLRequest) (*AddMedi
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
-	var result struct {
-		Message string `json:"message"`
-		Count   int    `json:"count"`
-	}
+	var result AddMediaURLResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &AddMediaURLResponse{
-		Message: result.Message,
-		Count:   result.Count,
-	}, nil
+	return &result, nil
 }
 
 func (s *svc) ChatSearch(ctx context.Context, req ChatSearchRequest) (*ChatSearchResponse, error) {
-	reqBody := struct {
-		Query               string        `json:"query"`
-		ConversationHistory []ChatMessage `json:"conversation_history,omitempty"`
-	}{
-		Query:               req.Query,
-		ConversationHistory: req.ConversationHistory,
-	}
-
-	bodyBytes, err := json.Marshal(reqBody)
+	bodyBytes, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -556,16 +734,19 @@ func (s *svc) ChatSearch(ctx context.Context, req ChatSearchRequest) (*ChatSearc
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
-	var listings []Listing
-	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+	var result struct {
+		Items   []Listing      `json:"items"`
+		Filters *SearchFilters `json:"filters"`
+		Facets  *Facets        `json:"facets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &ChatSearchResponse{Listings: listings}, nil
+	return &ChatSearchResponse{Listings: result.Items, Filters: result.Filters, Facets: result.Facets}, nil
 }
 
 func (s *svc) FetchFlaggedListings(ctx context.Context, req FetchFlaggedListingsRequest) (*FetchFlaggedListingsResponse, error) {
@@ -609,8 +790,7 @@ func (s *svc) FetchFlaggedListings(ctx context.Context, req FetchFlaggedListings
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var flaggedListings []FlaggedListing
@@ -662,19 +842,7 @@ func (s *svc) FlagListing(ctx context.Context, req FlagListingRequest) (*FlagLis
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		if resp.StatusCode == http.StatusConflict {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			// Try to parse error message
-			var errorResp struct {
-				Message string `json:"message"`
-			}
-			if err := json.Unmarshal(bodyBytes, &errorResp); err == nil && errorResp.Message != "" {
-				return nil, fmt.Errorf(errorResp.Message)
-			}
-			return nil, fmt.Errorf("user has already flagged this listing")
-		}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var flaggedListing FlaggedListing
@@ -710,8 +878,7 @@ func (s *svc) HasUserFlaggedListing(ctx context.Context, listingID int64) (bool,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return false, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -750,7 +917,7 @@ func (s *svc) UpdateFlagListing(ctx context.Context, req UpdateFlagListingReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	fullURL := fmt.Sprintf("%s/listings/flag/%d", s.config.URL, req.FlagID)
+	fullURL := withReason(fmt.Sprintf("%s/listings/flag/%d", s.config.URL, req.FlagID), req.Reason)
 	httpReq, err := http.NewRequestWithContext(ctx, "PATCH", fullURL, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -768,8 +935,7 @@ func (s *svc) UpdateFlagListing(ctx context.Context, req UpdateFlagListingReques
 
 	// Listing-service returns StatusCreated for updates, but StatusOK is also acceptable
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var flaggedListing FlaggedListing
@@ -792,7 +958,7 @@ func (s *svc) DeleteFlagListing(ctx context.Context, req DeleteFlagListingReques
 		return nil, fmt.Errorf("admin access required")
 	}
 
-	fullURL := fmt.Sprintf("%s/listings/flag/%d", s.config.URL, req.FlagID)
+	fullURL := withReason(fmt.Sprintf("%s/listings/flag/%d", s.config.URL, req.FlagID), req.Reason)
 	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -808,11 +974,7 @@ func (s *svc) DeleteFlagListing(ctx context.Context, req DeleteFlagListingReques
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("flag not found")
-		}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -837,6 +999,12 @@ func (s *svc) FetchMediaURLs(ctx context.Context, listingID int64) (*FetchMediaU
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Owners can see the media of their own drafts
+	if userID, roleID, err := s.extractUserAndRole(ctx); err == nil {
+		httpReq.Header.Set("X-User-ID", userID)
+		httpReq.Header.Set("X-Role-ID", roleID)
+	}
+
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
@@ -844,8 +1012,7 @@ func (s *svc) FetchMediaURLs(ctx context.Context, listingID int64) (*FetchMediaU
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var media []ListingMedia
@@ -863,9 +1030,13 @@ func (s *svc) UpdateMediaURL(ctx context.Context, req UpdateMediaURLRequest) (*U
 	}
 
 	reqBody := struct {
-		NewURL string `json:"new_url"`
+		NewURL  string  `json:"new_url,omitempty"`
+		AltText *string `json:"alt_text,omitempty"`
+		Caption *string `json:"caption,omitempty"`
 	}{
-		NewURL: req.NewURL,
+		NewURL:  req.NewURL,
+		AltText: req.AltText,
+		Caption: req.Caption,
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -873,7 +1044,7 @@ func (s *svc) UpdateMediaURL(ctx context.Context, req UpdateMediaURLRequest) (*U
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	fullURL := fmt.Sprintf("%s/listings/%d/media/%d", s.config.URL, req.ListingID, req.MediaID)
+	fullURL := withReason(fmt.Sprintf("%s/listings/%d/media/%d", s.config.URL, req.ListingID, req.MediaID), req.Reason)
 	httpReq, err := http.NewRequestWithContext(ctx, "PATCH", fullURL, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -882,6 +1053,9 @@ func (s *svc) UpdateMediaURL(ctx context.Context, req UpdateMediaURLRequest) (*U
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-User-ID", userID)
 	httpReq.Header.Set("X-Role-ID", roleID)
+	if req.IfMatch != "" {
+		httpReq.Header.Set("If-Match", req.IfMatch)
+	}
 
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
@@ -890,18 +1064,15 @@ func (s *svc) UpdateMediaURL(ctx context.Context, req UpdateMediaURLRequest) (*U
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
-	var result struct {
-		Message string `json:"message"`
-	}
+	var result UpdateMediaURLResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &UpdateMediaURLResponse{Message: result.Message}, nil
+	return &result, nil
 }
 
 func (s *svc) DeleteMediaURL(ctx context.Context, req DeleteMediaURLRequest) (*DeleteMediaURLResponse, error) {
@@ -921,7 +1092,7 @@ func (s *svc) DeleteMediaURL(ctx context.Context, req DeleteMediaURLRequest) (*D
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	fullURL := fmt.Sprintf("%s/listings/%d/media", s.config.URL, req.ListingID)
+	fullURL := withReason(fmt.Sprintf("%s/listings/%d/media", s.config.URL, req.ListingID), req.Reason)
 	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", fullURL, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -930,6 +1101,9 @@ func (s *svc) DeleteMediaURL(ctx context.Context, req DeleteMediaURLRequest) (*D
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-User-ID", userID)
 	httpReq.Header.Set("X-Role-ID", roleID)
+	if req.IfMatch != "" {
+		httpReq.Header.Set("If-Match", req.IfMatch)
+	}
 
 	resp, err := s.config.Client.Do(httpReq)
 	if err != nil {
@@ -938,18 +1112,74 @@ func (s *svc) DeleteMediaURL(ctx context.Context, req DeleteMediaURLRequest) (*D
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
-	var result struct {
-		Message string `json:"message"`
+	var result DeleteMediaURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (s *svc) DeleteMedia(ctx context.Context, req DeleteMediaRequest) (*MediaChangeResponse, error) {
+	fullURL := withReason(fmt.Sprintf("%s/listings/%d/media/%d", s.config.URL, req.ListingID, req.MediaID), req.Reason)
+	return s.changeMedia(ctx, "DELETE", fullURL, nil, req.IfMatch)
+}
+
+func (s *svc) ReorderMedia(ctx context.Context, req ReorderMediaRequest) (*MediaChangeResponse, error) {
+	bodyBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	fullURL := fmt.Sprintf("%s/listings/%d/media/order", s.config.URL, req.ListingID)
+	return s.changeMedia(ctx, "PUT", fullURL, bodyBytes, req.IfMatch)
+}
+
+func (s *svc) SetCover(ctx context.Context, req SetCoverRequest) (*MediaChangeResponse, error) {
+	fullURL := fmt.Sprintf("%s/listings/%d/media/%d/cover", s.config.URL, req.ListingID, req.MediaID)
+	return s.changeMedia(ctx, "POST", fullURL, nil, req.IfMatch)
+}
+
+// changeMedia sends a gallery change to the listing service on behalf of
+// the caller and returns the new listing version.
+func (s *svc) changeMedia(ctx context.Context, method, fullURL string, body []byte, ifMatch string) (*MediaChangeResponse, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+	if ifMatch != "" {
+		httpReq.Header.Set("If-Match", ifMatch)
+	}
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
 	}
+
+	var result MediaChangeResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &DeleteMediaURLResponse{Message: result.Message}, nil
+	return &result, nil
 }
 
 func (s *svc) SaveListing(ctx context.Context, req SaveListingRequest) (*SaveListingResponse, error) {
@@ -975,11 +1205,7 @@ func (s *svc) SaveListing(ctx context.Context, req SaveListingRequest) (*SaveLis
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("listing not found")
-		}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -1015,11 +1241,7 @@ func (s *svc) UnsaveListing(ctx context.Context, req UnsaveListingRequest) (*Uns
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("saved listing not found")
-		}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -1055,8 +1277,7 @@ func (s *svc) IsListingSaved(ctx context.Context, listingID int64) (bool, error)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return false, httplib.ProblemFromResponse(resp)
 	}
 
 	var result struct {
@@ -1069,14 +1290,14 @@ func (s *svc) IsListingSaved(ctx context.Context, listingID int64) (bool, error)
 	return result.IsSaved, nil
 }
 
-func (s *svc) FetchSavedListings(ctx context.Context) (*FetchSavedListingsResponse, error) {
+func (s *svc) FetchSavedListings(ctx context.Context, req PageRequest) (*FetchSavedListingsResponse, error) {
 	// Extract and validate user authentication
 	userID, roleID, err := s.extractUserAndRole(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fullURL := s.config.URL + "/listings/saved"
+	fullURL := withPage(s.config.URL+"/listings/saved", req)
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -1092,8 +1313,7 @@ func (s *svc) FetchSavedListings(ctx context.Context) (*FetchSavedListingsRespon
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, httplib.ProblemFromResponse(resp)
 	}
 
 	var savedListings []SavedListing
@@ -1101,5 +1321,466 @@ func (s *svc) FetchSavedListings(ctx context.Context) (*FetchSavedListingsRespon
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &FetchSavedListingsResponse{SavedListings: savedListings}, nil
+	return &FetchSavedListingsResponse{SavedListings: savedListings, NextCursor: resp.Header.Get("X-Next-Cursor")}, nil
+}
+
+func (s *svc) CreateOffer(ctx context.Context, req CreateOfferRequest) (*Offer, error) {
+	var offer Offer
+	fullURL := fmt.Sprintf("%s/listings/offers/%d", s.config.URL, req.ListingID)
+	if err := s.postJSON(ctx, fullURL, req, http.StatusCreated, &offer); err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (s *svc) RespondOffer(ctx context.Context, req RespondOfferRequest) (*RespondOfferResponse, error) {
+	var res RespondOfferResponse
+	fullURL := fmt.Sprintf("%s/listings/offers/respond/%d", s.config.URL, req.OfferID)
+	if err := s.postJSON(ctx, fullURL, req, http.StatusOK, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// postJSON posts body as the calling user and decodes the response into out
+// when listing-service answers with wantStatus.
+func (s *svc) postJSON(ctx context.Context, fullURL string, body any, wantStatus int, out any) error {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return err
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		return httplib.ProblemFromResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (s *svc) FetchOffers(ctx context.Context, req FetchOffersRequest) (*FetchOffersResponse, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	if req.Role != nil {
+		q.Set("role", *req.Role)
+	}
+	if req.ListingID != nil {
+		q.Set("listing_id", strconv.FormatInt(*req.ListingID, 10))
+	}
+	fullURL := s.config.URL + "/listings/offers"
+	if len(q) > 0 {
+		fullURL += "?" + q.Encode()
+	}
+	fullURL = withPage(fullURL, req.PageRequest)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var offers []Offer
+	if err := json.NewDecoder(resp.Body).Decode(&offers); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &FetchOffersResponse{Offers: offers, NextCursor: resp.Header.Get("X-Next-Cursor")}, nil
+}
+
+func (s *svc) RecordSale(ctx context.Context, req RecordSaleRequest) (*SaleResponse, error) {
+	var t SaleResponse
+	fullURL := fmt.Sprintf("%s/listings/sell/%d", s.config.URL, req.ListingID)
+	if err := s.postJSON(ctx, fullURL, req, http.StatusCreated, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *svc) ConfirmReceipt(ctx context.Context, transactionID int64) (*Transaction, error) {
+	var t Transaction
+	fullURL := fmt.Sprintf("%s/listings/transactions/confirm/%d", s.config.URL, transactionID)
+	if err := s.postJSON(ctx, fullURL, struct{}{}, http.StatusOK, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *svc) FetchTransactions(ctx context.Context, req FetchTransactionsRequest) (*FetchTransactionsResponse, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := s.config.URL + "/listings/transactions"
+	if req.Role != nil {
+		fullURL += "?" + url.Values{"role": {*req.Role}}.Encode()
+	}
+	fullURL = withPage(fullURL, req.PageRequest)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var txns []Transaction
+	if err := json.NewDecoder(resp.Body).Decode(&txns); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &FetchTransactionsResponse{Transactions: txns, NextCursor: resp.Header.Get("X-Next-Cursor")}, nil
+}
+
+func (s *svc) LookupBook(ctx context.Context, isbn string) (*BookPrefillResponse, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := s.config.URL + "/listings/books/" + url.PathEscape(isbn)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var prefill BookPrefillResponse
+	if err := json.NewDecoder(resp.Body).Decode(&prefill); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &prefill, nil
+}
+
+func (s *svc) FetchTrending(ctx context.Context, req FetchTrendingRequest) ([]TrendingListing, error) {
+	q := url.Values{}
+	if req.Category != nil {
+		q.Set("category", string(*req.Category))
+	}
+	if req.Limit != nil {
+		q.Set("limit", strconv.Itoa(*req.Limit))
+	}
+	fullURL := s.config.URL + "/listings/trending"
+	if len(q) > 0 {
+		fullURL += "?" + q.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var listings []TrendingListing
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return listings, nil
+}
+
+func (s *svc) FetchSimilar(ctx context.Context, req FetchSimilarRequest) ([]SimilarListing, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/listings/%d/similar", s.config.URL, req.ID)
+	if req.Limit != nil {
+		fullURL += "?" + url.Values{"limit": {strconv.Itoa(*req.Limit)}}.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The caller's own listings are left out
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var listings []SimilarListing
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return listings, nil
+}
+
+func (s *svc) FetchHistory(ctx context.Context, listingID int64) ([]ListingRevision, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/listings/%d/history", s.config.URL, listingID)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var revisions []ListingRevision
+	if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return revisions, nil
+}
+
+func (s *svc) FetchCourse(ctx context.Context, code string) (*Course, error) {
+	fullURL := s.config.URL + "/listings/courses/" + url.PathEscape(code)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var course Course
+	if err := json.NewDecoder(resp.Body).Decode(&course); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &course, nil
+}
+
+func (s *svc) FetchCourseListings(ctx context.Context, req FetchCourseListingsRequest) (*FetchAllListingsResponse, error) {
+	fullURL := s.config.URL + "/listings/courses/" + url.PathEscape(req.Code) + "/listings"
+	if req.Sort != nil {
+		fullURL += "?" + url.Values{"sort": {*req.Sort}}.Encode()
+	}
+	fullURL = withPage(fullURL, req.PageRequest)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var page FetchAllListingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &page, nil
+}
+
+// ImportCourses forwards a course catalog CSV to listing-service as is
+func (s *svc) ImportCourses(ctx context.Context, csv io.Reader) (*CourseImportResult, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.config.URL+"/listings/courses/import", csv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "text/csv")
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var res CourseImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &res, nil
+}
+
+// ImportListings forwards a JSON or CSV bulk import to listing-service as is
+func (s *svc) ImportListings(ctx context.Context, body io.Reader, contentType string, dryRun bool) (*ImportReport, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := s.config.URL + "/listings/import"
+	wantStatus := http.StatusCreated
+	if dryRun {
+		fullURL += "?dry_run=true"
+		wantStatus = http.StatusOK
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	var report ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &report, nil
+}
+
+func (s *svc) ExportListings(ctx context.Context, format string) (*ListingExport, error) {
+	userID, roleID, err := s.extractUserAndRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := s.config.URL + "/listings/export"
+	if format != "" {
+		fullURL += "?" + url.Values{"format": {format}}.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("X-User-ID", userID)
+	httpReq.Header.Set("X-Role-ID", roleID)
+
+	resp, err := s.config.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httplib.ProblemFromResponse(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &ListingExport{
+		ContentType:        resp.Header.Get("Content-Type"),
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+		Data:               data,
+	}, nil
 }
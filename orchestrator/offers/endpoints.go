@@ -0,0 +1,169 @@
+package offers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Endpoints struct {
+	service     Service
+	idempotency *httplib.Idempotency
+}
+
+// NewEndpoints creates offer endpoints. idempotency may be nil to disable
+// Idempotency-Key replay.
+func NewEndpoints(service Service, idempotency *httplib.Idempotency) *Endpoints {
+	return &Endpoints{
+		service:     service,
+		idempotency: idempotency,
+	}
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if !ok {
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+	}
+	return userID, ok
+}
+
+// ListOffersHandler lists the offers the caller made or received
+func (e *Endpoints) ListOffersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var req listings.FetchOffersRequest
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		req.Cursor = &cursor
+	}
+	if role := q.Get("role"); role != "" {
+		req.Role = &role
+	}
+	if idStr := q.Get("listing_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+			return
+		}
+		req.ListingID = &id
+	}
+
+	response, err := e.service.List(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to fetch offers", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// CreateOfferHandler makes an offer on a listing
+func (e *Endpoints) CreateOfferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req listings.CreateOfferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+	if req.ListingID <= 0 {
+		httplib.WriteProblem(w, httplib.ValidationProblem("listing_id is required",
+			httplib.FieldError{Field: "listing_id", Code: "required", Message: "listing_id is required"}))
+		return
+	}
+
+	offer, err := e.service.Create(r.Context(), userID, req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to create offer", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusCreated, offer)
+}
+
+// RespondOfferHandler accepts, rejects, counters or withdraws an offer
+func (e *Endpoints) RespondOfferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	offerID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid offer ID format")
+		return
+	}
+	var req listings.RespondOfferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+	req.OfferID = offerID
+
+	response, err := e.service.Respond(r.Context(), userID, req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to respond to offer", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// RecordSaleHandler marks a listing sold to a buyer (owner only)
+func (e *Endpoints) RecordSaleHandler(w http.ResponseWriter, r *http.Request) {
+	listingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid listing ID format")
+		return
+	}
+
+	var req listings.RecordSaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+	if req.BuyerID == uuid.Nil {
+		httplib.WriteProblem(w, httplib.ValidationProblem("buyer_id is required",
+			httplib.FieldError{Field: "buyer_id", Code: "required", Message: "buyer_id is required"}))
+		return
+	}
+	req.ListingID = listingID
+
+	response, err := e.service.Sell(r.Context(), req)
+	if err != nil {
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Failed to record sale", err)
+		return
+	}
+	httplib.WriteJSON(w, http.StatusCreated, response)
+}
+
+// RegisterRoutes registers all offer routes with proper middleware
+func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
+	// Protected routes: require auth + role injection
+	protected := func(h http.Handler) http.Handler {
+		return httplib.AuthMiddleWare(
+			httplib.RoleInjectionMiddleWare(dbPool)(
+				httplib.JSONRequestDecoder(h),
+			),
+		)
+	}
+	idempotent := func(h http.Handler) http.Handler {
+		return e.idempotency.Middleware(h)
+	}
+
+	mux.Handle("GET /api/offers", protected(http.HandlerFunc(e.ListOffersHandler)))
+	mux.Handle("POST /api/offers", protected(idempotent(http.HandlerFunc(e.CreateOfferHandler))))
+	mux.Handle("POST /api/offers/{id}/respond", protected(idempotent(http.HandlerFunc(e.RespondOfferHandler))))
+	// Selling closes the listing's open offers, so the sale is recorded here
+	// where their buyers can be told
+	mux.Handle("POST /api/listings/sell/{id}", protected(idempotent(http.HandlerFunc(e.RecordSaleHandler))))
+}
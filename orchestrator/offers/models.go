@@ -0,0 +1,24 @@
+package offers
+
+import "github.com/kunal768/cmpe202/orchestrator/listings"
+
+// MessageTypeOffer is the chat message type of offer events. Their content
+// is a JSON encoded Event instead of plain text.
+const MessageTypeOffer = "offer"
+
+// Offer events posted to the conversation between buyer and seller
+const (
+	EventCreated   = "created"
+	EventCountered = "countered"
+	EventAccepted  = "accepted"
+	EventRejected  = "rejected"
+	EventWithdrawn = "withdrawn"
+)
+
+// Event is the content of an offer chat message
+type Event struct {
+	Event string         `json:"event"`
+	Offer listings.Offer `json:"offer"`
+	// Text is a plain summary for conversation previews and older clients
+	Text string `json:"text"`
+}
@@ -0,0 +1,133 @@
+package offers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kunal768/cmpe202/orchestrator/internal/queue"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Service interface {
+	Create(ctx context.Context, userID string, req listings.CreateOfferRequest) (*listings.Offer, error)
+	Respond(ctx context.Context, userID string, req listings.RespondOfferRequest) (*listings.RespondOfferResponse, error)
+	List(ctx context.Context, req listings.FetchOffersRequest) (*listings.FetchOffersResponse, error)
+	Sell(ctx context.Context, req listings.RecordSaleRequest) (*listings.SaleResponse, error)
+}
+
+type service struct {
+	listings  listings.Service
+	publisher queue.Publisher
+}
+
+// NewService creates the offer service on top of listing-service. Offer
+// events are posted to the buyer's and seller's conversation through the
+// chat queue; without a publisher they are only visible via the offers API.
+func NewService(listingService listings.Service, publisher queue.Publisher) Service {
+	return &service{
+		listings:  listingService,
+		publisher: publisher,
+	}
+}
+
+func (s *service) Create(ctx context.Context, userID string, req listings.CreateOfferRequest) (*listings.Offer, error) {
+	offer, err := s.listings.CreateOffer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.post(ctx, userID, EventCreated, *offer, fmt.Sprintf("Offered %s", formatPrice(offer.Amount)))
+	return offer, nil
+}
+
+func (s *service) Respond(ctx context.Context, userID string, req listings.RespondOfferRequest) (*listings.RespondOfferResponse, error) {
+	res, err := s.listings.RespondOffer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	o := res.Offer
+	switch o.Status {
+	case listings.OfferPending:
+		s.post(ctx, userID, EventCountered, o, fmt.Sprintf("Countered with %s", formatPrice(o.Amount)))
+	case listings.OfferAccepted:
+		s.post(ctx, userID, EventAccepted, o, fmt.Sprintf("Accepted the offer of %s", formatPrice(o.Amount)))
+	case listings.OfferRejected:
+		s.post(ctx, userID, EventRejected, o, fmt.Sprintf("Declined the offer of %s", formatPrice(o.Amount)))
+	case listings.OfferWithdrawn:
+		s.post(ctx, userID, EventWithdrawn, o, fmt.Sprintf("Withdrew the offer of %s", formatPrice(o.Amount)))
+	}
+	for _, d := range res.Declined {
+		s.post(ctx, d.SellerID.String(), EventRejected, d,
+			fmt.Sprintf("Declined the offer of %s: the listing was reserved for another buyer", formatPrice(d.Amount)))
+	}
+	return res, nil
+}
+
+func (s *service) List(ctx context.Context, req listings.FetchOffersRequest) (*listings.FetchOffersResponse, error) {
+	res, err := s.listings.FetchOffers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Offers == nil {
+		res.Offers = []listings.Offer{}
+	}
+	return res, nil
+}
+
+// Sell records a sale and tells the other buyers with an open offer that it
+// was declined because the listing was sold.
+func (s *service) Sell(ctx context.Context, req listings.RecordSaleRequest) (*listings.SaleResponse, error) {
+	res, err := s.listings.RecordSale(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range res.Declined {
+		s.post(ctx, d.SellerID.String(), EventRejected, d,
+			fmt.Sprintf("Declined the offer of %s: the listing was sold to another buyer", formatPrice(d.Amount)))
+	}
+	return res, nil
+}
+
+// post queues an offer event from senderID to the other party as a chat
+// message, so chat-consumer stores and delivers it like any other message.
+func (s *service) post(ctx context.Context, senderID, event string, o listings.Offer, text string) {
+	if s.publisher == nil {
+		return
+	}
+	if o.Message != nil && *o.Message != "" && (event == EventCreated || event == EventCountered) {
+		text += ": " + *o.Message
+	}
+	recipientID := o.BuyerID.String()
+	if senderID == recipientID {
+		recipientID = o.SellerID.String()
+	}
+
+	content, err := json.Marshal(Event{Event: event, Offer: o, Text: text})
+	if err != nil {
+		log.Printf("Failed to encode offer %d event: %v", o.ID, err)
+		return
+	}
+	msg, err := json.Marshal(map[string]any{
+		"messageId":   uuid.NewString(),
+		"senderId":    senderID,
+		"recipientId": recipientID,
+		"content":     string(content),
+		"timestamp":   time.Now().UTC(),
+		"type":        MessageTypeOffer,
+	})
+	if err != nil {
+		log.Printf("Failed to encode offer %d message: %v", o.ID, err)
+		return
+	}
+	if err := s.publisher.Publish(ctx, msg); err != nil {
+		log.Printf("Failed to post offer %d event to chat: %v", o.ID, err)
+	}
+}
+
+func formatPrice(cents int64) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
@@ -0,0 +1,10 @@
+package pricedrops
+
+// Drop is a price decrease recorded in listing_price_history, sent as the data
+// of a price_drop notification.
+type Drop struct {
+	ListingID int64  `json:"listing_id"`
+	Title     string `json:"title"`
+	OldPrice  int64  `json:"old_price"`
+	NewPrice  int64  `json:"new_price"`
+}
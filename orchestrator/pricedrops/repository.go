@@ -0,0 +1,81 @@
+package pricedrops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// ClaimDrop marks the latest price change of a listing as notified and
+	// returns it if it was an unannounced drop, or nil otherwise
+	ClaimDrop(ctx context.Context, listingID int64) (*Drop, error)
+	// Savers returns the users other than the owner who saved the listing
+	Savers(ctx context.Context, listingID int64) ([]string, error)
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+func (r *repo) ClaimDrop(ctx context.Context, listingID int64) (*Drop, error) {
+	// Only the latest change counts, so a drop that was already raised
+	// again is not announced. Claiming with notified_at IS NULL keeps
+	// concurrent updates from alerting twice.
+	d := Drop{ListingID: listingID}
+	err := r.db.QueryRow(ctx, `
+		UPDATE listing_price_history h
+		SET notified_at = NOW()
+		FROM listings l
+		WHERE h.id = (
+			SELECT id FROM listing_price_history
+			WHERE listing_id = $1
+			ORDER BY changed_at DESC, id DESC
+			LIMIT 1
+		)
+		AND l.id = h.listing_id
+		AND l.deleted_at IS NULL
+		AND h.notified_at IS NULL
+		AND h.new_price < h.old_price
+		RETURNING l.title, h.old_price, h.new_price
+	`, listingID).Scan(&d.Title, &d.OldPrice, &d.NewPrice)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim price drop: %w", err)
+	}
+	return &d, nil
+}
+
+func (r *repo) Savers(ctx context.Context, listingID int64) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT sl.user_id::text
+		FROM saved_listings sl
+		JOIN listings l ON l.id = sl.listing_id
+		WHERE sl.listing_id = $1 AND sl.user_id <> l.user_id AND l.deleted_at IS NULL
+	`, listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query savers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan saver: %w", err)
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
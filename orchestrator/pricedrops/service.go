@@ -0,0 +1,62 @@
+package pricedrops
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kunal768/cmpe202/orchestrator/internal/notify"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Service interface {
+	// ListingChanged implements listings.ListingWatcher
+	ListingChanged(ctx context.Context, listing listings.Listing)
+}
+
+type service struct {
+	repo     Repository
+	notifier notify.Publisher
+}
+
+// NewService creates the price drop service. Without a notifier drops are
+// not claimed, so they stay pending rather than being silently dropped.
+func NewService(repo Repository, notifier notify.Publisher) Service {
+	return &service{
+		repo:     repo,
+		notifier: notifier,
+	}
+}
+
+// ListingChanged alerts every user who saved the listing when its price went
+// down. It runs in the background, like saved search matching.
+func (s *service) ListingChanged(ctx context.Context, listing listings.Listing) {
+	if s.notifier == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		drop, err := s.repo.ClaimDrop(ctx, listing.ID)
+		if err != nil {
+			log.Printf("Failed to check listing %d for a price drop: %v", listing.ID, err)
+			return
+		}
+		if drop == nil {
+			return
+		}
+		savers, err := s.repo.Savers(ctx, listing.ID)
+		if err != nil {
+			log.Printf("Failed to load savers of listing %d: %v", listing.ID, err)
+			return
+		}
+		for _, userID := range savers {
+			n := notify.Notification{SubType: notify.SubTypePriceDrop, Count: 1, Data: drop}
+			if err := s.notifier.Notify(ctx, userID, n); err != nil {
+				log.Printf("Failed to notify user %s of price drop: %v", userID, err)
+			}
+		}
+	}()
+}
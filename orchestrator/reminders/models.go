@@ -0,0 +1,16 @@
+package reminders
+
+import "time"
+
+// DefaultLeadTime is how long before expiry owners are reminded to renew
+const DefaultLeadTime = 72 * time.Hour
+
+// Expiring is an active listing about to be archived, sent as the data of a
+// listing_expiring notification.
+type Expiring struct {
+	ListingID int64     `json:"listing_id"`
+	Title     string    `json:"title"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    string    `json:"-"`
+	Email     string    `json:"-"`
+}
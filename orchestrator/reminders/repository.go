@@ -0,0 +1,53 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// ClaimExpiring marks active listings expiring within lead as reminded
+	// and returns them, so each expiry period is only reminded once
+	ClaimExpiring(ctx context.Context, lead time.Duration) ([]Expiring, error)
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+func (r *repo) ClaimExpiring(ctx context.Context, lead time.Duration) ([]Expiring, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE listings l
+		SET expiry_reminded_at = NOW()
+		FROM users u
+		WHERE u.user_id = l.user_id
+		AND l.status IN ('AVAILABLE', 'PENDING')
+		AND l.expiry_reminded_at IS NULL
+		AND l.deleted_at IS NULL
+		AND l.expires_at <= NOW() + make_interval(secs => $1)
+		RETURNING l.id, l.title, l.expires_at, l.user_id::text, u.email
+	`, lead.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim expiring listings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Expiring
+	for rows.Next() {
+		var e Expiring
+		if err := rows.Scan(&e.ListingID, &e.Title, &e.ExpiresAt, &e.UserID, &e.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan expiring listing: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
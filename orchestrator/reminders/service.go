@@ -0,0 +1,96 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kunal768/cmpe202/orchestrator/internal/mail"
+	"github.com/kunal768/cmpe202/orchestrator/internal/notify"
+)
+
+type Service interface {
+	// Run reminds owners of expiring listings every interval until ctx is done
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type service struct {
+	repo      Repository
+	notifier  notify.Publisher
+	mailer    mail.Mailer
+	lead      time.Duration
+	publicURL string
+}
+
+// NewService creates the expiry reminder service. Owners are reminded lead
+// before their listing expires, over WebSocket and by email when a notifier
+// or mailer is configured. publicURL is the frontend origin used for links.
+func NewService(repo Repository, notifier notify.Publisher, mailer mail.Mailer, lead time.Duration, publicURL string) Service {
+	if lead <= 0 {
+		lead = DefaultLeadTime
+	}
+	return &service{
+		repo:      repo,
+		notifier:  notifier,
+		mailer:    mailer,
+		lead:      lead,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	if s.notifier == nil && s.mailer == nil {
+		log.Println("No notifier or mailer configured; listing expiry reminders disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.remind(ctx); err != nil {
+				log.Printf("Failed to send listing expiry reminders: %v", err)
+			}
+		}
+	}
+}
+
+// remind claims the listings entering the reminder window and tells their
+// owners. A reminder that fails to send is not retried; the listing can
+// still be renewed after it was archived.
+func (s *service) remind(ctx context.Context) error {
+	expiring, err := s.repo.ClaimExpiring(ctx, s.lead)
+	if err != nil {
+		return err
+	}
+	for _, e := range expiring {
+		if s.notifier != nil {
+			n := notify.Notification{SubType: notify.SubTypeListingExpiring, Count: 1, Data: e}
+			if err := s.notifier.Notify(ctx, e.UserID, n); err != nil {
+				log.Printf("Failed to notify user %s of expiring listing %d: %v", e.UserID, e.ListingID, err)
+			}
+		}
+		if s.mailer != nil {
+			subject := fmt.Sprintf("Your listing %q expires soon", e.Title)
+			if err := s.mailer.Send(ctx, e.Email, subject, s.body(e)); err != nil {
+				log.Printf("Failed to email user %s about expiring listing %d: %v", e.UserID, e.ListingID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *service) body(e Expiring) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your listing %q will be archived on %s.\n", e.Title, e.ExpiresAt.UTC().Format("Jan 2, 2006 15:04 MST"))
+	b.WriteString("Renew it to keep it visible to buyers")
+	if s.publicURL != "" {
+		fmt.Fprintf(&b, ": %s/listing/%d", s.publicURL, e.ListingID)
+	}
+	b.WriteString(".\n")
+	return b.String()
+}
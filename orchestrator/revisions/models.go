@@ -0,0 +1,13 @@
+package revisions
+
+// Change sums up the edits of a listing that buyers have not been told
+// about, sent as the data of a listing_changed notification.
+type Change struct {
+	ListingID int64    `json:"listing_id"`
+	Title     string   `json:"title"`
+	Fields    []string `json:"fields"`
+}
+
+// noticeFields are the listing fields whose edits buyers are told about.
+// Status changes reach them through offers and chat already.
+var noticeFields = []string{"title", "description", "price", "category", "condition", "pickup_location", "attributes"}
@@ -0,0 +1,66 @@
+package revisions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// ClaimChangedFields marks the unannounced revisions of a listing as
+	// notified and returns the fields they changed
+	ClaimChangedFields(ctx context.Context, listingID int64) ([]string, error)
+	// Buyers returns the users other than the owner who contacted the seller
+	// about the listing or have an open or accepted offer on it
+	Buyers(ctx context.Context, listingID int64) ([]string, error)
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+func (r *repo) ClaimChangedFields(ctx context.Context, listingID int64) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH claimed AS (
+			UPDATE listing_revisions
+			SET notified_at = NOW()
+			WHERE listing_id = $1 AND notified_at IS NULL
+			RETURNING changes
+		)
+		SELECT DISTINCT jsonb_object_keys(changes) FROM claimed
+	`, listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim listing revisions: %w", err)
+	}
+	fields, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan changed fields: %w", err)
+	}
+	return fields, nil
+}
+
+func (r *repo) Buyers(ctx context.Context, listingID int64) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id::text FROM listing_chats WHERE listing_id = $1
+		UNION
+		SELECT buyer_id::text FROM offers WHERE listing_id = $1 AND status IN ('PENDING', 'ACCEPTED')
+		EXCEPT
+		SELECT user_id::text FROM listings WHERE id = $1
+	`, listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query buyers: %w", err)
+	}
+	buyers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan buyer: %w", err)
+	}
+	return buyers, nil
+}
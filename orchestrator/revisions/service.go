@@ -0,0 +1,72 @@
+package revisions
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/kunal768/cmpe202/orchestrator/internal/notify"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Service interface {
+	// ListingChanged implements listings.ListingWatcher
+	ListingChanged(ctx context.Context, listing listings.Listing)
+}
+
+type service struct {
+	repo     Repository
+	notifier notify.Publisher
+}
+
+// NewService creates the listing change notice service. Without a notifier
+// revisions are not claimed, so they stay pending rather than being
+// silently dropped.
+func NewService(repo Repository, notifier notify.Publisher) Service {
+	return &service{
+		repo:     repo,
+		notifier: notifier,
+	}
+}
+
+// ListingChanged tells the buyers talking to the seller of a listing that
+// it was edited, so a deal is not closed on terms that changed underneath
+// them. It runs in the background, like price drop alerts.
+func (s *service) ListingChanged(ctx context.Context, listing listings.Listing) {
+	if s.notifier == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		changed, err := s.repo.ClaimChangedFields(ctx, listing.ID)
+		if err != nil {
+			log.Printf("Failed to check listing %d for edits: %v", listing.ID, err)
+			return
+		}
+		var fields []string
+		for _, f := range noticeFields {
+			if slices.Contains(changed, f) {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			return
+		}
+		buyers, err := s.repo.Buyers(ctx, listing.ID)
+		if err != nil {
+			log.Printf("Failed to load buyers of listing %d: %v", listing.ID, err)
+			return
+		}
+		change := Change{ListingID: listing.ID, Title: listing.Title, Fields: fields}
+		for _, userID := range buyers {
+			n := notify.Notification{SubType: notify.SubTypeListingChanged, Count: 1, Data: change}
+			if err := s.notifier.Notify(ctx, userID, n); err != nil {
+				log.Printf("Failed to notify user %s of listing change: %v", userID, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package scheduled
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Repository interface {
+	// ClaimPublished clears the notice flag of the listings published by the
+	// listing-service scheduler and returns those not deleted since, so each
+	// one is announced only once
+	ClaimPublished(ctx context.Context) ([]listings.Listing, error)
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+func (r *repo) ClaimPublished(ctx context.Context) ([]listings.Listing, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH claimed AS (
+			UPDATE listings
+			SET publish_notice_pending = FALSE
+			WHERE publish_notice_pending
+			RETURNING id, title, price, category, user_id, status, version, created_at, updated_at, deleted_at
+		)
+		SELECT id, title, price, category, user_id, status, version, created_at, updated_at
+		FROM claimed
+		WHERE deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim published listings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []listings.Listing
+	for rows.Next() {
+		var l listings.Listing
+		if err := rows.Scan(&l.ID, &l.Title, &l.Price, &l.Category, &l.UserID, &l.Status, &l.Version, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan published listing: %w", err)
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
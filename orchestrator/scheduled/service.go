@@ -0,0 +1,49 @@
+package scheduled
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Service interface {
+	// Run announces scheduled listings every interval until ctx is done
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type service struct {
+	repo    Repository
+	watcher listings.ListingWatcher
+}
+
+// NewService creates the service announcing listings that listing-service
+// published on schedule to the listing watchers, as if they had been
+// published through the orchestrator.
+func NewService(repo Repository, watcher listings.ListingWatcher) Service {
+	return &service{
+		repo:    repo,
+		watcher: watcher,
+	}
+}
+
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := s.repo.ClaimPublished(ctx)
+			if err != nil {
+				log.Printf("Failed to announce scheduled listings: %v", err)
+				continue
+			}
+			for _, l := range published {
+				s.watcher.ListingChanged(ctx, l)
+			}
+		}
+	}
+}
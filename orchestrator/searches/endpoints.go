@@ -0,0 +1,221 @@
+package searches
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+type Endpoints struct {
+	service Service
+}
+
+func NewEndpoints(service Service) *Endpoints {
+	return &Endpoints{
+		service: service,
+	}
+}
+
+var (
+	validCategories = []listings.Category{listings.CatTextbook, listings.CatGadget, listings.CatEssential, listings.CatNonEssential, listings.CatOther}
+	validStatuses   = []listings.Status{listings.StAvailable, listings.StPending, listings.StSold, listings.StArchived, listings.StReported}
+	validSorts      = []string{"", "created_at_desc", "price_asc", "price_desc"}
+	validConditions = []string{"NEW", "LIKE_NEW", "GOOD", "FAIR", "POOR"}
+)
+
+// validateSavedSearch checks the name and filters of a saved search,
+// normalising the structured filters in place
+func validateSavedSearch(name string, f *Filters) error {
+	var fields []httplib.FieldError
+	if fe := f.normalize(); fe != nil {
+		fields = append(fields, *fe)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fields = append(fields, httplib.FieldError{Field: "name", Code: "required", Message: "name is required"})
+	}
+	if len(name) > 100 {
+		fields = append(fields, httplib.FieldError{Field: "name", Code: "too_long", Message: "name must be at most 100 characters"})
+	}
+	if f.empty() {
+		fields = append(fields, httplib.FieldError{Field: "filters", Code: "required", Message: "at least one filter is required"})
+	}
+	if f.Category != nil && !slices.Contains(validCategories, *f.Category) {
+		fields = append(fields, httplib.FieldError{Field: "filters.category", Code: "invalid", Message: "invalid category"})
+	}
+	if f.Status != nil && !slices.Contains(validStatuses, *f.Status) {
+		fields = append(fields, httplib.FieldError{Field: "filters.status", Code: "invalid", Message: "invalid status"})
+	}
+	if f.Condition != nil && !slices.Contains(validConditions, *f.Condition) {
+		fields = append(fields, httplib.FieldError{Field: "filters.condition", Code: "invalid", Message: "invalid condition"})
+	}
+	if (f.MinPrice != nil && *f.MinPrice < 0) || (f.MaxPrice != nil && *f.MaxPrice < 0) {
+		fields = append(fields, httplib.FieldError{Field: "filters.min_price", Code: "out_of_range", Message: "prices must be non-negative"})
+	} else if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		fields = append(fields, httplib.FieldError{Field: "filters.max_price", Code: "out_of_range", Message: "max_price must not be below min_price"})
+	}
+	if !slices.Contains(validSorts, f.Sort) {
+		fields = append(fields, httplib.FieldError{Field: "filters.sort", Code: "invalid", Message: "invalid sort"})
+	}
+	if len(fields) > 0 {
+		return httplib.ValidationProblem(fields[0].Message, fields...)
+	}
+	return nil
+}
+
+// userAndID reads the caller and the {id} path value, writing an error if
+// either is missing or malformed.
+func userAndID(w http.ResponseWriter, r *http.Request) (string, int64, bool) {
+	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if !ok {
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Invalid saved search ID format")
+		return "", 0, false
+	}
+	return userID, id, true
+}
+
+// writeSavedSearchError maps repository errors to problem responses;
+// validation problems pass through WriteServiceError unchanged
+func writeSavedSearchError(w http.ResponseWriter, detail string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httplib.WriteError(w, http.StatusNotFound, "Not found", err.Error())
+	case errors.Is(err, ErrNameTaken):
+		httplib.WriteError(w, http.StatusConflict, "Conflict", err.Error())
+	case errors.Is(err, ErrLimitReached):
+		httplib.WriteError(w, http.StatusUnprocessableEntity, "Limit reached", err.Error())
+	default:
+		httplib.WriteServiceError(w, http.StatusInternalServerError, detail, err)
+	}
+}
+
+// ListSavedSearchesHandler handles listing the caller's saved searches
+func (e *Endpoints) ListSavedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if !ok {
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+
+	response, err := e.service.List(r.Context(), userID)
+	if err != nil {
+		writeSavedSearchError(w, "Failed to fetch saved searches", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetSavedSearchHandler handles getting one of the caller's saved searches
+func (e *Endpoints) GetSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := e.service.Get(r.Context(), userID, id)
+	if err != nil {
+		writeSavedSearchError(w, "Failed to fetch saved search", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// CreateSavedSearchHandler handles saving a search. The filters can be typed
+// or taken from the filters of a chat search response.
+func (e *Endpoints) CreateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
+	if !ok {
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+
+	var req CreateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateSavedSearch(req.Name, &req.Filters); err != nil {
+		httplib.WriteServiceError(w, http.StatusBadRequest, "Validation error", err)
+		return
+	}
+
+	response, err := e.service.Create(r.Context(), userID, req)
+	if err != nil {
+		writeSavedSearchError(w, "Failed to save search", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusCreated, response)
+}
+
+// UpdateSavedSearchHandler handles renaming a saved search, replacing its
+// filters or toggling its email digest
+func (e *Endpoints) UpdateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
+		return
+	}
+
+	// The service validates the search as it will be after the update
+	response, err := e.service.Update(r.Context(), userID, id, req)
+	if err != nil {
+		writeSavedSearchError(w, "Failed to update saved search", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteSavedSearchHandler handles deleting one of the caller's saved searches
+func (e *Endpoints) DeleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.service.Delete(r.Context(), userID, id); err != nil {
+		writeSavedSearchError(w, "Failed to delete saved search", err)
+		return
+	}
+
+	httplib.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// RegisterRoutes registers all saved search routes with proper middleware
+func (e *Endpoints) RegisterRoutes(mux *http.ServeMux, dbPool *pgxpool.Pool) {
+	// Protected routes: require auth + role injection
+	protected := func(h http.Handler) http.Handler {
+		return httplib.AuthMiddleWare(
+			httplib.RoleInjectionMiddleWare(dbPool)(
+				httplib.JSONRequestDecoder(h),
+			),
+		)
+	}
+
+	mux.Handle("GET /api/saved-searches", protected(http.HandlerFunc(e.ListSavedSearchesHandler)))
+	mux.Handle("POST /api/saved-searches", protected(http.HandlerFunc(e.CreateSavedSearchHandler)))
+	mux.Handle("GET /api/saved-searches/{id}", protected(http.HandlerFunc(e.GetSavedSearchHandler)))
+	mux.Handle("PATCH /api/saved-searches/{id}", protected(http.HandlerFunc(e.UpdateSavedSearchHandler)))
+	mux.Handle("DELETE /api/saved-searches/{id}", protected(http.HandlerFunc(e.DeleteSavedSearchHandler)))
+}
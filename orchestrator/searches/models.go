@@ -0,0 +1,200 @@
+package searches
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	httplib "github.com/kunal768/cmpe202/http-lib"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+// MaxSavedSearches caps how many searches one user can save
+const MaxSavedSearches = 20
+
+// Filters are the listing search filters a saved search re-runs. Query is
+// typed web-search syntax (the keywords parameter of GET /api/listings);
+// Keywords are alternatives as returned in the filters of a chat search.
+// Tags and Attributes must all match, as in the listing search.
+type Filters struct {
+	Query          string             `json:"query,omitempty"`
+	Keywords       []string           `json:"keywords,omitempty"`
+	Category       *listings.Category `json:"category,omitempty"`
+	Status         *listings.Status   `json:"status,omitempty"`
+	MinPrice       *int64             `json:"min_price,omitempty"`
+	MaxPrice       *int64             `json:"max_price,omitempty"`
+	Condition      *string            `json:"condition,omitempty"`
+	PickupLocation *string            `json:"pickup_location,omitempty"`
+	Tags           []string           `json:"tags,omitempty"`
+	Course         *string            `json:"course,omitempty"`
+	Attributes     map[string]string  `json:"attributes,omitempty"`
+	Sort           string             `json:"sort,omitempty"`
+}
+
+// SavedSearch is a named set of filters owned by a user
+type SavedSearch struct {
+	ID          int64     `json:"id"`
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	Filters     Filters   `json:"filters"`
+	EmailDigest bool      `json:"email_digest"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateSavedSearchRequest for saving a search
+type CreateSavedSearchRequest struct {
+	Name        string  `json:"name"`
+	Filters     Filters `json:"filters"`
+	EmailDigest bool    `json:"email_digest"`
+}
+
+// UpdateSavedSearchRequest for renaming a saved search, replacing its
+// filters or toggling the digest. Nil fields are left unchanged.
+type UpdateSavedSearchRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Filters     *Filters `json:"filters,omitempty"`
+	EmailDigest *bool    `json:"email_digest,omitempty"`
+}
+
+// ListSavedSearchesResponse returns the caller's saved searches
+type ListSavedSearchesResponse struct {
+	SavedSearches []SavedSearch `json:"saved_searches"`
+}
+
+// Match is a listing newly matched by a saved search
+type Match struct {
+	SavedSearchID   int64  `json:"saved_search_id"`
+	SavedSearchName string `json:"saved_search_name"`
+	UserID          string `json:"-"`
+	ListingID       int64  `json:"listing_id"`
+	Title           string `json:"title"`
+	Price           int64  `json:"price"`
+}
+
+// DigestEntry is a match waiting to be emailed to Email
+type DigestEntry struct {
+	Match
+	Email string
+}
+
+// searchText returns the websearch_to_tsquery input for f, the same way
+// listing-service builds it: a typed query is used as is and keywords are
+// alternatives, with multi-word keywords matched as phrases.
+func (f Filters) searchText() string {
+	if q := strings.TrimSpace(f.Query); q != "" {
+		return q
+	}
+	terms := make([]string, 0, len(f.Keywords))
+	for _, kw := range f.Keywords {
+		kw = strings.TrimSpace(strings.ReplaceAll(kw, `"`, ""))
+		if kw == "" {
+			continue
+		}
+		if strings.ContainsAny(kw, " \t") {
+			kw = `"` + kw + `"`
+		}
+		terms = append(terms, kw)
+	}
+	return strings.Join(terms, " or ")
+}
+
+// empty reports whether f would match every listing
+func (f Filters) empty() bool {
+	return f.searchText() == "" && f.Category == nil && f.Status == nil && f.MinPrice == nil && f.MaxPrice == nil &&
+		f.Condition == nil && f.PickupLocation == nil && len(f.Tags) == 0 && f.Course == nil && len(f.Attributes) == 0
+}
+
+// normalize canonicalises the structured filters the way listing-service
+// stores listings, so that MatchListing can compare them exactly: tags are
+// lowercased, blank values dropped and course codes, ISBNs and storage sizes
+// reduced to one spelling. It returns the field of the first invalid value.
+func (f *Filters) normalize() *httplib.FieldError {
+	if f.PickupLocation != nil {
+		if p := strings.TrimSpace(*f.PickupLocation); p != "" {
+			f.PickupLocation = &p
+		} else {
+			f.PickupLocation = nil
+		}
+	}
+	var tags []string
+	for _, t := range f.Tags {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t != "" && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	f.Tags = tags
+	if f.Course != nil {
+		code, err := normalizeAttribute(attrCourseCode, *f.Course)
+		if err != nil {
+			return &httplib.FieldError{Field: "filters.course", Code: "invalid", Message: err.Error()}
+		}
+		f.Course = &code
+	}
+	attrs := map[string]string{}
+	for key, value := range f.Attributes {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		v, err := normalizeAttribute(key, value)
+		if err != nil {
+			return &httplib.FieldError{Field: "filters.attributes." + key, Code: "invalid", Message: err.Error()}
+		}
+		attrs[key] = v
+	}
+	f.Attributes = nil
+	if len(attrs) > 0 {
+		f.Attributes = attrs
+	}
+	return nil
+}
+
+// Attribute keys that listing-service stores in one canonical spelling
+const (
+	attrISBN       = "isbn"
+	attrCourseCode = "course_code"
+	attrStorage    = "storage"
+)
+
+var (
+	courseCodePattern = regexp.MustCompile(`^[A-Z]{2,6}[0-9]{1,4}[A-Z]?$`)
+	storagePattern    = regexp.MustCompile(`^[0-9]+(MB|GB|TB)$`)
+	isbnPattern       = regexp.MustCompile(`^([0-9]{13}|[0-9]{9}[0-9X])$`)
+)
+
+// normalizeAttribute mirrors listing-service's attribute normalisation: ISBN-10s
+// become ISBN-13s, course codes and storage sizes are upper-cased without
+// spaces, and other values are only trimmed.
+func normalizeAttribute(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch key {
+	case attrISBN:
+		value = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+		if !isbnPattern.MatchString(value) {
+			return "", fmt.Errorf("isbn must be an ISBN-10 or ISBN-13")
+		}
+		if len(value) == 10 {
+			value = "978" + value[:9]
+			sum := 0
+			for i, c := range value {
+				sum += int(c-'0') * (1 + 2*(i%2))
+			}
+			value += strconv.Itoa((10 - sum%10) % 10)
+		}
+	case attrCourseCode:
+		value = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(value))
+		if !courseCodePattern.MatchString(value) {
+			return "", fmt.Errorf("course_code must look like CMPE202")
+		}
+	case attrStorage:
+		value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+		if !storagePattern.MatchString(value) {
+			return "", fmt.Errorf("storage must look like 256GB")
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,276 @@
+package searches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+var (
+	ErrNotFound  = errors.New("saved search not found")
+	ErrNameTaken = errors.New("a saved search with this name already exists")
+)
+
+type Repository interface {
+	List(ctx context.Context, userID string) ([]SavedSearch, error)
+	Get(ctx context.Context, userID string, id int64) (*SavedSearch, error)
+	Count(ctx context.Context, userID string) (int, error)
+	Create(ctx context.Context, userID string, req CreateSavedSearchRequest) (*SavedSearch, error)
+	Update(ctx context.Context, userID string, id int64, s SavedSearch) (*SavedSearch, error)
+	Delete(ctx context.Context, userID string, id int64) error
+	MatchListing(ctx context.Context, listingID int64) ([]Match, error)
+	PendingDigest(ctx context.Context) ([]DigestEntry, error)
+	MarkEmailed(ctx context.Context, matches []Match) error
+}
+
+type repo struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repo{
+		db: db,
+	}
+}
+
+const savedSearchColumns = `id, user_id::text, name, COALESCE(query, ''), keywords, category::text, status::text,
+	min_price, max_price, condition::text, pickup_location, tags, course, attributes, COALESCE(sort, ''),
+	email_digest, created_at, updated_at`
+
+func scanSavedSearch(row pgx.Row) (*SavedSearch, error) {
+	var s SavedSearch
+	var category, status *string
+	err := row.Scan(&s.ID, &s.UserID, &s.Name, &s.Filters.Query, &s.Filters.Keywords, &category, &status,
+		&s.Filters.MinPrice, &s.Filters.MaxPrice, &s.Filters.Condition, &s.Filters.PickupLocation, &s.Filters.Tags,
+		&s.Filters.Course, &s.Filters.Attributes, &s.Filters.Sort, &s.EmailDigest, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if category != nil {
+		c := listings.Category(*category)
+		s.Filters.Category = &c
+	}
+	if status != nil {
+		st := listings.Status(*status)
+		s.Filters.Status = &st
+	}
+	return &s, nil
+}
+
+func (r *repo) List(ctx context.Context, userID string) ([]SavedSearch, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+savedSearchColumns+` FROM saved_searches WHERE user_id = $1::uuid ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SavedSearch
+	for rows.Next() {
+		s, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+func (r *repo) Get(ctx context.Context, userID string, id int64) (*SavedSearch, error) {
+	s, err := scanSavedSearch(r.db.QueryRow(ctx,
+		`SELECT `+savedSearchColumns+` FROM saved_searches WHERE id = $1 AND user_id = $2::uuid`, id, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return s, nil
+}
+
+func (r *repo) Count(ctx context.Context, userID string) (int, error) {
+	var n int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM saved_searches WHERE user_id = $1::uuid`, userID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	return n, nil
+}
+
+func (r *repo) Create(ctx context.Context, userID string, req CreateSavedSearchRequest) (*SavedSearch, error) {
+	f := req.Filters
+	s, err := scanSavedSearch(r.db.QueryRow(ctx, `
+		INSERT INTO saved_searches (user_id, name, query, keywords, category, status, min_price, max_price,
+			condition, pickup_location, tags, course, attributes, sort, search_text, email_digest)
+		VALUES ($1::uuid, $2, NULLIF($3, ''), $4, $5, $6, $7, $8,
+			$9, $10, COALESCE($11::text[], '{}'), $12, COALESCE($13::jsonb, '{}'), NULLIF($14, ''), NULLIF($15, ''), $16)
+		RETURNING `+savedSearchColumns,
+		userID, req.Name, f.Query, f.Keywords, f.Category, f.Status, f.MinPrice, f.MaxPrice,
+		f.Condition, f.PickupLocation, f.Tags, f.Course, f.Attributes, f.Sort, f.searchText(), req.EmailDigest))
+	if err != nil {
+		return nil, savedSearchWriteError(err)
+	}
+	return s, nil
+}
+
+func (r *repo) Update(ctx context.Context, userID string, id int64, s SavedSearch) (*SavedSearch, error) {
+	f := s.Filters
+	out, err := scanSavedSearch(r.db.QueryRow(ctx, `
+		UPDATE saved_searches
+		SET name = $3, query = NULLIF($4, ''), keywords = $5, category = $6, status = $7, min_price = $8,
+			max_price = $9, condition = $10, pickup_location = $11, tags = COALESCE($12::text[], '{}'), course = $13,
+			attributes = COALESCE($14::jsonb, '{}'), sort = NULLIF($15, ''), search_text = NULLIF($16, ''),
+			email_digest = $17, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2::uuid
+		RETURNING `+savedSearchColumns,
+		id, userID, s.Name, f.Query, f.Keywords, f.Category, f.Status, f.MinPrice, f.MaxPrice,
+		f.Condition, f.PickupLocation, f.Tags, f.Course, f.Attributes, f.Sort, f.searchText(), s.EmailDigest))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, savedSearchWriteError(err)
+	}
+	return out, nil
+}
+
+func (r *repo) Delete(ctx context.Context, userID string, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2::uuid`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func savedSearchWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrNameTaken
+	}
+	return fmt.Errorf("failed to save search: %w", err)
+}
+
+// MatchListing records the saved searches of other users that listingID now
+// satisfies and returns only the matches that are new. A saved search without
+// a status filter only matches AVAILABLE listings. The structured filters
+// match the way listing-service's search does: pickup location as a
+// case-insensitive substring, a course by its code or its books in any
+// edition, an ISBN in any edition and free-text attributes case-insensitively.
+func (r *repo) MatchListing(ctx context.Context, listingID int64) ([]Match, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH inserted AS (
+			INSERT INTO saved_search_matches (saved_search_id, listing_id)
+			SELECT ss.id, l.id
+			FROM saved_searches ss
+			JOIN listings l ON l.id = $1
+			WHERE ss.user_id <> l.user_id
+			  AND l.status = COALESCE(ss.status, 'AVAILABLE')
+			  AND l.status <> 'DRAFT'
+			  AND l.deleted_at IS NULL
+			  AND (ss.category IS NULL OR ss.category = l.category)
+			  AND (ss.min_price IS NULL OR l.price >= ss.min_price)
+			  AND (ss.max_price IS NULL OR l.price <= ss.max_price)
+			  AND (ss.search_text IS NULL OR l.search_vector @@ websearch_to_tsquery('english', ss.search_text))
+			  AND (ss.condition IS NULL OR l.condition = ss.condition)
+			  AND (ss.pickup_location IS NULL OR l.pickup_location ILIKE
+				'%' || replace(replace(replace(ss.pickup_location, '\', '\\'), '%', '\%'), '_', '\_') || '%')
+			  AND l.tags @> ss.tags
+			  AND (ss.course IS NULL OR l.attributes->>'course_code' = ss.course OR l.attributes->>'isbn' IN (
+				SELECT b.isbn FROM book_metadata b
+				JOIN book_metadata q ON q.work_id = b.work_id
+				JOIN course_books cb ON cb.isbn = q.isbn
+				WHERE cb.course_code = ss.course
+				UNION
+				SELECT cb.isbn FROM course_books cb WHERE cb.course_code = ss.course))
+			  AND NOT EXISTS (
+				SELECT 1 FROM jsonb_each_text(ss.attributes) a(key, value)
+				WHERE NOT COALESCE(CASE
+					WHEN a.key = 'isbn' THEN l.attributes->>'isbn' = a.value OR l.attributes->>'isbn' IN (
+						SELECT b.isbn FROM book_metadata b JOIN book_metadata q ON q.work_id = b.work_id WHERE q.isbn = a.value)
+					WHEN a.key IN ('course_code', 'storage') THEN l.attributes->>a.key = a.value
+					ELSE lower(l.attributes->>a.key) = lower(a.value)
+				END, FALSE))
+			ON CONFLICT DO NOTHING
+			RETURNING saved_search_id, listing_id
+		)
+		SELECT ss.id, ss.name, ss.user_id::text, l.id, l.title, l.price
+		FROM inserted i
+		JOIN saved_searches ss ON ss.id = i.saved_search_id
+		JOIN listings l ON l.id = i.listing_id
+	`, listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Match
+	for rows.Next() {
+		var m Match
+		if err := rows.Scan(&m.SavedSearchID, &m.SavedSearchName, &m.UserID, &m.ListingID, &m.Title, &m.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search match: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// PendingDigest returns the matches of digest-enabled searches from the last
+// week that have not been emailed yet and are still for sale, grouped by
+// recipient.
+func (r *repo) PendingDigest(ctx context.Context) ([]DigestEntry, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT ss.id, ss.name, ss.user_id::text, l.id, l.title, l.price, u.email
+		FROM saved_search_matches m
+		JOIN saved_searches ss ON ss.id = m.saved_search_id
+		JOIN listings l ON l.id = m.listing_id
+		JOIN users u ON u.user_id = ss.user_id
+		WHERE m.emailed_at IS NULL
+		  AND l.deleted_at IS NULL
+		  AND l.status = 'AVAILABLE'
+		  AND ss.email_digest
+		  AND m.matched_at > NOW() - INTERVAL '7 days'
+		ORDER BY ss.user_id, ss.name, m.matched_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saved search digest: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DigestEntry
+	for rows.Next() {
+		var d DigestEntry
+		if err := rows.Scan(&d.SavedSearchID, &d.SavedSearchName, &d.UserID, &d.ListingID, &d.Title, &d.Price, &d.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search digest: %w", err)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *repo) MarkEmailed(ctx context.Context, matches []Match) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	var values []string
+	var args []any
+	for _, m := range matches {
+		args = append(args, m.SavedSearchID, m.ListingID)
+		values = append(values, fmt.Sprintf("($%d::bigint, $%d::integer)", len(args)-1, len(args)))
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE saved_search_matches m SET emailed_at = NOW()
+		FROM (VALUES `+strings.Join(values, ", ")+`) AS v(saved_search_id, listing_id)
+		WHERE m.saved_search_id = v.saved_search_id AND m.listing_id = v.listing_id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to mark saved search matches emailed: %w", err)
+	}
+	return nil
+}
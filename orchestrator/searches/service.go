@@ -0,0 +1,201 @@
+package searches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kunal768/cmpe202/orchestrator/internal/mail"
+	"github.com/kunal768/cmpe202/orchestrator/internal/notify"
+	"github.com/kunal768/cmpe202/orchestrator/listings"
+)
+
+var ErrLimitReached = fmt.Errorf("at most %d saved searches are allowed", MaxSavedSearches)
+
+type Service interface {
+	List(ctx context.Context, userID string) (*ListSavedSearchesResponse, error)
+	Get(ctx context.Context, userID string, id int64) (*SavedSearch, error)
+	Create(ctx context.Context, userID string, req CreateSavedSearchRequest) (*SavedSearch, error)
+	Update(ctx context.Context, userID string, id int64, req UpdateSavedSearchRequest) (*SavedSearch, error)
+	Delete(ctx context.Context, userID string, id int64) error
+	// ListingChanged implements listings.ListingWatcher
+	ListingChanged(ctx context.Context, listing listings.Listing)
+	// RunDigests emails pending matches every interval until ctx is done
+	RunDigests(ctx context.Context, interval time.Duration)
+}
+
+type service struct {
+	repo      Repository
+	notifier  notify.Publisher
+	mailer    mail.Mailer
+	publicURL string
+}
+
+// NewService creates the saved search service. notifier and mailer are
+// optional; without them real-time alerts or email digests are skipped.
+// publicURL is the frontend origin used for links in digests.
+func NewService(repo Repository, notifier notify.Publisher, mailer mail.Mailer, publicURL string) Service {
+	return &service{
+		repo:      repo,
+		notifier:  notifier,
+		mailer:    mailer,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+func (s *service) List(ctx context.Context, userID string) (*ListSavedSearchesResponse, error) {
+	out, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = []SavedSearch{}
+	}
+	return &ListSavedSearchesResponse{SavedSearches: out}, nil
+}
+
+func (s *service) Get(ctx context.Context, userID string, id int64) (*SavedSearch, error) {
+	return s.repo.Get(ctx, userID, id)
+}
+
+func (s *service) Create(ctx context.Context, userID string, req CreateSavedSearchRequest) (*SavedSearch, error) {
+	n, err := s.repo.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= MaxSavedSearches {
+		return nil, ErrLimitReached
+	}
+	return s.repo.Create(ctx, userID, req)
+}
+
+func (s *service) Update(ctx context.Context, userID string, id int64, req UpdateSavedSearchRequest) (*SavedSearch, error) {
+	current, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		current.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Filters != nil {
+		current.Filters = *req.Filters
+	}
+	if req.EmailDigest != nil {
+		current.EmailDigest = *req.EmailDigest
+	}
+	if err := validateSavedSearch(current.Name, &current.Filters); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, userID, id, *current)
+}
+
+func (s *service) Delete(ctx context.Context, userID string, id int64) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// ListingChanged matches a created or updated listing against saved searches
+// in the background, so the listing write does not wait on the fan-out.
+func (s *service) ListingChanged(ctx context.Context, listing listings.Listing) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		matches, err := s.repo.MatchListing(ctx, listing.ID)
+		if err != nil {
+			log.Printf("Failed to match listing %d against saved searches: %v", listing.ID, err)
+			return
+		}
+		if s.notifier == nil {
+			return
+		}
+		for _, m := range matches {
+			n := notify.Notification{SubType: notify.SubTypeSavedSearch, Count: 1, Data: m}
+			if err := s.notifier.Notify(ctx, m.UserID, n); err != nil {
+				log.Printf("Failed to notify user %s of saved search match: %v", m.UserID, err)
+			}
+		}
+	}()
+}
+
+func (s *service) RunDigests(ctx context.Context, interval time.Duration) {
+	if s.mailer == nil {
+		log.Println("No mailer configured; saved search email digests disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.sendDigests(ctx); err != nil {
+				log.Printf("Failed to send saved search digests: %v", err)
+			}
+		}
+	}
+}
+
+// sendDigests sends one email per user listing their new matches. Matches are
+// only marked emailed once their email went out, so failures are retried on
+// the next run.
+func (s *service) sendDigests(ctx context.Context) error {
+	pending, err := s.repo.PendingDigest(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for len(pending) > 0 {
+		// PendingDigest orders by user, so each user's entries are contiguous
+		end := 1
+		for end < len(pending) && pending[end].UserID == pending[0].UserID {
+			end++
+		}
+		batch := pending[:end]
+		pending = pending[end:]
+
+		if err := s.mailer.Send(ctx, batch[0].Email, digestSubject(batch), s.digestBody(batch)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		matches := make([]Match, len(batch))
+		for i, d := range batch {
+			matches[i] = d.Match
+		}
+		if err := s.repo.MarkEmailed(ctx, matches); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func digestSubject(batch []DigestEntry) string {
+	if len(batch) == 1 {
+		return fmt.Sprintf("New listing for your saved search %q", batch[0].SavedSearchName)
+	}
+	return fmt.Sprintf("%d new listings for your saved searches", len(batch))
+}
+
+func (s *service) digestBody(batch []DigestEntry) string {
+	var b strings.Builder
+	b.WriteString("New listings match your saved searches:\n")
+	name := ""
+	for _, d := range batch {
+		if d.SavedSearchName != name {
+			name = d.SavedSearchName
+			fmt.Fprintf(&b, "\n%s\n", name)
+		}
+		fmt.Fprintf(&b, "  - %s, $%d.%02d", d.Title, d.Price/100, d.Price%100)
+		if s.publicURL != "" {
+			fmt.Fprintf(&b, " %s/listing/%d", s.publicURL, d.ListingID)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nYou can turn these emails off in your saved searches.\n")
+	return b.String()
+}
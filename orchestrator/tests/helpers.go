@@ -123,7 +123,7 @@ func setupTestServerOnce(t *testing.T) {
 		listingSharedSecret = "test-secret" // Default for testing
 	}
 	listingService := listings.NewListingService(listingBaseURL, listingSharedSecret)
-	listingEndpoints := listings.NewEndpoints(listingService)
+	listingEndpoints := listings.NewEndpoints(listingService, nil, nil, nil, nil)
 
 	// Setup HTTP server
 	testMux = http.NewServeMux()
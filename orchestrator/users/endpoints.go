@@ -29,29 +29,20 @@ func (e *Endpoints) SignupHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if err := validateSignupRequest(req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
 	// Call service
 	response, err := e.service.Signup(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusConflict, ErrorResponse{
-			Error:   "Signup failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusConflict, "Signup failed", err)
 		return
 	}
 
@@ -64,29 +55,20 @@ func (e *Endpoints) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if err := validateLoginRequest(req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
 	// Call service
 	response, err := e.service.Login(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Login failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusUnauthorized, "Login failed", err)
 		return
 	}
 
@@ -98,10 +80,7 @@ func (e *Endpoints) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
@@ -111,10 +90,7 @@ func (e *Endpoints) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	user, err := e.service.GetUserByID(r.Context(), userID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-			Error:   "User not found",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusNotFound, "User not found", err)
 		return
 	}
 
@@ -133,29 +109,20 @@ func (e *Endpoints) RefreshTokenHandler(w http.ResponseWriter, r *http.Request)
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if req.RefreshToken == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "Refresh token is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "Refresh token is required")
 		return
 	}
 
 	// Call service
 	response, err := e.service.RefreshToken(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Token refresh failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusUnauthorized, "Token refresh failed", err)
 		return
 	}
 
@@ -168,48 +135,33 @@ func (e *Endpoints) EventsVerifyHandler(w http.ResponseWriter, r *http.Request)
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if req.UserID == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: "User ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Validation error", "User ID is required")
 		return
 	}
 
 	// Get user ID from JWT token context (set by AuthMiddleware)
 	tokenUserID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in token",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in token")
 		return
 	}
 
 	// Verify that the token's user ID matches the requested user ID
 	if tokenUserID != req.UserID {
-		httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-			Error:   "Forbidden",
-			Message: "Token user ID does not match requested user ID",
-		})
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Token user ID does not match requested user ID")
 		return
 	}
 
 	// Verify user exists in database
 	_, err := e.service.GetUserByID(r.Context(), req.UserID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-			Error:   "User not found",
-			Message: "User does not exist",
-		})
+		httplib.WriteError(w, http.StatusNotFound, "User not found", "User does not exist")
 		return
 	}
 
@@ -228,29 +180,20 @@ func (e *Endpoints) SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if !ok {
-		httplib.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found in context",
-		})
+		httplib.WriteError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
 		return
 	}
 
 	// Extract query parameter (required)
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Query parameter 'q' is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Query parameter 'q' is required")
 		return
 	}
 
 	// Validate query length
 	if len(query) < 1 {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Query must be at least 1 character",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Query must be at least 1 character")
 		return
 	}
 
@@ -277,10 +220,7 @@ func (e *Endpoints) SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	results, err := e.service.SearchUsers(r.Context(), query, userID, page, limit)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Search failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Search failed", err)
 		return
 	}
 
@@ -332,29 +272,20 @@ func (e *Endpoints) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Message: "Failed to decode request body",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request body", "Failed to decode request body")
 		return
 	}
 
 	// Validate request
 	if err := validateUpdateUserRequest(req); err != nil {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Validation error",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
 	// Call service
 	response, err := e.service.UpdateUser(r.Context(), req)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Update failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Update failed", err)
 		return
 	}
 
@@ -448,30 +379,21 @@ func (e *Endpoints) GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Check if user is admin
 	isAdmin, _ := checkAdminRole(r)
 	if !isAdmin {
-		httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-			Error:   "Forbidden",
-			Message: "Admin access required",
-		})
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 		return
 	}
 
 	// Extract user ID from URL path
 	userID := r.PathValue("id")
 	if userID == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "User ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "User ID is required")
 		return
 	}
 
 	// Call service
 	user, err := e.service.GetUserByID(r.Context(), userID)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusNotFound, ErrorResponse{
-			Error:   "User not found",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusNotFound, "User not found", err)
 		return
 	}
 
@@ -483,40 +405,36 @@ func (e *Endpoints) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Check if user is admin
 	isAdmin, _ := checkAdminRole(r)
 	if !isAdmin {
-		httplib.WriteJSON(w, http.StatusForbidden, ErrorResponse{
-			Error:   "Forbidden",
-			Message: "Admin access required",
-		})
+		httplib.WriteError(w, http.StatusForbidden, "Forbidden", "Admin access required")
 		return
 	}
 
 	// Extract user ID from URL path
 	userID := r.PathValue("id")
 	if userID == "" {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "User ID is required",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "User ID is required")
 		return
 	}
 
 	// Prevent admin from deleting themselves
 	currentUserID, ok := r.Context().Value(httplib.ContextKey("userId")).(string)
 	if ok && currentUserID == userID {
-		httplib.WriteJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request",
-			Message: "Cannot delete your own account",
-		})
+		httplib.WriteError(w, http.StatusBadRequest, "Invalid request", "Cannot delete your own account")
+		return
+	}
+
+	// Destructive admin actions must say why; the reason goes to the audit log
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	if reason == "" {
+		httplib.WriteProblem(w, httplib.ValidationProblem("A reason is required to delete a user",
+			httplib.FieldError{Field: "reason", Code: "required", Message: "reason is required"}))
 		return
 	}
 
 	// Call service
-	err := e.service.DeleteUser(r.Context(), userID)
+	err := e.service.DeleteUser(r.Context(), userID, reason)
 	if err != nil {
-		httplib.WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error:   "Delete failed",
-			Message: err.Error(),
-		})
+		httplib.WriteServiceError(w, http.StatusInternalServerError, "Delete failed", err)
 		return
 	}
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kunal768/cmpe202/orchestrator/audit"
 	"github.com/kunal768/cmpe202/orchestrator/models"
 )
 
@@ -19,7 +20,7 @@ type Repository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID string) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) (*models.User, error)
-	DeleteUser(ctx context.Context, userID string) error
+	DeleteUser(ctx context.Context, user *models.User, reason string) error
 	SearchUsers(ctx context.Context, query string, excludeUserID string, limit int, offset int) ([]models.User, error)
 
 	// UserAuth operations
@@ -167,11 +168,23 @@ func (r *repo) UpdateUser(ctx context.Context, user *models.User) (*models.User,
 	return &updatedUser, nil
 }
 
-// DeleteUser deletes a user by ID
-func (r *repo) DeleteUser(ctx context.Context, userID string) error {
+// DeleteUser deletes a user and records the deletion, with the user as it
+// was, in the audit log within the same transaction
+func (r *repo) DeleteUser(ctx context.Context, user *models.User, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `DELETE FROM users WHERE user_id = $1`
-	_, err := r.db.Exec(ctx, query, userID)
-	return err
+	if _, err := tx.Exec(ctx, query, user.UserId); err != nil {
+		return err
+	}
+	if err := audit.Record(ctx, tx, audit.ActionUserDelete, "user", user.UserId, reason, user, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // UserAuth methods
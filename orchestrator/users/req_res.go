@@ -53,12 +53,6 @@ type EventsVerifyResponse struct {
 	Valid   bool   `json:"valid"`
 }
 
-// Error Response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
 type SearchUsersResponse struct {
 	Users   []models.User `json:"users"`
 	Page    int           `json:"page"`
@@ -25,7 +25,7 @@ type Service interface {
 	GetUserByID(ctx context.Context, userID string) (*models.User, error)
 	SearchUsers(ctx context.Context, query string, excludeUserID string, page int, limit int) ([]models.User, error)
 	UpdateUser(ctx context.Context, req UpdateUserRequest) (*UpdateUserResponse, error)
-	DeleteUser(ctx context.Context, userID string) error
+	DeleteUser(ctx context.Context, userID string, reason string) error
 }
 
 func NewService(repo Repository, publisher queue.Publisher) Service {
@@ -298,10 +298,11 @@ func (s *svc) UpdateUser(ctx context.Context, req UpdateUserRequest) (*UpdateUse
 	}, nil
 }
 
-// DeleteUser deletes a user and all associated auth records
-func (s *svc) DeleteUser(ctx context.Context, userID string) error {
+// DeleteUser deletes a user and all associated auth records. The reason is
+// kept in the audit log.
+func (s *svc) DeleteUser(ctx context.Context, userID string, reason string) error {
 	// Verify user exists
-	_, err := s.repo.GetUserByID(ctx, userID)
+	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("user not found: %w", err)
 	}
@@ -319,7 +320,7 @@ func (s *svc) DeleteUser(ctx context.Context, userID string) error {
 	}
 
 	// Delete user
-	if err := s.repo.DeleteUser(ctx, userID); err != nil {
+	if err := s.repo.DeleteUser(ctx, user, reason); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 